@@ -2,21 +2,26 @@ package v1alpha1
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook"
 )
 
 const SecretsInjectorFQDN = "secrets-injector.vault.crd.gocardless.com"
@@ -25,17 +30,21 @@ const EnvconsulInjectorFQDN = "envconsul-injector.vault.crd.gocardless.com"
 var FQDNArray = []string{SecretsInjectorFQDN, EnvconsulInjectorFQDN}
 
 type SecretsInjector struct {
-	client  client.Client
-	logger  logr.Logger
-	decoder *admission.Decoder
-	opts    SecretsInjectorOptions
+	client    client.Client
+	apiReader client.Reader
+	logger    logr.Logger
+	decoder   *admission.Decoder
+	opts      SecretsInjectorOptions
 }
 
-func NewSecretsInjector(c client.Client, logger logr.Logger, opts SecretsInjectorOptions) *SecretsInjector {
+// NewSecretsInjector creates the webhook. The apiReader is used to read pods directly
+// from the API server, as we'd rather not cache every pod in the cluster.
+func NewSecretsInjector(c client.Client, apiReader client.Reader, logger logr.Logger, opts SecretsInjectorOptions) *SecretsInjector {
 	return &SecretsInjector{
-		client: c,
-		logger: logger,
-		opts:   opts,
+		client:    c,
+		apiReader: apiReader,
+		logger:    logger,
+		opts:      opts,
 	}
 }
 
@@ -49,67 +58,36 @@ type SecretsInjectorOptions struct {
 	InstallPath                 string           // location of vault installation directory
 	NamespaceLabel              string           // namespace label that enables webhook to operate on
 	VaultConfigMapKey           client.ObjectKey // reference to the vault config configMap
+	NamespaceVaultConfigMapName string           // name of the optional per-namespace vault config configMap
 	ServiceAccountTokenFile     string           // mount path of our projected service account token
 	ServiceAccountTokenExpiry   time.Duration    // Kubelet expiry for the service account token
 	ServiceAccountTokenAudience string           // optional token audience
+	SecretsPath                 string           // mount path of the shared secrets volume, in sidecar mode
 }
 
 var (
-	podLabels   = []string{"pod_namespace"}
-	handleTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "theatre_vault_secrets_injector_handle_total",
-			Help: "Count of requests handled by the webhook",
-		},
-		podLabels,
-	)
-	mutateTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "theatre_vault_secrets_injector_mutate_total",
-			Help: "Count of pods mutated by the webhook",
-		},
-		podLabels,
-	)
-	skipTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "theatre_vault_secrets_injector_skip_total",
-			Help: "Count of pods skipped by the webhook, as they lack annotations",
-		},
-		podLabels,
-	)
-	errorsTotal = prometheus.NewCounterVec(
+	configSourceTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "theatre_vault_secrets_injector_errors_total",
-			Help: "Count of not-allowed responses from webhook",
+			Name: "theatre_vault_secrets_injector_config_source_total",
+			Help: "Count of vault config fields resolved by the webhook, by the source that provided them",
 		},
-		podLabels,
+		[]string{"pod_namespace", "field", "source"},
 	)
 )
 
 func init() {
 	// Register custom metrics with the global controller runtime prometheus registry
-	metrics.Registry.MustRegister(handleTotal, mutateTotal, skipTotal, errorsTotal)
+	metrics.Registry.MustRegister(configSourceTotal)
 }
 
-func (i *SecretsInjector) Handle(ctx context.Context, req admission.Request) (resp admission.Response) {
-	labels := prometheus.Labels{"pod_namespace": req.Namespace}
+func (i *SecretsInjector) Handle(ctx context.Context, req admission.Request) admission.Response {
 	logger := i.logger.WithValues("uuid", string(req.UID))
-	logger.Info("starting request", "event", "request.start")
-	defer func(start time.Time) {
-		logger.Info("request completed", "event", "request.end", "duration", time.Since(start).Seconds())
-
-		handleTotal.With(labels).Inc()
-		{ // add 0 to initialise the metrics
-			mutateTotal.With(labels).Add(0)
-			skipTotal.With(labels).Add(0)
-			errorsTotal.With(labels).Add(0)
-		}
 
-		// Catch any Allowed=false responses, as this means we've failed to accept this pod
-		if !resp.Allowed {
-			errorsTotal.With(labels).Inc()
-		}
-	}(time.Now())
+	// Ephemeral containers are added to existing pods through a subresource, rather than
+	// as part of the pod creation.
+	if req.SubResource == "ephemeralcontainers" {
+		return i.handleEphemeralContainers(ctx, logger, req)
+	}
 
 	pod := &corev1.Pod{}
 	if err := i.decoder.Decode(req, pod); err != nil {
@@ -122,10 +100,14 @@ func (i *SecretsInjector) Handle(ctx context.Context, req admission.Request) (re
 	// code futher along returns an error.
 	if _, ok := getFQDNConfig(pod.Annotations, FQDNArray); !ok {
 		logger.Info("skipping pod with no annotation", "event", "pod.skipped", "msg", "no annotation found")
-		skipTotal.With(labels).Inc()
 		return admission.Allowed("no annotation found")
 	}
 
+	if _, err := parseInjectionMode(*pod); err != nil {
+		logger.Info("invalid injection mode", "event", "pod.invalid", "error", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
 	// ensure the pod has a namespace if it has one as we use it in the secretMountPathPrefix
 	pod.Namespace = req.AdmissionRequest.Namespace
 
@@ -139,14 +121,7 @@ func (i *SecretsInjector) Handle(ctx context.Context, req admission.Request) (re
 		"pod_name", pod.Name,
 	)
 
-	mutateTotal.With(labels).Inc() // we're committed to mutating this pod now
-
-	vaultConfigMap := &corev1.ConfigMap{}
-	if err := i.client.Get(ctx, i.opts.VaultConfigMapKey, vaultConfigMap); err != nil {
-		logger.Info("vault config error", "event", "vault.config", "error", err)
-		return admission.Errored(http.StatusInternalServerError, err)
-	}
-	vaultConfig, err := newVaultConfig(vaultConfigMap)
+	vaultConfig, err := i.getVaultConfig(ctx, logger, *pod)
 	if err != nil {
 		logger.Info("vault config error", "event", "vault.config", "error", err)
 		return admission.Errored(http.StatusInternalServerError, err)
@@ -158,16 +133,107 @@ func (i *SecretsInjector) Handle(ctx context.Context, req admission.Request) (re
 		return admission.Allowed("no annotation found")
 	}
 
-	mutatedPodBytes, err := json.Marshal(mutatedPod)
+	patch, err := webhook.Diff(pod, mutatedPod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return patch.Response("")
+}
+
+// handleEphemeralContainers injects theatre-secrets into ephemeral containers that are
+// being added to a running pod, provided the pod was injected when it was created.
+func (i *SecretsInjector) handleEphemeralContainers(ctx context.Context, logger logr.Logger, req admission.Request) admission.Response {
+	ecs := &corev1.EphemeralContainers{}
+	if err := i.decoder.Decode(req, ecs); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if _, ok := getFQDNConfig(ecs.Annotations, FQDNArray); !ok {
+		logger.Info("skipping ephemeral containers with no annotation", "event", "pod.skipped", "msg", "no annotation found")
+		return admission.Allowed("no annotation found")
+	}
+
+	logger = logger.WithValues(
+		"pod_namespace", req.Namespace,
+		"pod_name", req.Name,
+	)
+
+	// The ephemeral containers object doesn't carry the pod spec, which we need to
+	// determine the service account and whether the pod has our volumes.
+	pod := &corev1.Pod{}
+	if err := i.apiReader.Get(ctx, client.ObjectKey{Namespace: req.Namespace, Name: req.Name}, pod); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	vaultConfig, err := i.getVaultConfig(ctx, logger, *pod)
+	if err != nil {
+		logger.Info("vault config error", "event", "vault.config", "error", err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	mutatedECs := podInjector{SecretsInjectorOptions: i.opts, vaultConfig: vaultConfig}.InjectEphemeralContainers(*pod, *ecs)
+	if mutatedECs == nil {
+		logger.Info("no ephemeral containers to inject", "event", "pod.skipped", "msg", "no ephemeral containers to inject")
+		return admission.Allowed("no ephemeral containers to inject")
+	}
+
+	patch, err := webhook.Diff(ecs, mutatedECs)
 	if err != nil {
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
-	return admission.PatchResponseFromRaw(req.Object.Raw, mutatedPodBytes)
+	return patch.Response("")
+}
+
+// getVaultConfig resolves the Vault configuration for the given pod. Each field is taken
+// from the first of these sources that sets it:
+//
+//   1. pod annotations, such as secrets-injector.vault.crd.gocardless.com/auth-role
+//   2. the namespace vault config configMap, if present in the pod's namespace
+//   3. the cluster-global vault config configMap
+//
+// The resulting config is validated before we use it to mutate the pod.
+func (i *SecretsInjector) getVaultConfig(ctx context.Context, logger logr.Logger, pod corev1.Pod) (vaultConfig, error) {
+	globalConfigMap := &corev1.ConfigMap{}
+	if err := i.client.Get(ctx, i.opts.VaultConfigMapKey, globalConfigMap); err != nil {
+		return vaultConfig{}, errors.Wrap(err, "failed to get global vault config")
+	}
+
+	sources := []vaultConfigSource{
+		{Name: vaultConfigSourcePod, Data: vaultConfigFromAnnotations(pod.Annotations)},
+	}
+
+	if i.opts.NamespaceVaultConfigMapName != "" {
+		namespaceConfigMap := &corev1.ConfigMap{}
+		err := i.client.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: i.opts.NamespaceVaultConfigMapName}, namespaceConfigMap)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return vaultConfig{}, errors.Wrap(err, "failed to get namespace vault config")
+		}
+
+		sources = append(sources, vaultConfigSource{Name: vaultConfigSourceNamespace, Data: namespaceConfigMap.Data})
+	}
+
+	sources = append(sources, vaultConfigSource{Name: vaultConfigSourceGlobal, Data: globalConfigMap.Data})
+
+	cfg, resolvedSources, err := resolveVaultConfig(sources...)
+	if err != nil {
+		return vaultConfig{}, err
+	}
+
+	for key, source := range resolvedSources {
+		configSourceTotal.With(prometheus.Labels{
+			"pod_namespace": pod.Namespace, "field": key, "source": source,
+		}).Inc()
+	}
+
+	logger.Info("resolved vault config", "event", "vault.config", "sources", resolvedSources)
+
+	return cfg, cfg.Validate()
 }
 
 // vaultConfig specifies the structure we expect to find in a cluster-global namespace,
 // which we intend to be provisioned as part of whatever process generates the auth
-// backend in Vault.
+// backend in Vault. Namespaces and pods can override individual fields, should they
+// need to talk to a different Vault or authenticate with a different role.
 //
 // If we can't parse the configmap into this structure, we should fail our webhook.
 type vaultConfig struct {
@@ -177,9 +243,93 @@ type vaultConfig struct {
 	SecretMountPathPrefix string `mapstructure:"secret_mount_path_prefix"`
 }
 
-func newVaultConfig(cfgmap *corev1.ConfigMap) (vaultConfig, error) {
+// vaultConfigKeys maps each vaultConfig key to the pod annotation that can override it
+var vaultConfigKeys = map[string]string{
+	"address":                  fmt.Sprintf("%s/vault-address", SecretsInjectorFQDN),
+	"auth_mount_path":          fmt.Sprintf("%s/auth-mount-path", SecretsInjectorFQDN),
+	"auth_role":                fmt.Sprintf("%s/auth-role", SecretsInjectorFQDN),
+	"secret_mount_path_prefix": fmt.Sprintf("%s/secret-mount-path-prefix", SecretsInjectorFQDN),
+}
+
+const (
+	vaultConfigSourcePod       = "pod"
+	vaultConfigSourceNamespace = "namespace"
+	vaultConfigSourceGlobal    = "global"
+)
+
+// vaultConfigSource is a set of vaultConfig values, keyed as they are in the configMap
+type vaultConfigSource struct {
+	Name string
+	Data map[string]string
+}
+
+func vaultConfigFromAnnotations(annotations map[string]string) map[string]string {
+	data := map[string]string{}
+	for key, annotation := range vaultConfigKeys {
+		if value, ok := annotations[annotation]; ok {
+			data[key] = value
+		}
+	}
+
+	return data
+}
+
+// resolveVaultConfig builds a vaultConfig from the given sources, in order of precedence.
+// It returns the name of the source that provided each key, for observability.
+func resolveVaultConfig(sources ...vaultConfigSource) (vaultConfig, map[string]string, error) {
+	data, resolvedSources := map[string]string{}, map[string]string{}
+	for key := range vaultConfigKeys {
+		for _, source := range sources {
+			if value := strings.TrimSpace(source.Data[key]); value != "" {
+				data[key], resolvedSources[key] = value, source.Name
+				break
+			}
+		}
+	}
+
 	var cfg vaultConfig
-	return cfg, mapstructure.Decode(cfgmap.Data, &cfg)
+	return cfg, resolvedSources, mapstructure.Decode(data, &cfg)
+}
+
+// Validate checks the vaultConfig is complete, and that each field is safe to pass to
+// theatre-secrets. As pods and namespaces can supply their own values, we can't assume
+// the config is well formed.
+func (c vaultConfig) Validate() error {
+	var result *multierror.Error
+
+	if c.Address == "" {
+		result = multierror.Append(result, fmt.Errorf("address must be set"))
+	} else if u, err := url.Parse(c.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		result = multierror.Append(result, fmt.Errorf("address must be an http(s) URL, got %q", c.Address))
+	}
+
+	for name, value := range map[string]string{
+		"auth_mount_path":          c.AuthMountPath,
+		"auth_role":                c.AuthRole,
+		"secret_mount_path_prefix": c.SecretMountPathPrefix,
+	} {
+		if value == "" {
+			result = multierror.Append(result, fmt.Errorf("%s must be set", name))
+		} else if containsDotDot(value) {
+			result = multierror.Append(result, fmt.Errorf("%s must not contain '..', got %q", name, value))
+		}
+	}
+
+	if err := result.ErrorOrNil(); err != nil {
+		return errors.Wrap(err, "invalid vault config")
+	}
+
+	return nil
+}
+
+func containsDotDot(value string) bool {
+	for _, elem := range strings.Split(value, "/") {
+		if elem == ".." {
+			return true
+		}
+	}
+
+	return false
 }
 
 // podInjector isolates the logic around injecting theatre-secrets away from anything to
@@ -199,9 +349,39 @@ func (i podInjector) Inject(pod corev1.Pod) *corev1.Pod {
 	}
 
 	mutatedPod := pod.DeepCopy()
-	expirySeconds := int64(i.ServiceAccountTokenExpiry / time.Second)
+	secretMountPathPrefix := i.secretMountPathPrefix(pod)
+
+	// If we don't already have an fsGroup set, we'll need to configure it so we can read
+	// the contents of the volumes we mount. Failing to do this will prevent us from reading
+	// the projected service account token.
+	if mutatedPod.Spec.SecurityContext == nil {
+		mutatedPod.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	if mutatedPod.Spec.SecurityContext.FSGroup == nil {
+		defaultFSGroup := int64(1000)
+		mutatedPod.Spec.SecurityContext.FSGroup = &defaultFSGroup
+	}
+
+	if mode, _ := parseInjectionMode(pod); mode == injectionModeSidecar {
+		i.injectSidecars(mutatedPod, containerConfigs, secretMountPathPrefix)
+		return mutatedPod
+	}
+
+	// Init containers that we inject into need theatre-secrets to be installed before
+	// they run, so place our install container before the first of them. If there are
+	// none, we run last to avoid delaying any existing init containers.
+	installIdx := len(mutatedPod.Spec.InitContainers)
+	for idx, container := range mutatedPod.Spec.InitContainers {
+		if _, ok := containerConfigs[container.Name]; ok {
+			installIdx = idx
+			break
+		}
+	}
+
+	initContainers := append([]corev1.Container{}, mutatedPod.Spec.InitContainers[:installIdx]...)
+	initContainers = append(initContainers, i.buildInitContainer())
+	mutatedPod.Spec.InitContainers = append(initContainers, mutatedPod.Spec.InitContainers[installIdx:]...)
 
-	mutatedPod.Spec.InitContainers = append(mutatedPod.Spec.InitContainers, i.buildInitContainer())
 	mutatedPod.Spec.Volumes = append(
 		mutatedPod.Spec.Volumes,
 		// Installation directory for theatre binaries, used as a scratch installation path
@@ -211,42 +391,73 @@ func (i podInjector) Inject(pod corev1.Pod) *corev1.Pod {
 				EmptyDir: &corev1.EmptyDirVolumeSource{},
 			},
 		},
-		// Projected service account tokens that are automatically rotated, unlike the default
-		// service account tokens Kubernetes normally mounts.
+		i.buildServiceAccountVolume(),
+	)
+
+	for idx, container := range mutatedPod.Spec.InitContainers {
+		containerConfigPath, ok := containerConfigs[container.Name]
+		if !ok || idx == installIdx {
+			continue
+		}
+
+		mutatedPod.Spec.InitContainers[idx] = i.configureContainer(container, containerConfigPath, secretMountPathPrefix)
+	}
+
+	for idx, container := range mutatedPod.Spec.Containers {
+		containerConfigPath, ok := containerConfigs[container.Name]
+		if !ok {
+			continue
+		}
+
+		mutatedPod.Spec.Containers[idx] = i.configureContainer(container, containerConfigPath, secretMountPathPrefix)
+	}
+
+	return mutatedPod
+}
+
+// injectSidecars configures the pod to deliver secrets as files, without modifying the
+// targeted containers' commands. Each targeted container gets a theatre-secrets sidecar
+// that writes its secrets into a shared in-memory volume and keeps them refreshed, and
+// unless disabled, an init container that writes them before the application starts.
+//
+// Sidecars run forever, so pods that aren't restarted would never complete with one.
+// Those pods only get the init container, which writes the secrets once, as do any
+// targeted init containers, which run before sidecars could start.
+//
+// The sidecar resolves secrets from the same environment variables as the targeted
+// container, so we copy them across.
+func (i podInjector) injectSidecars(mutatedPod *corev1.Pod, containerConfigs map[string]string, secretMountPathPrefix string) {
+	mutatedPod.Spec.Volumes = append(
+		mutatedPod.Spec.Volumes,
+		// Secrets should never touch the node's disk, so we hold them in memory
 		corev1.Volume{
-			Name: "theatre-secrets-serviceaccount",
+			Name: "theatre-secrets-files",
 			VolumeSource: corev1.VolumeSource{
-				Projected: &corev1.ProjectedVolumeSource{
-					// Ensure this token is readable by whatever user the container might run in, as
-					// your application might run with a non-root user but must be able to access
-					// its secrets.
-					DefaultMode: func() *int32 { mode := int32(444); return &mode }(),
-					Sources: []corev1.VolumeProjection{
-						{
-							ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
-								Path:              path.Base(i.ServiceAccountTokenFile),
-								ExpirationSeconds: &expirySeconds,
-								Audience:          i.ServiceAccountTokenAudience,
-							},
-						},
-					},
-				},
+				EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
 			},
 		},
+		i.buildServiceAccountVolume(),
 	)
 
-	// If we don't already have an fsGroup set, we'll need to configure it so we can read
-	// the contents of the volumes we mount. Failing to do this will prevent us from reading
-	// the projected service account token.
-	if mutatedPod.Spec.SecurityContext == nil {
-		mutatedPod.Spec.SecurityContext = &corev1.PodSecurityContext{}
-	}
-	if mutatedPod.Spec.SecurityContext.FSGroup == nil {
-		defaultFSGroup := int64(1000)
-		mutatedPod.Spec.SecurityContext.FSGroup = &defaultFSGroup
+	restartPolicy := mutatedPod.Spec.RestartPolicy
+	withSidecar := restartPolicy == "" || restartPolicy == corev1.RestartPolicyAlways
+	withInit := !withSidecar || mutatedPod.Annotations[fmt.Sprintf("%s/sidecar-init", SecretsInjectorFQDN)] != "false"
+
+	// Targeted init containers have their secrets written immediately before they run
+	initContainers := []corev1.Container{}
+	for _, container := range mutatedPod.Spec.InitContainers {
+		if containerConfigPath, ok := containerConfigs[container.Name]; ok {
+			initContainers = append(
+				initContainers,
+				i.buildSidecar(fmt.Sprintf("theatre-secrets-init-%s", container.Name), container, containerConfigPath, secretMountPathPrefix, "--once"),
+			)
+			container.VolumeMounts = append(container.VolumeMounts, i.buildSecretsFilesMount(container.Name))
+		}
+
+		initContainers = append(initContainers, container)
 	}
 
-	secretMountPathPrefix := path.Join(i.vaultConfig.SecretMountPathPrefix, pod.Namespace, pod.Spec.ServiceAccountName)
+	mutatedPod.Spec.InitContainers = initContainers
 
 	for idx, container := range mutatedPod.Spec.Containers {
 		containerConfigPath, ok := containerConfigs[container.Name]
@@ -254,10 +465,171 @@ func (i podInjector) Inject(pod corev1.Pod) *corev1.Pod {
 			continue
 		}
 
-		mutatedPod.Spec.Containers[idx] = i.configureContainer(container, containerConfigPath, secretMountPathPrefix)
+		mutatedPod.Spec.Containers[idx].VolumeMounts = append(
+			mutatedPod.Spec.Containers[idx].VolumeMounts,
+			i.buildSecretsFilesMount(container.Name),
+		)
+
+		if withInit {
+			mutatedPod.Spec.InitContainers = append(
+				mutatedPod.Spec.InitContainers,
+				i.buildSidecar(fmt.Sprintf("theatre-secrets-init-%s", container.Name), container, containerConfigPath, secretMountPathPrefix, "--once"),
+			)
+		}
+
+		if withSidecar {
+			mutatedPod.Spec.Containers = append(
+				mutatedPod.Spec.Containers,
+				i.buildSidecar(fmt.Sprintf("theatre-secrets-%s", container.Name), container, containerConfigPath, secretMountPathPrefix),
+			)
+		}
 	}
+}
 
-	return mutatedPod
+// buildSecretsFilesMount returns the mount of a container's secrets directory. Each
+// container sees only its own secrets, at the root of the secrets path.
+func (i podInjector) buildSecretsFilesMount(containerName string) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "theatre-secrets-files",
+		MountPath: i.SecretsPath,
+		SubPath:   containerName,
+		ReadOnly:  true,
+	}
+}
+
+// buildSidecar returns a container that runs theatre-secrets sync for the secrets of the
+// reference container, writing them to a directory named after it.
+func (i podInjector) buildSidecar(name string, reference corev1.Container, containerConfigPath, secretMountPathPrefix string, extraArgs ...string) corev1.Container {
+	args := i.buildArgs("sync", containerConfigPath, secretMountPathPrefix)
+	args = append(args, "--output-dir", path.Join(i.SecretsPath, reference.Name))
+	args = append(args, extraArgs...)
+
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "theatre-secrets-files",
+			MountPath: i.SecretsPath,
+			ReadOnly:  false,
+		},
+		{
+			Name:      "theatre-secrets-serviceaccount",
+			MountPath: path.Dir(i.ServiceAccountTokenFile),
+			ReadOnly:  true,
+		},
+	}
+
+	// Config files can only be read from volumes, as they're not in our image, so give the
+	// sidecar read-only access to the same volumes as the reference container.
+	if containerConfigPath != "" {
+		for _, volumeMount := range reference.VolumeMounts {
+			volumeMount.ReadOnly = true
+			volumeMounts = append(volumeMounts, volumeMount)
+		}
+	}
+
+	return corev1.Container{
+		Name:            name,
+		Image:           i.Image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"theatre-secrets"},
+		Args:            args,
+		Env:             reference.Env,
+		EnvFrom:         reference.EnvFrom,
+		VolumeMounts:    volumeMounts,
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+			},
+		},
+	}
+}
+
+// InjectEphemeralContainers configures any ephemeral containers named in the pod
+// annotation to use theatre-secrets. Ephemeral containers can't add volumes to a pod, so
+// this only works for pods that were injected on creation. If it returns nil, it's
+// because there's nothing to inject.
+func (i podInjector) InjectEphemeralContainers(pod corev1.Pod, ecs corev1.EphemeralContainers) *corev1.EphemeralContainers {
+	containerConfigs := parseContainerConfigs(pod)
+	if containerConfigs == nil || !hasVolume(pod, "theatre-secrets-install") {
+		return nil
+	}
+
+	mutatedECs := ecs.DeepCopy()
+	secretMountPathPrefix := i.secretMountPathPrefix(pod)
+	injected := false
+
+	for idx, ec := range mutatedECs.EphemeralContainers {
+		containerConfigPath, ok := containerConfigs[ec.Name]
+		if !ok {
+			continue
+		}
+
+		// Ephemeral containers are immutable once added, so we must leave any that we've
+		// already configured as they are.
+		container := corev1.Container(ec.EphemeralContainerCommon)
+		if i.isConfigured(container) {
+			continue
+		}
+
+		mutatedECs.EphemeralContainers[idx].EphemeralContainerCommon = corev1.EphemeralContainerCommon(
+			i.configureContainer(container, containerConfigPath, secretMountPathPrefix),
+		)
+		injected = true
+	}
+
+	if !injected {
+		return nil
+	}
+
+	return mutatedECs
+}
+
+const (
+	injectionModeExec    = "exec"
+	injectionModeSidecar = "sidecar"
+)
+
+// parseInjectionMode reads how the pod would like secrets delivered. By default we wrap
+// the container command with theatre-secrets exec, which sets secrets as environment
+// variables. Images that we can't wrap, such as distroless images, can instead ask for
+// secrets to be written to files by a sidecar:
+//
+//   secrets-injector.vault.crd.gocardless.com/mode: sidecar
+func parseInjectionMode(pod corev1.Pod) (string, error) {
+	mode, ok := pod.Annotations[fmt.Sprintf("%s/mode", SecretsInjectorFQDN)]
+	if !ok {
+		return injectionModeExec, nil
+	}
+
+	switch mode {
+	case injectionModeExec, injectionModeSidecar:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported injection mode %q, must be %q or %q", mode, injectionModeExec, injectionModeSidecar)
+	}
+}
+
+func (i podInjector) secretMountPathPrefix(pod corev1.Pod) string {
+	return path.Join(i.vaultConfig.SecretMountPathPrefix, pod.Namespace, pod.Spec.ServiceAccountName)
+}
+
+// isConfigured returns true if the container already runs via theatre-secrets
+func (i podInjector) isConfigured(container corev1.Container) bool {
+	return len(container.Command) > 0 && container.Command[0] == path.Join(i.InstallPath, "theatre-secrets")
+}
+
+func hasVolume(pod corev1.Pod, name string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+
+	return false
 }
 
 // parseContainerConfigs extracts the pod annotation and parses that configuration
@@ -291,6 +663,34 @@ func parseContainerConfigs(pod corev1.Pod) map[string]string {
 	return containerConfigs
 }
 
+// buildServiceAccountVolume returns a volume containing projected service account tokens
+// that are automatically rotated, unlike the default service account tokens Kubernetes
+// normally mounts.
+func (i podInjector) buildServiceAccountVolume() corev1.Volume {
+	expirySeconds := int64(i.ServiceAccountTokenExpiry / time.Second)
+
+	return corev1.Volume{
+		Name: "theatre-secrets-serviceaccount",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				// Ensure this token is readable by whatever user the container might run in, as
+				// your application might run with a non-root user but must be able to access
+				// its secrets.
+				DefaultMode: func() *int32 { mode := int32(444); return &mode }(),
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Path:              path.Base(i.ServiceAccountTokenFile),
+							ExpirationSeconds: &expirySeconds,
+							Audience:          i.ServiceAccountTokenAudience,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func (i podInjector) buildInitContainer() corev1.Container {
 	return corev1.Container{
 		Name:            "theatre-secrets-injector",
@@ -317,12 +717,10 @@ func (i podInjector) buildInitContainer() corev1.Container {
 	}
 }
 
-// configureContainer returns a copy with the command modified to run theatre-secrets,
-// along with a volume mount that will contain the secrets binaries.
-func (i podInjector) configureContainer(reference corev1.Container, containerConfigPath, secretMountPathPrefix string) corev1.Container {
-	c := &reference
-
-	args := []string{"exec"}
+// buildArgs returns the arguments for the given theatre-secrets subcommand, configured to
+// authenticate with Vault and read secrets for this pod.
+func (i podInjector) buildArgs(subcommand, containerConfigPath, secretMountPathPrefix string) []string {
+	args := []string{subcommand}
 	args = append(args, "--vault-address", i.Address)
 	args = append(args, "--vault-path-prefix", secretMountPathPrefix)
 	args = append(args, "--auth-backend-mount-path", i.AuthMountPath)
@@ -333,6 +731,16 @@ func (i podInjector) configureContainer(reference corev1.Container, containerCon
 		args = append(args, "--config-file", containerConfigPath)
 	}
 
+	return args
+}
+
+// configureContainer returns a copy with the command modified to run theatre-secrets,
+// along with a volume mount that will contain the secrets binaries.
+func (i podInjector) configureContainer(reference corev1.Container, containerConfigPath, secretMountPathPrefix string) corev1.Container {
+	c := &reference
+
+	args := i.buildArgs("exec", containerConfigPath, secretMountPathPrefix)
+
 	execCommand := []string{"--"}
 	execCommand = append(execCommand, reference.Command...)
 	execCommand = append(execCommand, reference.Args...)
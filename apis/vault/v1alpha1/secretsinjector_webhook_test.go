@@ -6,12 +6,17 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook"
+	"github.com/gocardless/theatre/v2/pkg/webhook/webhooktest"
 )
 
 func mustPodFixture(path string) *corev1.Pod {
@@ -251,9 +256,436 @@ var _ = Describe("PodInjector", func() {
 				),
 			)
 		})
+
+		It("Patches only the injected fields", func() {
+			patch, err := webhook.Diff(fixture, pod)
+			Expect(err).NotTo(HaveOccurred())
+			webhooktest.ExpectGoldenPatch("./testdata/app_with_config_pod.patch.json", patch)
+		})
+	})
+})
+
+var _ = Describe("PodInjector with init and ephemeral containers", func() {
+	var (
+		injector *podInjector
+		fixture  *corev1.Pod
+	)
+
+	containerNames := func(containers []corev1.Container) []string {
+		names := []string{}
+		for _, container := range containers {
+			names = append(names, container.Name)
+		}
+
+		return names
+	}
+
+	isInjected := func(container corev1.Container) bool {
+		return len(container.Command) > 0 && container.Command[0] == "/var/run/theatre-secrets/theatre-secrets"
+	}
+
+	BeforeEach(func() {
+		injector = &podInjector{
+			vaultConfig: vaultConfig{
+				Address:               "https://vault.example.com",
+				AuthMountPath:         "kubernetes.gc-prd-effc.cluster",
+				AuthRole:              "default",
+				SecretMountPathPrefix: "secret/data/kubernetes",
+			},
+			SecretsInjectorOptions: SecretsInjectorOptions{
+				Image:                     "theatre:latest",
+				InstallPath:               "/var/run/theatre-secrets",
+				ServiceAccountTokenFile:   "/var/run/secrets/kubernetes.io/vault/token",
+				ServiceAccountTokenExpiry: 15 * time.Minute,
+			},
+		}
+
+		fixture = mustPodFixture("./testdata/app_with_init_containers_pod.yaml")
+	})
+
+	DescribeTable("Init container ordering and injection",
+		func(annotation string, expectedOrder []string, expectedInjected []string) {
+			fixture.ObjectMeta.Annotations = map[string]string{
+				fmt.Sprintf("%s/configs", SecretsInjectorFQDN): annotation,
+			}
+
+			pod := injector.Inject(*fixture)
+
+			Expect(containerNames(pod.Spec.InitContainers)).To(Equal(expectedOrder))
+
+			injected := []string{}
+			for _, container := range pod.Spec.InitContainers {
+				if isInjected(container) {
+					injected = append(injected, container.Name)
+				}
+			}
+
+			Expect(injected).To(Equal(expectedInjected))
+		},
+		Entry("no init containers targeted",
+			"app",
+			[]string{"setup", "migrate", "warm-cache", "theatre-secrets-injector"},
+			[]string{},
+		),
+		Entry("a single init container targeted",
+			"app,migrate:config/migrate.yaml",
+			[]string{"setup", "theatre-secrets-injector", "migrate", "warm-cache"},
+			[]string{"migrate"},
+		),
+		Entry("the first init container targeted",
+			"app,setup",
+			[]string{"theatre-secrets-injector", "setup", "migrate", "warm-cache"},
+			[]string{"setup"},
+		),
+		Entry("multiple init containers targeted",
+			"migrate,warm-cache",
+			[]string{"setup", "theatre-secrets-injector", "migrate", "warm-cache"},
+			[]string{"migrate", "warm-cache"},
+		),
+	)
+
+	Context("With a targeted init container", func() {
+		var pod *corev1.Pod
+
+		JustBeforeEach(func() {
+			pod = injector.Inject(*fixture)
+		})
+
+		It("Configures the init container with its config file", func() {
+			Expect(pod.Spec.InitContainers).To(
+				ContainElement(
+					MatchFields(
+						IgnoreExtras, Fields{
+							"Name": Equal("migrate"),
+							"Args": Equal([]string{
+								"exec",
+								"--vault-address",
+								"https://vault.example.com",
+								"--vault-path-prefix",
+								"secret/data/kubernetes/staging/secret-reader",
+								"--auth-backend-mount-path",
+								"kubernetes.gc-prd-effc.cluster",
+								"--auth-backend-role",
+								"default",
+								"--service-account-token-file",
+								"/var/run/secrets/kubernetes.io/vault/token",
+								"--config-file",
+								"config/migrate.yaml",
+								"--",
+								"rake",
+								"db:migrate",
+							}),
+							"VolumeMounts": ContainElement(
+								corev1.VolumeMount{
+									Name:      "theatre-secrets-install",
+									MountPath: "/var/run/theatre-secrets",
+									ReadOnly:  true,
+								},
+							),
+						},
+					),
+				),
+			)
+		})
+
+		It("Still configures the app container", func() {
+			Expect(isInjected(pod.Spec.Containers[0])).To(BeTrue())
+		})
+	})
+
+	Describe("InjectEphemeralContainers", func() {
+		var (
+			pod     corev1.Pod
+			ecs     corev1.EphemeralContainers
+			mutated *corev1.EphemeralContainers
+		)
+
+		debugContainer := func(name string) corev1.EphemeralContainer {
+			return corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name:    name,
+					Command: []string{"sh"},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			fixture.ObjectMeta.Annotations = map[string]string{
+				fmt.Sprintf("%s/configs", SecretsInjectorFQDN): "app,debugger",
+			}
+
+			// The pod will have been injected on creation
+			pod = *injector.Inject(*fixture)
+			ecs = corev1.EphemeralContainers{
+				ObjectMeta:          pod.ObjectMeta,
+				EphemeralContainers: []corev1.EphemeralContainer{debugContainer("debugger")},
+			}
+		})
+
+		JustBeforeEach(func() {
+			mutated = injector.InjectEphemeralContainers(pod, ecs)
+		})
+
+		It("Configures the targeted ephemeral container", func() {
+			Expect(mutated).NotTo(BeNil())
+			Expect(mutated.EphemeralContainers[0].Command).To(Equal([]string{"/var/run/theatre-secrets/theatre-secrets"}))
+			Expect(mutated.EphemeralContainers[0].Args).To(ContainElement("sh"))
+			Expect(mutated.EphemeralContainers[0].VolumeMounts).To(ContainElement(
+				corev1.VolumeMount{
+					Name:      "theatre-secrets-serviceaccount",
+					MountPath: "/var/run/secrets/kubernetes.io/vault",
+					ReadOnly:  true,
+				},
+			))
+		})
+
+		Context("When the ephemeral container isn't targeted", func() {
+			BeforeEach(func() {
+				ecs.EphemeralContainers = []corev1.EphemeralContainer{debugContainer("other")}
+			})
+
+			It("Returns nil", func() {
+				Expect(mutated).To(BeNil())
+			})
+		})
+
+		Context("When the ephemeral container has already been configured", func() {
+			BeforeEach(func() {
+				existing := injector.InjectEphemeralContainers(pod, ecs)
+				ecs.EphemeralContainers = append(existing.EphemeralContainers, debugContainer("other"))
+			})
+
+			It("Returns nil, leaving the existing container unmodified", func() {
+				Expect(mutated).To(BeNil())
+			})
+		})
+
+		Context("When the pod was not injected on creation", func() {
+			BeforeEach(func() {
+				pod = *fixture
+			})
+
+			It("Returns nil, as ephemeral containers can't add volumes", func() {
+				Expect(mutated).To(BeNil())
+			})
+		})
 	})
 })
 
+var _ = Describe("PodInjector in sidecar mode", func() {
+	var (
+		injector *podInjector
+		fixture  *corev1.Pod
+		pod      *corev1.Pod
+	)
+
+	containerNames := func(containers []corev1.Container) []string {
+		names := []string{}
+		for _, container := range containers {
+			names = append(names, container.Name)
+		}
+
+		return names
+	}
+
+	expectedArgs := func(extraArgs ...string) []string {
+		return append([]string{
+			"sync",
+			"--vault-address",
+			"https://vault.example.com",
+			"--vault-path-prefix",
+			"secret/data/kubernetes/staging/secret-reader",
+			"--auth-backend-mount-path",
+			"kubernetes.gc-prd-effc.cluster",
+			"--auth-backend-role",
+			"default",
+			"--service-account-token-file",
+			"/var/run/secrets/kubernetes.io/vault/token",
+			"--config-file",
+			"/config/env.yaml",
+			"--output-dir",
+			"/var/run/secrets/theatre/app",
+		}, extraArgs...)
+	}
+
+	BeforeEach(func() {
+		injector = &podInjector{
+			vaultConfig: vaultConfig{
+				Address:               "https://vault.example.com",
+				AuthMountPath:         "kubernetes.gc-prd-effc.cluster",
+				AuthRole:              "default",
+				SecretMountPathPrefix: "secret/data/kubernetes",
+			},
+			SecretsInjectorOptions: SecretsInjectorOptions{
+				Image:                     "theatre:latest",
+				InstallPath:               "/var/run/theatre-secrets",
+				ServiceAccountTokenFile:   "/var/run/secrets/kubernetes.io/vault/token",
+				ServiceAccountTokenExpiry: 15 * time.Minute,
+				SecretsPath:               "/var/run/secrets/theatre",
+			},
+		}
+
+		fixture = mustPodFixture("./testdata/app_sidecar_pod.yaml")
+	})
+
+	JustBeforeEach(func() {
+		pod = injector.Inject(*fixture)
+	})
+
+	It("Leaves the app container command untouched", func() {
+		Expect(pod.Spec.Containers[0].Command).To(BeEmpty())
+		Expect(pod.Spec.Containers[0].Args).To(BeEmpty())
+	})
+
+	It("Mounts the app's secrets directory into the app container", func() {
+		Expect(pod.Spec.Containers[0].VolumeMounts).To(ContainElement(
+			corev1.VolumeMount{
+				Name:      "theatre-secrets-files",
+				MountPath: "/var/run/secrets/theatre",
+				SubPath:   "app",
+				ReadOnly:  true,
+			},
+		))
+	})
+
+	It("Adds an in-memory volume for secrets, and no install volume", func() {
+		Expect(pod.Spec.Volumes).To(ContainElement(
+			corev1.Volume{
+				Name: "theatre-secrets-files",
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+				},
+			},
+		))
+		Expect(hasVolume(*pod, "theatre-secrets-install")).To(BeFalse())
+		Expect(hasVolume(*pod, "theatre-secrets-serviceaccount")).To(BeTrue())
+	})
+
+	It("Adds a sidecar and init container for only the targeted container", func() {
+		Expect(containerNames(pod.Spec.Containers)).To(Equal([]string{"app", "proxy", "theatre-secrets-app"}))
+		Expect(containerNames(pod.Spec.InitContainers)).To(Equal([]string{"theatre-secrets-init-app"}))
+	})
+
+	It("Configures the sidecar to sync the app's secrets", func() {
+		Expect(pod.Spec.Containers[2]).To(
+			MatchFields(IgnoreExtras, Fields{
+				"Image":   Equal("theatre:latest"),
+				"Command": Equal([]string{"theatre-secrets"}),
+				"Args":    Equal(expectedArgs()),
+				"Env":     Equal(fixture.Spec.Containers[0].Env),
+				"VolumeMounts": Equal([]corev1.VolumeMount{
+					{
+						Name:      "theatre-secrets-files",
+						MountPath: "/var/run/secrets/theatre",
+					},
+					{
+						Name:      "theatre-secrets-serviceaccount",
+						MountPath: "/var/run/secrets/kubernetes.io/vault",
+						ReadOnly:  true,
+					},
+					{
+						Name:      "app-config",
+						MountPath: "/config",
+						ReadOnly:  true,
+					},
+				}),
+			}),
+		)
+	})
+
+	It("Configures the init container to sync the app's secrets once", func() {
+		Expect(pod.Spec.InitContainers[0].Args).To(Equal(expectedArgs("--once")))
+	})
+
+	It("Patches only the injected fields", func() {
+		patch, err := webhook.Diff(fixture, pod)
+		Expect(err).NotTo(HaveOccurred())
+		webhooktest.ExpectGoldenPatch("./testdata/app_sidecar_pod.patch.json", patch)
+	})
+
+	Context("When the init container is disabled", func() {
+		BeforeEach(func() {
+			fixture.ObjectMeta.Annotations["secrets-injector.vault.crd.gocardless.com/sidecar-init"] = "false"
+		})
+
+		It("Only adds the sidecar", func() {
+			Expect(pod.Spec.InitContainers).To(BeEmpty())
+			Expect(containerNames(pod.Spec.Containers)).To(Equal([]string{"app", "proxy", "theatre-secrets-app"}))
+		})
+	})
+
+	DescribeTable("For pods that aren't restarted",
+		func(restartPolicy corev1.RestartPolicy, sidecarInit string) {
+			fixture.Spec.RestartPolicy = restartPolicy
+			fixture.ObjectMeta.Annotations["secrets-injector.vault.crd.gocardless.com/sidecar-init"] = sidecarInit
+
+			pod = injector.Inject(*fixture)
+
+			By("Writing the secrets once, from an init container")
+			Expect(containerNames(pod.Spec.InitContainers)).To(Equal([]string{"theatre-secrets-init-app"}))
+			Expect(pod.Spec.InitContainers[0].Args).To(Equal(expectedArgs("--once")))
+
+			By("Not adding a sidecar, which would stop the pod from completing")
+			Expect(containerNames(pod.Spec.Containers)).To(Equal([]string{"app", "proxy"}))
+		},
+		Entry("OnFailure", corev1.RestartPolicyOnFailure, "true"),
+		Entry("Never", corev1.RestartPolicyNever, "true"),
+		Entry("Never, with the init container disabled", corev1.RestartPolicyNever, "false"),
+	)
+
+	Context("When an init container is targeted", func() {
+		BeforeEach(func() {
+			fixture.ObjectMeta.Annotations["secrets-injector.vault.crd.gocardless.com/configs"] = "app:/config/env.yaml,migrate"
+			fixture.Spec.InitContainers = []corev1.Container{
+				{Name: "setup", Image: "busybox"},
+				{Name: "migrate", Image: "gcr.io/distroless/static"},
+			}
+		})
+
+		It("Writes its secrets immediately before it runs", func() {
+			Expect(containerNames(pod.Spec.InitContainers)).To(Equal([]string{
+				"setup", "theatre-secrets-init-migrate", "migrate", "theatre-secrets-init-app",
+			}))
+			Expect(pod.Spec.InitContainers[1].Args).To(ContainElement("--once"))
+			Expect(pod.Spec.InitContainers[1].Args).To(ContainElement("/var/run/secrets/theatre/migrate"))
+		})
+
+		It("Mounts its secrets directory", func() {
+			Expect(pod.Spec.InitContainers[2].VolumeMounts).To(ContainElement(
+				corev1.VolumeMount{
+					Name:      "theatre-secrets-files",
+					MountPath: "/var/run/secrets/theatre",
+					SubPath:   "migrate",
+					ReadOnly:  true,
+				},
+			))
+			Expect(pod.Spec.InitContainers[0].VolumeMounts).To(BeEmpty())
+		})
+
+		It("Doesn't add a sidecar for it", func() {
+			Expect(containerNames(pod.Spec.Containers)).To(Equal([]string{"app", "proxy", "theatre-secrets-app"}))
+		})
+	})
+})
+
+var _ = Describe("parseInjectionMode", func() {
+	DescribeTable("Parses the mode annotation",
+		func(annotations map[string]string, expected string, expectErr bool) {
+			mode, err := parseInjectionMode(corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}})
+			if expectErr {
+				Expect(err).To(HaveOccurred())
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mode).To(Equal(expected))
+			}
+		},
+		Entry("no annotation", map[string]string{}, "exec", false),
+		Entry("exec", map[string]string{"secrets-injector.vault.crd.gocardless.com/mode": "exec"}, "exec", false),
+		Entry("sidecar", map[string]string{"secrets-injector.vault.crd.gocardless.com/mode": "sidecar"}, "sidecar", false),
+		Entry("unknown", map[string]string{"secrets-injector.vault.crd.gocardless.com/mode": "magic"}, "", true),
+	)
+})
+
 var _ = Describe("parseContainerConfigs", func() {
 	var (
 		fixture          *corev1.Pod
@@ -311,3 +743,118 @@ var _ = Describe("parseContainerConfigs", func() {
 
 	})
 })
+
+var _ = Describe("resolveVaultConfig", func() {
+	var (
+		sources         []vaultConfigSource
+		cfg             vaultConfig
+		resolvedSources map[string]string
+		err             error
+	)
+
+	global := vaultConfigSource{
+		Name: vaultConfigSourceGlobal,
+		Data: map[string]string{
+			"address":                  "https://vault.example.com",
+			"auth_mount_path":          "kubernetes.gc-prd-effc.cluster",
+			"auth_role":                "default",
+			"secret_mount_path_prefix": "secret/data/kubernetes",
+		},
+	}
+
+	JustBeforeEach(func() {
+		cfg, resolvedSources, err = resolveVaultConfig(sources...)
+	})
+
+	Context("With only the global config", func() {
+		BeforeEach(func() {
+			sources = []vaultConfigSource{global}
+		})
+
+		It("Uses the global config for every field", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg).To(Equal(vaultConfig{
+				Address:               "https://vault.example.com",
+				AuthMountPath:         "kubernetes.gc-prd-effc.cluster",
+				AuthRole:              "default",
+				SecretMountPathPrefix: "secret/data/kubernetes",
+			}))
+			Expect(resolvedSources).To(Equal(map[string]string{
+				"address":                  "global",
+				"auth_mount_path":          "global",
+				"auth_role":                "global",
+				"secret_mount_path_prefix": "global",
+			}))
+		})
+	})
+
+	Context("With pod and namespace overrides", func() {
+		BeforeEach(func() {
+			sources = []vaultConfigSource{
+				{
+					Name: vaultConfigSourcePod,
+					Data: vaultConfigFromAnnotations(map[string]string{
+						"secrets-injector.vault.crd.gocardless.com/auth-role": "payments",
+						"unrelated.example.com/auth-role":                     "ignored",
+					}),
+				},
+				{
+					Name: vaultConfigSourceNamespace,
+					Data: map[string]string{
+						"address":   "https://vault.tenant.example.com",
+						"auth_role": "tenant",
+						// Empty values don't override lower precedence sources
+						"auth_mount_path": "",
+					},
+				},
+				global,
+			}
+		})
+
+		It("Takes each field from the highest precedence source that sets it", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg).To(Equal(vaultConfig{
+				Address:               "https://vault.tenant.example.com",
+				AuthMountPath:         "kubernetes.gc-prd-effc.cluster",
+				AuthRole:              "payments",
+				SecretMountPathPrefix: "secret/data/kubernetes",
+			}))
+			Expect(resolvedSources).To(Equal(map[string]string{
+				"address":                  "namespace",
+				"auth_mount_path":          "global",
+				"auth_role":                "pod",
+				"secret_mount_path_prefix": "global",
+			}))
+		})
+	})
+})
+
+var _ = Describe("vaultConfig.Validate", func() {
+	valid := vaultConfig{
+		Address:               "https://vault.example.com",
+		AuthMountPath:         "kubernetes.gc-prd-effc.cluster",
+		AuthRole:              "default",
+		SecretMountPathPrefix: "secret/data/kubernetes",
+	}
+
+	It("Accepts a complete config", func() {
+		Expect(valid.Validate()).To(Succeed())
+	})
+
+	DescribeTable("Rejects invalid configs",
+		func(mutate func(*vaultConfig), expected string) {
+			cfg := valid
+			mutate(&cfg)
+
+			err := cfg.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(expected))
+		},
+		Entry("missing address", func(c *vaultConfig) { c.Address = "" }, "address must be set"),
+		Entry("address without scheme", func(c *vaultConfig) { c.Address = "vault.example.com" }, "address must be an http(s) URL"),
+		Entry("address with bad scheme", func(c *vaultConfig) { c.Address = "ftp://vault.example.com" }, "address must be an http(s) URL"),
+		Entry("missing auth role", func(c *vaultConfig) { c.AuthRole = "" }, "auth_role must be set"),
+		Entry("missing auth mount path", func(c *vaultConfig) { c.AuthMountPath = "" }, "auth_mount_path must be set"),
+		Entry("secret prefix traversal", func(c *vaultConfig) { c.SecretMountPathPrefix = "secret/../other" }, "secret_mount_path_prefix must not contain '..'"),
+	)
+})
@@ -0,0 +1,52 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConsoleActivitySpec defines the desired state of ConsoleActivity
+type ConsoleActivitySpec struct {
+	// The reference to the console by name that this activity belongs to.
+	ConsoleRef corev1.LocalObjectReference `json:"consoleRef"`
+
+	// The user that last attached to or sent input to the console. This is set by
+	// an admission webhook.
+	// +optional
+	LastActivityUser string `json:"lastActivityUser,omitempty"`
+
+	// Time at which activity was last reported. This is set by an admission
+	// webhook.
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
+}
+
+// ConsoleActivityStatus defines the observed state of ConsoleActivity
+type ConsoleActivityStatus struct{}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// ConsoleActivity is the Schema for the consoleactivities API. Anyone attached to a
+// console reports their activity to it, so that the controller can stop consoles
+// that have been idle for longer than their template permits.
+type ConsoleActivity struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsoleActivitySpec   `json:"spec,omitempty"`
+	Status ConsoleActivityStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsoleActivityList contains a list of ConsoleActivity
+type ConsoleActivityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsoleActivity `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsoleActivity{}, &ConsoleActivityList{})
+}
@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook"
+)
+
+// ConsoleActivityWebhook attributes reports of activity in a console to whoever made
+// them, at the time they made them, so that users can't keep a console alive by
+// reporting activity in the future.
+//
+// +kubebuilder:object:generate=false
+type ConsoleActivityWebhook struct {
+	logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+func NewConsoleActivityWebhook(logger logr.Logger) *ConsoleActivityWebhook {
+	return &ConsoleActivityWebhook{
+		logger: logger,
+	}
+}
+
+func (c *ConsoleActivityWebhook) InjectDecoder(d *admission.Decoder) error {
+	c.decoder = d
+	return nil
+}
+
+func (c *ConsoleActivityWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := c.logger.WithValues("uuid", string(req.UID))
+
+	updatedActivity := &ConsoleActivity{}
+	if err := c.decoder.DecodeRaw(req.Object, updatedActivity); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	existingActivity := &ConsoleActivity{}
+	if err := c.decoder.DecodeRaw(req.OldObject, existingActivity); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	update := &ConsoleActivityUpdate{
+		existingActivity: existingActivity,
+		updatedActivity:  updatedActivity,
+		user:             req.UserInfo.Username,
+	}
+
+	if err := update.Validate(); err != nil {
+		logger.Info("activity rejected", "event", "activity.failure", "error", err)
+		return admission.ValidationResponse(false, fmt.Sprintf("the console activity spec is invalid: %v", err))
+	}
+
+	// Updates that don't report activity, such as to labels, are left alone
+	if !update.Reported() {
+		return admission.Allowed("no activity reported")
+	}
+
+	update.Attribute(metav1.Now())
+
+	logger.Info("activity recorded", "event", "activity.success", "user", update.user)
+	return update.Patch().Response("")
+}
+
+type ConsoleActivityUpdate struct {
+	existingActivity *ConsoleActivity
+	updatedActivity  *ConsoleActivity
+	user             string
+}
+
+// Reported returns whether this update reports activity in the console
+func (u *ConsoleActivityUpdate) Reported() bool {
+	existing, updated := u.existingActivity.Spec, u.updatedActivity.Spec
+
+	return existing.LastActivityUser != updated.LastActivityUser ||
+		!reflect.DeepEqual(existing.LastActivityTime, updated.LastActivityTime)
+}
+
+// Attribute sets the user and time of the reported activity
+func (u *ConsoleActivityUpdate) Attribute(now metav1.Time) {
+	u.updatedActivity.Spec.LastActivityUser = u.user
+	u.updatedActivity.Spec.LastActivityTime = &now
+}
+
+// Patch returns the changes made by Attribute, for the webhook to respond with
+func (u *ConsoleActivityUpdate) Patch() webhook.Patch {
+	patch := webhook.Patch{}
+	patch.Add(webhook.Path("spec", "lastActivityUser"), u.updatedActivity.Spec.LastActivityUser)
+	patch.Add(webhook.Path("spec", "lastActivityTime"), u.updatedActivity.Spec.LastActivityTime)
+
+	return patch
+}
+
+func (u *ConsoleActivityUpdate) Validate() error {
+	// check immutable fields haven't been updated
+	if !reflect.DeepEqual(u.updatedActivity.Spec.ConsoleRef, u.existingActivity.Spec.ConsoleRef) {
+		return errors.New("the spec.consoleRef field is immutable")
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook/webhooktest"
+)
+
+var _ = Describe("Activity webhook", func() {
+	var (
+		existingActivity *ConsoleActivity
+		updatedActivity  *ConsoleActivity
+		update           *ConsoleActivityUpdate
+	)
+
+	reported := metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	BeforeEach(func() {
+		existingActivity = &ConsoleActivity{
+			Spec: ConsoleActivitySpec{
+				ConsoleRef:       corev1.LocalObjectReference{Name: "console"},
+				LastActivityUser: "someone-else",
+				LastActivityTime: &reported,
+			},
+		}
+
+		forged := metav1.NewTime(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+		updatedActivity = existingActivity.DeepCopy()
+		updatedActivity.Spec.LastActivityUser = "forged-user"
+		updatedActivity.Spec.LastActivityTime = &forged
+	})
+
+	JustBeforeEach(func() {
+		update = &ConsoleActivityUpdate{
+			existingActivity: existingActivity,
+			updatedActivity:  updatedActivity,
+			user:             "current-user",
+		}
+	})
+
+	Describe("Reported", func() {
+		It("Is true when the activity changes", func() {
+			Expect(update.Reported()).To(BeTrue())
+		})
+
+		Context("When only the labels change", func() {
+			BeforeEach(func() {
+				updatedActivity = existingActivity.DeepCopy()
+				updatedActivity.Labels = map[string]string{"new": "label"}
+			})
+
+			It("Is false", func() {
+				Expect(update.Reported()).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Validate", func() {
+		It("Returns no errors", func() {
+			Expect(update.Validate()).To(Succeed())
+		})
+
+		Context("When the console reference changes", func() {
+			BeforeEach(func() {
+				updatedActivity.Spec.ConsoleRef.Name = "another-console"
+			})
+
+			It("Returns an error", func() {
+				Expect(update.Validate()).To(MatchError(ContainSubstring("spec.consoleRef field is immutable")))
+			})
+		})
+	})
+
+	Describe("Patch", func() {
+		It("Attributes the activity to the current user, at the current time", func() {
+			update.Attribute(reported)
+
+			Expect(updatedActivity.Spec.LastActivityUser).To(Equal("current-user"))
+			Expect(updatedActivity.Spec.LastActivityTime).To(Equal(&reported))
+			webhooktest.ExpectGoldenPatch("testdata/console_activity_report.patch.json", update.Patch())
+		})
+	})
+})
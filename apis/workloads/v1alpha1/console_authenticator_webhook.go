@@ -2,24 +2,33 @@ package v1alpha1
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/go-logr/logr"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook"
 )
 
 // +kubebuilder:object:generate=false
 type ConsoleAuthenticatorWebhook struct {
-	logger  logr.Logger
-	decoder *admission.Decoder
+	logger          logr.Logger
+	decoder         *admission.Decoder
+	managerUsername string
 }
 
-func NewConsoleAuthenticatorWebhook(logger logr.Logger) *ConsoleAuthenticatorWebhook {
+// NewConsoleAuthenticatorWebhook builds the webhook that records who created each
+// console. Consoles created by the manager, which does so for scheduled consoles,
+// keep the user they were created for, as the manager checks that the user could
+// have created them before doing so.
+func NewConsoleAuthenticatorWebhook(logger logr.Logger, managerUsername string) *ConsoleAuthenticatorWebhook {
 	return &ConsoleAuthenticatorWebhook{
-		logger: logger,
+		logger:          logger,
+		managerUsername: managerUsername,
 	}
 }
 
@@ -30,26 +39,68 @@ func (c *ConsoleAuthenticatorWebhook) InjectDecoder(d *admission.Decoder) error
 
 func (c *ConsoleAuthenticatorWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
 	logger := c.logger.WithValues("uuid", string(req.UID))
-	logger.Info("starting request", "event", "request.start")
-	defer func(start time.Time) {
-		logger.Info("completed request", "event", "request.end", "duration", time.Now().Sub(start).Seconds())
-	}(time.Now())
 
 	csl := &Console{}
 	if err := c.decoder.Decode(req, csl); err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
+	// The script may have already been authorised, so it can't be changed once
+	// the console exists. Updates also leave alone who created the console.
+	if req.Operation == admissionv1beta1.Update {
+		existing := &Console{}
+		if err := c.decoder.DecodeRaw(req.OldObject, existing); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if csl.Spec.Script != existing.Spec.Script || csl.Spec.ScriptSHA256 != existing.Spec.ScriptSHA256 {
+			logger.Info("script change rejected", "event", "authentication.failure", "user", req.UserInfo.Username)
+			return admission.Denied("the spec.script and spec.scriptSha256 fields are immutable")
+		}
+
+		// Only the manager records who deleted a console, from the deletion webhook
+		if csl.DeletedBy() != existing.DeletedBy() && !c.isManager(req.UserInfo.Username) {
+			logger.Info("deleted-by change rejected", "event", "authentication.failure", "user", req.UserInfo.Username)
+			return admission.Denied(fmt.Sprintf("the %s annotation can only be set by the manager", DeletedByAnnotation))
+		}
+
+		return admission.Allowed("update allowed")
+	}
+
 	user := req.UserInfo.Username
-	copy := csl.DeepCopy()
-	copy.Spec.User = user
+	if c.isManager(user) && csl.Spec.User != "" {
+		logger.Info(fmt.Sprintf("manager created console for user %s", csl.Spec.User), "event", "authentication.on_behalf", "user", csl.Spec.User)
+		user = csl.Spec.User
+	}
 
-	copyBytes, err := json.Marshal(copy)
-	if err != nil {
-		return admission.Errored(http.StatusInternalServerError, err)
+	patch := webhook.Patch{}
+	patch.Add("/spec/user", user)
+
+	// Nobody has deleted a console that is only just being created
+	if csl.DeletedBy() != "" {
+		patch.Remove(webhook.Path("metadata", "annotations", DeletedByAnnotation))
+	}
+
+	// Record the checksum of the script ourselves, so that authorisers and the
+	// audit log can trust that it refers to the script that will be run
+	switch {
+	case csl.HasScript():
+		if len(csl.Spec.Script) > MaxScriptBytes {
+			return admission.Denied(fmt.Sprintf("script is %d bytes, which exceeds the limit of %d bytes", len(csl.Spec.Script), MaxScriptBytes))
+		}
+
+		checksum := sha256.Sum256([]byte(csl.Spec.Script))
+		patch.Add("/spec/scriptSha256", hex.EncodeToString(checksum[:]))
+	case csl.Spec.ScriptSHA256 != "":
+		patch.Remove("/spec/scriptSha256")
 	}
 
 	logger.Info(fmt.Sprintf("authentication successful for user %s", user), "event", "authentication.success", "user", user)
 
-	return admission.PatchResponseFromRaw(req.Object.Raw, copyBytes)
+	return patch.Response("")
+}
+
+// isManager returns whether the request was made by the manager itself
+func (c *ConsoleAuthenticatorWebhook) isManager(user string) bool {
+	return c.managerUsername != "" && user == c.managerUsername
 }
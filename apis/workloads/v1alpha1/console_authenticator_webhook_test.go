@@ -0,0 +1,216 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook/webhooktest"
+)
+
+var _ = Describe("ConsoleAuthenticatorWebhook", func() {
+	var (
+		csl       *Console
+		requester string
+		resp      admission.Response
+	)
+
+	BeforeEach(func() {
+		requester = "alice@example.com"
+		csl = &Console{
+			TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "Console"},
+			ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: "default"},
+			Spec: ConsoleSpec{
+				User:               "forged-user",
+				ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		_, decoder := newTestDecoder()
+
+		authenticator := NewConsoleAuthenticatorWebhook(zap.LoggerTo(GinkgoWriter, true), "system:serviceaccount:theatre-system:theatre-workloads-manager")
+		Expect(authenticator.InjectDecoder(decoder)).To(Succeed())
+
+		resp = authenticator.Handle(context.Background(), newCreateRequest("default", requester, csl))
+	})
+
+	userPatch := func() interface{} {
+		for _, op := range resp.Patches {
+			if op.Path == "/spec/user" {
+				return op.Value
+			}
+		}
+
+		return nil
+	}
+
+	It("Sets the user to whoever made the request", func() {
+		Expect(resp.Allowed).To(BeTrue())
+		webhooktest.ExpectGoldenPatch("testdata/console_authenticator_user.patch.json", resp.Patches)
+	})
+
+	Context("When the manager creates the console on behalf of a user", func() {
+		BeforeEach(func() {
+			requester = "system:serviceaccount:theatre-system:theatre-workloads-manager"
+			csl.Spec.User = "bob@example.com"
+		})
+
+		It("Keeps the user that the console was created for", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(userPatch()).To(Equal("bob@example.com"))
+		})
+
+		Context("Without naming a user", func() {
+			BeforeEach(func() {
+				csl.Spec.User = ""
+			})
+
+			It("Sets the user to the manager", func() {
+				Expect(userPatch()).To(Equal(requester))
+			})
+		})
+	})
+
+	Context("With a script", func() {
+		BeforeEach(func() {
+			csl.Spec.Script = "echo hello\n"
+		})
+
+		It("Records the checksum of the script", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			webhooktest.ExpectGoldenPatch("testdata/console_authenticator_script.patch.json", resp.Patches)
+		})
+	})
+
+	Context("With a checksum but no script", func() {
+		BeforeEach(func() {
+			csl.Spec.ScriptSHA256 = strings.Repeat("a", 64)
+		})
+
+		It("Removes the checksum", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			webhooktest.ExpectGoldenPatch("testdata/console_authenticator_forged_checksum.patch.json", resp.Patches)
+		})
+	})
+
+	Context("With a deleted-by annotation", func() {
+		BeforeEach(func() {
+			csl.Annotations = map[string]string{DeletedByAnnotation: "someone-else@example.com"}
+		})
+
+		It("Removes the annotation", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			webhooktest.ExpectGoldenPatch("testdata/console_authenticator_deleted_by.patch.json", resp.Patches)
+		})
+	})
+
+	Context("With a script that's too large", func() {
+		BeforeEach(func() {
+			csl.Spec.Script = strings.Repeat("a", MaxScriptBytes+1)
+		})
+
+		It("Denies the request", func() {
+			Expect(resp.Allowed).To(BeFalse())
+		})
+	})
+
+	Describe("Updating a console", func() {
+		var (
+			existing *Console
+			updated  *Console
+		)
+
+		BeforeEach(func() {
+			existing = csl.DeepCopy()
+			existing.Spec.User = "alice@example.com"
+			existing.Spec.Script = "echo hello\n"
+			existing.Spec.ScriptSHA256 = strings.Repeat("a", 64)
+			updated = existing.DeepCopy()
+			requester = "bob@example.com"
+		})
+
+		JustBeforeEach(func() {
+			_, decoder := newTestDecoder()
+
+			authenticator := NewConsoleAuthenticatorWebhook(zap.LoggerTo(GinkgoWriter, true), "system:serviceaccount:theatre-system:theatre-workloads-manager")
+			Expect(authenticator.InjectDecoder(decoder)).To(Succeed())
+
+			resp = authenticator.Handle(context.Background(), newUpdateRequest("default", requester, existing, updated))
+		})
+
+		Context("Without changing the script", func() {
+			BeforeEach(func() {
+				updated.Labels = map[string]string{"new": "label"}
+			})
+
+			It("Allows the request without changing the user", func() {
+				Expect(resp.Allowed).To(BeTrue())
+				Expect(resp.Patches).To(BeEmpty())
+			})
+		})
+
+		Context("Changing the script", func() {
+			BeforeEach(func() {
+				updated.Spec.Script = "rm -rf /\n"
+			})
+
+			It("Denies the request", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(string(resp.Result.Reason)).To(ContainSubstring("immutable"))
+			})
+		})
+
+		Context("Setting the deleted-by annotation", func() {
+			BeforeEach(func() {
+				updated.Annotations = map[string]string{DeletedByAnnotation: "someone-else@example.com"}
+			})
+
+			It("Denies the request", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(string(resp.Result.Reason)).To(ContainSubstring("can only be set by the manager"))
+			})
+
+			Context("As the manager", func() {
+				BeforeEach(func() {
+					requester = "system:serviceaccount:theatre-system:theatre-workloads-manager"
+				})
+
+				It("Allows the request", func() {
+					Expect(resp.Allowed).To(BeTrue())
+				})
+			})
+		})
+
+		Context("Changing the checksum", func() {
+			BeforeEach(func() {
+				updated.Spec.ScriptSHA256 = strings.Repeat("b", 64)
+			})
+
+			It("Denies the request", func() {
+				Expect(resp.Allowed).To(BeFalse())
+			})
+		})
+	})
+})
+
+func newUpdateRequest(namespace, username string, existing, updated runtime.Object) admission.Request {
+	req := newCreateRequest(namespace, username, updated)
+	req.Operation = admissionv1beta1.Update
+
+	raw, err := json.Marshal(existing)
+	Expect(err).NotTo(HaveOccurred())
+	req.OldObject = runtime.RawExtension{Raw: raw}
+
+	return req
+}
@@ -13,6 +13,12 @@ type ConsoleAuthorisationSpec struct {
 
 	// List of authorisations that have been given to the referenced console.
 	Authorisations []rbacv1.Subject `json:"authorisations"`
+
+	// Checksum of the script that is being authorised, if the console runs one.
+	// This is set by the controller when the authorisation is created, and the
+	// console is only authorised while its script still matches.
+	// +optional
+	ScriptSHA256 string `json:"scriptSha256,omitempty"`
 }
 
 // ConsoleAuthorisationStatus defines the observed state of ConsoleAuthorisation
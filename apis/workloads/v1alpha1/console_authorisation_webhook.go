@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
-	"time"
 
 	"github.com/go-logr/logr"
 	rbacutils "github.com/gocardless/theatre/v2/pkg/rbac"
@@ -36,10 +35,6 @@ func (c *ConsoleAuthorisationWebhook) InjectDecoder(d *admission.Decoder) error
 
 func (c *ConsoleAuthorisationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
 	logger := c.logger.WithValues("uuid", string(req.UID))
-	logger.Info("starting request", "event", "request.start")
-	defer func(start time.Time) {
-		logger.Info("completed request", "event", "request.end", "duration", time.Now().Sub(start).Seconds())
-	}(time.Now())
 
 	// request console authorisation object
 	updatedAuth := &ConsoleAuthorisation{}
@@ -103,6 +98,10 @@ func (u *ConsoleAuthorisationUpdate) Validate() error {
 		err = multierror.Append(err, errors.New("the spec.consoleRef field is immutable"))
 	}
 
+	if u.updatedAuth.Spec.ScriptSHA256 != u.existingAuth.Spec.ScriptSHA256 {
+		err = multierror.Append(err, errors.New("the spec.scriptSha256 field is immutable"))
+	}
+
 	// check no existing authorisation subjects have been modified and that a single subject has been added
 	add := rbacutils.Diff(u.updatedAuth.Spec.Authorisations, u.existingAuth.Spec.Authorisations)
 	remove := rbacutils.Diff(u.existingAuth.Spec.Authorisations, u.updatedAuth.Spec.Authorisations)
@@ -112,6 +112,17 @@ var _ = Describe("Authorisation webhook", func() {
 			})
 		})
 
+		Context("Changing the checksum of the script being authorised", func() {
+			BeforeEach(func() {
+				updateFixture = "./testdata/console_authorisation_update_script.yaml"
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError(ContainSubstring("spec.scriptSha256 field is immutable")))
+			})
+		})
+
 		Context("Removing an existing authoriser", func() {
 			BeforeEach(func() {
 				updateFixture = "./testdata/console_authorisation_update_remove.yaml"
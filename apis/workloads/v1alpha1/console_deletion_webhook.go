@@ -0,0 +1,78 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ConsoleDeletionWebhook records who deleted a console, as the API server doesn't keep
+// track of this, so that the controller can include them in the audit log once it has
+// finished cleaning up the console.
+//
+// +kubebuilder:object:generate=false
+type ConsoleDeletionWebhook struct {
+	client  client.Client
+	logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+func NewConsoleDeletionWebhook(c client.Client, logger logr.Logger) *ConsoleDeletionWebhook {
+	return &ConsoleDeletionWebhook{
+		client: c,
+		logger: logger,
+	}
+}
+
+func (c *ConsoleDeletionWebhook) InjectDecoder(d *admission.Decoder) error {
+	c.decoder = d
+	return nil
+}
+
+// Handle always allows the deletion: failing to record who made it only leaves them
+// out of the audit log, which is better than consoles that can't be deleted.
+func (c *ConsoleDeletionWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := c.logger.WithValues("uuid", string(req.UID))
+
+	if req.DryRun != nil && *req.DryRun {
+		return admission.Allowed("dry run")
+	}
+
+	csl := &Console{}
+	if err := c.decoder.DecodeRaw(req.OldObject, csl); err != nil {
+		logger.Error(err, "failed to decode console", "event", "deletion.error")
+		return admission.Allowed("failed to decode console")
+	}
+
+	// Consoles are deleted again by the garbage collector, and by anyone that
+	// retries, but it's the first deletion that we want to record. Until then, any
+	// existing annotation can't have come from us, so we overwrite it.
+	if !csl.DeletionTimestamp.IsZero() {
+		return admission.Allowed("console is already being deleted")
+	}
+
+	user := req.UserInfo.Username
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{DeletedByAnnotation: user},
+		},
+	})
+	if err != nil {
+		logger.Error(err, "failed to build patch", "event", "deletion.error")
+		return admission.Allowed("failed to record deletion")
+	}
+
+	if err := c.client.Patch(ctx, csl, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		logger.Error(err, "failed to record who deleted the console", "event", "deletion.error")
+		return admission.Allowed("failed to record deletion")
+	}
+
+	logger.Info(fmt.Sprintf("recorded deletion by user %s", user), "event", "deletion.recorded", "user", user)
+
+	return admission.Allowed("deletion recorded")
+}
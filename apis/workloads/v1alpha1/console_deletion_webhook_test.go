@@ -0,0 +1,108 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("ConsoleDeletionWebhook", func() {
+	var (
+		c      client.Client
+		csl    *Console
+		dryRun bool
+		resp   admission.Response
+	)
+
+	BeforeEach(func() {
+		dryRun = false
+		csl = &Console{
+			TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "Console"},
+			ObjectMeta: metav1.ObjectMeta{Name: "console", Namespace: "default"},
+			Spec: ConsoleSpec{
+				User:               "alice@example.com",
+				ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme, decoder := newTestDecoder()
+		c = fake.NewFakeClientWithScheme(scheme, csl.DeepCopy())
+
+		deletion := NewConsoleDeletionWebhook(c, zap.LoggerTo(GinkgoWriter, true))
+		Expect(deletion.InjectDecoder(decoder)).To(Succeed())
+
+		raw, err := json.Marshal(csl)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp = deletion.Handle(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				UID:       "test",
+				Namespace: "default",
+				Operation: admissionv1beta1.Delete,
+				OldObject: runtime.RawExtension{Raw: raw},
+				UserInfo:  authenticationv1.UserInfo{Username: "bob@example.com"},
+				DryRun:    &dryRun,
+			},
+		})
+	})
+
+	deletedBy := func() string {
+		updated := &Console{}
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "console", Namespace: "default"}, updated)).To(Succeed())
+		return updated.DeletedBy()
+	}
+
+	It("Records who deleted the console", func() {
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(deletedBy()).To(Equal("bob@example.com"))
+	})
+
+	Context("When the deletion is a dry run", func() {
+		BeforeEach(func() {
+			dryRun = true
+		})
+
+		It("Doesn't record anything", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(deletedBy()).To(BeEmpty())
+		})
+	})
+
+	Context("When the console has already been deleted by someone else", func() {
+		BeforeEach(func() {
+			now := metav1.Now()
+			csl.DeletionTimestamp = &now
+			csl.Annotations = map[string]string{DeletedByAnnotation: "alice@example.com"}
+		})
+
+		It("Keeps the original deleter", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(deletedBy()).To(Equal("alice@example.com"))
+		})
+	})
+
+	Context("When the annotation was set before the console was deleted", func() {
+		BeforeEach(func() {
+			csl.Annotations = map[string]string{DeletedByAnnotation: "someone-else@example.com"}
+		})
+
+		It("Overwrites it with who deleted the console", func() {
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(deletedBy()).To(Equal("bob@example.com"))
+		})
+	})
+})
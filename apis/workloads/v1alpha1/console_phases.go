@@ -14,4 +14,10 @@ const (
 	ConsoleStopped ConsolePhase = "Stopped"
 	// ConsoleDestroyed means the consoles job has been deleted
 	ConsoleDestroyed ConsolePhase = "Destroyed"
+	// ConsoleFailed means the console's pod failed to start for longer than its
+	// template allows, and the console's job has been deleted
+	ConsoleFailed ConsolePhase = "Failed"
+	// ConsoleTerminating means the console has been deleted, and is waiting for
+	// its pod to stop before it is released
+	ConsoleTerminating ConsolePhase = "Terminating"
 )
@@ -0,0 +1,98 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HostNamespace is a namespace of the node that a pod can share
+// +kubebuilder:validation:Enum=Network;PID;IPC
+type HostNamespace string
+
+const (
+	HostNetwork HostNamespace = "Network"
+	HostPID     HostNamespace = "PID"
+	HostIPC     HostNamespace = "IPC"
+)
+
+// These are the rules of a security profile, which templates name in their
+// SecurityProfileExceptionsAnnotation to be exempted from them
+const (
+	SecurityRuleHostNamespaces         = "host-namespaces"
+	SecurityRulePrivileged             = "privileged"
+	SecurityRuleRunAsNonRoot           = "run-as-non-root"
+	SecurityRuleReadOnlyRootFilesystem = "read-only-root-filesystem"
+	SecurityRuleCapabilities           = "capabilities"
+	SecurityRuleResourceLimits         = "resource-limits"
+	SecurityRuleVolumeTypes            = "volume-types"
+)
+
+// ConsoleSecurityProfileSpec defines the desired state of ConsoleSecurityProfile
+type ConsoleSecurityProfileSpec struct {
+	// Host namespaces that console pods may not share
+	// +optional
+	DisallowedHostNamespaces []HostNamespace `json:"disallowedHostNamespaces,omitempty"`
+
+	// Forbids privileged containers, and containers that allow privilege escalation.
+	// Containers that don't say whether they allow privilege escalation are
+	// configured not to.
+	// +optional
+	DisallowPrivileged bool `json:"disallowPrivileged,omitempty"`
+
+	// Requires console containers to run as a non-root user. Pods that don't say
+	// are configured to.
+	// +optional
+	RequireRunAsNonRoot bool `json:"requireRunAsNonRoot,omitempty"`
+
+	// Requires console containers to have a read-only root filesystem. Containers
+	// that don't say are configured to.
+	// +optional
+	RequireReadOnlyRootFilesystem bool `json:"requireReadOnlyRootFilesystem,omitempty"`
+
+	// Capabilities that console containers must drop, which are added to the
+	// capabilities that each container drops. Containers may not add them back.
+	// +optional
+	RequiredDropCapabilities []corev1.Capability `json:"requiredDropCapabilities,omitempty"`
+
+	// Resources, such as cpu and memory, that every console container must set a
+	// limit for
+	// +optional
+	RequiredResourceLimits []corev1.ResourceName `json:"requiredResourceLimits,omitempty"`
+
+	// Types of volume that console pods may not use, named as they are in the pod
+	// spec, such as hostPath
+	// +optional
+	ForbiddenVolumeTypes []string `json:"forbiddenVolumeTypes,omitempty"`
+}
+
+// ConsoleSecurityProfileStatus defines the observed state of ConsoleSecurityProfile
+type ConsoleSecurityProfileStatus struct{}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
+
+// ConsoleSecurityProfile is the Schema for the consolesecurityprofiles API. Every
+// profile in the cluster applies to every console, so that the ConsoleTemplate
+// webhook rejects templates that break them, and the console controller hardens and
+// checks the pod of each console before it is created.
+type ConsoleSecurityProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsoleSecurityProfileSpec   `json:"spec,omitempty"`
+	Status ConsoleSecurityProfileStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsoleSecurityProfileList contains a list of ConsoleSecurityProfile
+type ConsoleSecurityProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsoleSecurityProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsoleSecurityProfile{}, &ConsoleSecurityProfileList{})
+}
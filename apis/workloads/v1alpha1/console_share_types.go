@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConsoleShareSpec defines the desired state of ConsoleShare
+type ConsoleShareSpec struct {
+	// The reference to the console by name that this console share belongs to.
+	ConsoleRef corev1.LocalObjectReference `json:"consoleRef"`
+
+	// List of users that the console owner has invited to join the console.
+	// +optional
+	Participants []ConsoleParticipant `json:"participants,omitempty"`
+}
+
+// ConsoleParticipant is a user invited to join a console session
+type ConsoleParticipant struct {
+	// Name of the user, as it would appear in a RoleBinding subject
+	Name string `json:"name"`
+
+	// Read-only participants may follow the output of the console, but are not
+	// permitted to attach to it, and so cannot send any input.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// ConsoleShareStatus defines the observed state of ConsoleShare
+type ConsoleShareStatus struct{}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// ConsoleShare is the Schema for the consoleshares API
+type ConsoleShare struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsoleShareSpec   `json:"spec,omitempty"`
+	Status ConsoleShareStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsoleShareList contains a list of ConsoleShare
+type ConsoleShareList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsoleShare `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsoleShare{}, &ConsoleShareList{})
+}
@@ -86,12 +86,71 @@ type ConsoleTemplateSpec struct {
 	// +kubebuilder:validation:Maximum=604800
 	DefaultTTLSecondsAfterFinished *int32 `json:"defaultTtlSecondsAfterFinished,omitempty"`
 
+	// Number of finished Consoles created with this template that are kept for
+	// each user. Once a user has more than this many Consoles that have stopped,
+	// been destroyed or failed, the oldest are garbage collected even if their
+	// TTL hasn't elapsed. If not set, finished Consoles are only collected once
+	// their TTL elapses.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RetainFinishedConsolesPerUser *int32 `json:"retainFinishedConsolesPerUser,omitempty"`
+
+	// Number of seconds that the pod of a Console created with this template can
+	// fail to start, for example because its image can't be pulled or it can't be
+	// scheduled, before the Console moves to the Failed phase and its job is
+	// deleted. If not set, the Console stays Pending until its
+	// TTLSecondsBeforeRunning elapses, with the failure recorded in its status.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	PodFailureTimeoutSeconds *int32 `json:"podFailureTimeoutSeconds,omitempty"`
+
+	// Number of seconds that a running Console created with this template can go
+	// without anyone attaching to it or sending it input before it is stopped.
+	// Activity is reported by the theatre-consoles CLI while attached. If not set,
+	// Consoles run until they complete or their timeout elapses.
+	// +optional
+	// +kubebuilder:validation:Minimum=60
+	// +kubebuilder:validation:Maximum=86400
+	IdleTimeoutSeconds *int32 `json:"idleTimeoutSeconds,omitempty"`
+
+	// Number of seconds that the pod of a Console created with this template is
+	// given to stop after being sent SIGTERM, when the Console is deleted, before
+	// it is killed. If not set, the pod's own termination grace period applies,
+	// which defaults to 30 seconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=3600
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
 	// List of authorisation rules to match against in order from top to bottom.
 	// +optional
 	AuthorisationRules []ConsoleAuthorisationRule `json:"authorisationRules,omitempty"`
 	// Default authorisation rule to use if no authorisation rules are defined or no authorisation rules match.
 	// +optional
 	DefaultAuthorisationRule *ConsoleAuthorisers `json:"defaultAuthorisationRule,omitempty"`
+
+	// Controls copying files into and out of consoles created from this template.
+	// If not set, file transfers are enabled with the default size limit.
+	// +optional
+	FileTransfer *ConsoleFileTransferPolicy `json:"fileTransfer,omitempty"`
+}
+
+// ConsoleFileTransferPolicy declares whether files can be copied into and out of
+// consoles, and how large they may be.
+type ConsoleFileTransferPolicy struct {
+	// Prevent files being copied into or out of consoles created from this
+	// template.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Maximum size, in bytes, of a single file transfer. If not set, this value
+	// defaults to 512KiB, which is also the most that can be set: the contents are
+	// stored in the transfer log while they are copied.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=524288
+	MaxSizeBytes *int64 `json:"maxSizeBytes,omitempty"`
 }
 
 // ConsoleTemplateStatus defines the observed state of ConsoleTemplate
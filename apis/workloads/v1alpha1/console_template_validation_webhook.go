@@ -4,21 +4,23 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/go-logr/logr"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // +kubebuilder:object:generate=false
 type ConsoleTemplateValidationWebhook struct {
+	client  client.Client
 	logger  logr.Logger
 	decoder *admission.Decoder
 }
 
-func NewConsoleTemplateValidationWebhook(logger logr.Logger) *ConsoleTemplateValidationWebhook {
+func NewConsoleTemplateValidationWebhook(c client.Client, logger logr.Logger) *ConsoleTemplateValidationWebhook {
 	return &ConsoleTemplateValidationWebhook{
+		client: c,
 		logger: logger,
 	}
 }
@@ -30,11 +32,6 @@ func (c *ConsoleTemplateValidationWebhook) InjectDecoder(d *admission.Decoder) e
 
 func (c *ConsoleTemplateValidationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
 	logger := c.logger.WithValues("uuid", string(req.UID))
-	logger.Info("starting request", "event", "request.start")
-
-	defer func(start time.Time) {
-		logger.Info("request completed", "event", "request.end", "duration", time.Now().Sub(start).Seconds())
-	}(time.Now())
 
 	template := &ConsoleTemplate{}
 	if err := c.decoder.Decode(req, template); err != nil {
@@ -46,6 +43,22 @@ func (c *ConsoleTemplateValidationWebhook) Handle(ctx context.Context, req admis
 		return admission.ValidationResponse(false, fmt.Sprintf("the console template spec is invalid: %v", err))
 	}
 
+	profiles := &ConsoleSecurityProfileList{}
+	if err := c.client.List(ctx, profiles); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// Check the pod that consoles would run, once the controller has hardened it
+	spec := template.Spec.Template.Spec.DeepCopy()
+	if err := template.ApplySecurityProfiles(profiles.Items, spec); err != nil {
+		logger.Info("security profile violation", "event", "validation.failure")
+		return admission.ValidationResponse(false, fmt.Sprintf("the console template breaks the console security profiles: %v", err))
+	}
+
+	if exceptions := template.Annotations[SecurityProfileExceptionsAnnotation]; exceptions != "" {
+		logger.Info("template is exempt from security profile rules", "event", "validation.exception", "exceptions", exceptions)
+	}
+
 	logger.Info("completed validation", "event", "validation.success")
 	return admission.ValidationResponse(true, "")
 }
@@ -0,0 +1,115 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FileTransferDirection describes whether a file was copied into or out of a console
+type FileTransferDirection string
+
+const (
+	FileTransferUpload   FileTransferDirection = "Upload"
+	FileTransferDownload FileTransferDirection = "Download"
+)
+
+// ConsoleTransferLogSpec defines the desired state of ConsoleTransferLog
+type ConsoleTransferLogSpec struct {
+	// The reference to the console by name that this transfer log belongs to.
+	ConsoleRef corev1.LocalObjectReference `json:"consoleRef"`
+
+	// List of files that have been requested to be copied into or out of the
+	// referenced console.
+	Transfers []ConsoleFileTransfer `json:"transfers"`
+}
+
+// FileTransferPhase describes whether the controller managed to carry out a transfer
+type FileTransferPhase string
+
+const (
+	FileTransferCompleted FileTransferPhase = "Completed"
+	FileTransferFailed    FileTransferPhase = "Failed"
+)
+
+// ConsoleFileTransfer requests that a single file is copied into or out of a
+// console. The controller carries out the transfer, rather than the user, so
+// that the template's limits are enforced where the contents pass through.
+type ConsoleFileTransfer struct {
+	// The user that copied the file. This is set by an admission webhook.
+	User string `json:"user,omitempty"`
+
+	// +kubebuilder:validation:Enum=Upload;Download
+	Direction FileTransferDirection `json:"direction"`
+
+	// Path of the file within the console container
+	Path string `json:"path"`
+
+	// Contents of the file to upload. The controller removes these once it has
+	// written them to the console.
+	// +optional
+	Content []byte `json:"content,omitempty"`
+
+	// Time at which the transfer was requested. This is set by an admission
+	// webhook.
+	// +optional
+	Time metav1.Time `json:"time,omitempty"`
+}
+
+// ConsoleTransferLogStatus defines the observed state of ConsoleTransferLog
+type ConsoleTransferLogStatus struct {
+	// Outcome of each transfer in the spec, in the same order. Transfers without
+	// a result have yet to be carried out.
+	// +optional
+	Results []ConsoleFileTransferResult `json:"results,omitempty"`
+}
+
+// ConsoleFileTransferResult records the outcome of a file transfer, as observed by
+// the controller
+type ConsoleFileTransferResult struct {
+	Phase FileTransferPhase `json:"phase"`
+
+	// Why the transfer failed, if it did
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Size of the file that was transferred
+	// +optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// Hex-encoded SHA-256 checksum of the file that was transferred
+	// +optional
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Contents of a downloaded file. These are removed shortly after the transfer
+	// completes, once the user has had the chance to read them.
+	// +optional
+	Content []byte `json:"content,omitempty"`
+
+	CompletionTime metav1.Time `json:"completionTime"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+
+// ConsoleTransferLog is the Schema for the consoletransferlogs API
+type ConsoleTransferLog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsoleTransferLogSpec   `json:"spec,omitempty"`
+	Status ConsoleTransferLogStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsoleTransferLogList contains a list of ConsoleTransferLog
+type ConsoleTransferLogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsoleTransferLog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsoleTransferLog{}, &ConsoleTransferLogList{})
+}
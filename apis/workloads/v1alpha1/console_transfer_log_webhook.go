@@ -0,0 +1,193 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook"
+)
+
+// +kubebuilder:object:generate=false
+type ConsoleTransferLogWebhook struct {
+	client  client.Client
+	logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+func NewConsoleTransferLogWebhook(c client.Client, logger logr.Logger) *ConsoleTransferLogWebhook {
+	return &ConsoleTransferLogWebhook{
+		client: c,
+		logger: logger,
+	}
+}
+
+func (c *ConsoleTransferLogWebhook) InjectDecoder(d *admission.Decoder) error {
+	c.decoder = d
+	return nil
+}
+
+func (c *ConsoleTransferLogWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := c.logger.WithValues("uuid", string(req.UID))
+
+	updatedLog := &ConsoleTransferLog{}
+	if err := c.decoder.DecodeRaw(req.Object, updatedLog); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	existingLog := &ConsoleTransferLog{}
+	if err := c.decoder.DecodeRaw(req.OldObject, existingLog); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	csl := &Console{}
+	err := c.client.Get(ctx, client.ObjectKey{Namespace: existingLog.Namespace, Name: existingLog.Spec.ConsoleRef.Name}, csl)
+	if err != nil {
+		return admission.ValidationResponse(false, fmt.Sprintf("failed to retrieve console for the transfer log: %v", err))
+	}
+
+	tpl := &ConsoleTemplate{}
+	err = c.client.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Spec.ConsoleTemplateRef.Name}, tpl)
+	if err != nil {
+		return admission.ValidationResponse(false, fmt.Sprintf("failed to retrieve console template for the transfer log: %v", err))
+	}
+
+	update := &ConsoleTransferLogUpdate{
+		existingLog: existingLog,
+		updatedLog:  updatedLog,
+		user:        req.UserInfo.Username,
+		template:    tpl,
+	}
+
+	// Attribute the transfer to whoever made the request, at the time they made it,
+	// rather than trusting the values they provide.
+	update.Attribute(metav1.Now())
+
+	if err := update.Validate(); err != nil {
+		logger.Info("transfer rejected", "event", "transfer.failure", "error", err)
+		return admission.ValidationResponse(false, fmt.Sprintf("the console transfer log spec is invalid: %v", err))
+	}
+
+	logger.Info("transfer accepted", "event", "transfer.success", "user", update.user)
+	return update.Patch().Response("")
+}
+
+type ConsoleTransferLogUpdate struct {
+	existingLog *ConsoleTransferLog
+	updatedLog  *ConsoleTransferLog
+	user        string
+	template    *ConsoleTemplate
+}
+
+// added returns the transfers appended by this update
+func (u *ConsoleTransferLogUpdate) added() []ConsoleFileTransfer {
+	existing, updated := u.existingLog.Spec.Transfers, u.updatedLog.Spec.Transfers
+	if len(updated) <= len(existing) {
+		return nil
+	}
+
+	return updated[len(existing):]
+}
+
+// Attribute sets the user and time of any transfers appended by this update
+func (u *ConsoleTransferLogUpdate) Attribute(now metav1.Time) {
+	added := u.added()
+	for idx := range added {
+		added[idx].User = u.user
+		added[idx].Time = now
+	}
+}
+
+// Patch returns the changes made by Attribute, for the webhook to respond with
+func (u *ConsoleTransferLogUpdate) Patch() webhook.Patch {
+	patch := webhook.Patch{}
+	for idx, transfer := range u.added() {
+		path := webhook.Path("spec", "transfers", webhook.Index(len(u.existingLog.Spec.Transfers)+idx))
+		patch.Add(path+"/user", transfer.User)
+		patch.Add(path+"/time", transfer.Time)
+	}
+
+	return patch
+}
+
+func (u *ConsoleTransferLogUpdate) Validate() error {
+	var err error
+
+	// check immutable fields haven't been updated
+	if !reflect.DeepEqual(u.updatedLog.Spec.ConsoleRef, u.existingLog.Spec.ConsoleRef) {
+		err = multierror.Append(err, errors.New("the spec.consoleRef field is immutable"))
+	}
+
+	// Updates that don't add transfers, such as to labels, are fine, as is the
+	// controller removing the contents of uploads it has carried out
+	existing, updated := u.existingLog.Spec.Transfers, u.updatedLog.Spec.Transfers
+	if len(updated) == len(existing) {
+		for idx := range updated {
+			if !u.unchangedOrContentRemoved(idx) {
+				return multierror.Append(err, errors.New("existing transfers cannot be modified"))
+			}
+		}
+
+		return err
+	}
+
+	// check no existing transfers have been modified and that a single transfer has been added
+	if len(updated) != len(existing)+1 || !reflect.DeepEqual(updated[:len(existing)], existing) {
+		return multierror.Append(err, errors.New("the spec.transfers field can only be appended to (with one transfer) per update"))
+	}
+
+	// The contents of a transfer are held in the log until it has been carried out,
+	// so we only allow one at a time to bound the size of the object
+	if len(u.existingLog.Status.Results) < len(existing) {
+		err = multierror.Append(err, errors.New("another file transfer is in progress"))
+	}
+
+	if !u.template.FileTransferEnabled() {
+		err = multierror.Append(err, errors.New("file transfers are disabled for this console template"))
+	}
+
+	transfer := updated[len(existing)]
+	switch transfer.Direction {
+	case FileTransferUpload:
+		if max := u.template.MaxFileTransferBytes(); int64(len(transfer.Content)) > max {
+			err = multierror.Append(err, errors.Errorf("content exceeds the limit of %d bytes for this console template", max))
+		}
+	case FileTransferDownload:
+		if len(transfer.Content) > 0 {
+			err = multierror.Append(err, errors.New("content must not be set for downloads"))
+		}
+	default:
+		err = multierror.Append(err, errors.Errorf("direction must be %s or %s", FileTransferUpload, FileTransferDownload))
+	}
+
+	if transfer.Path == "" {
+		err = multierror.Append(err, errors.New("path must be set"))
+	}
+
+	return err
+}
+
+// unchangedOrContentRemoved returns whether the transfer at the given index is
+// unchanged, aside from its contents being removed once it has a result
+func (u *ConsoleTransferLogUpdate) unchangedOrContentRemoved(idx int) bool {
+	existing, updated := u.existingLog.Spec.Transfers[idx], u.updatedLog.Spec.Transfers[idx]
+	if reflect.DeepEqual(updated, existing) {
+		return true
+	}
+
+	if len(updated.Content) > 0 || idx >= len(u.existingLog.Status.Results) {
+		return false
+	}
+
+	existing.Content = nil
+	updated.Content = nil
+	return reflect.DeepEqual(updated, existing)
+}
@@ -0,0 +1,254 @@
+package v1alpha1
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook/webhooktest"
+)
+
+var _ = Describe("Transfer log webhook", func() {
+	Describe("Validate", func() {
+		var (
+			existingLog *ConsoleTransferLog
+			updatedLog  *ConsoleTransferLog
+			template    *ConsoleTemplate
+			transfer    ConsoleFileTransfer
+			update      *ConsoleTransferLogUpdate
+			err         error
+		)
+
+		recorded := ConsoleFileTransfer{
+			User:      "someone-else",
+			Direction: FileTransferUpload,
+			Path:      "/tmp/report.csv",
+			Content:   []byte("id,amount\n"),
+			Time:      metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		}
+
+		BeforeEach(func() {
+			existingLog = &ConsoleTransferLog{
+				Spec: ConsoleTransferLogSpec{
+					Transfers: []ConsoleFileTransfer{recorded},
+				},
+				Status: ConsoleTransferLogStatus{
+					Results: []ConsoleFileTransferResult{{Phase: FileTransferCompleted}},
+				},
+			}
+
+			template = &ConsoleTemplate{}
+			transfer = ConsoleFileTransfer{
+				User:      "forged-user",
+				Direction: FileTransferUpload,
+				Path:      "/tmp/input.csv",
+				Content:   []byte(strings.Repeat("b", 2048)),
+			}
+		})
+
+		JustBeforeEach(func() {
+			if updatedLog == nil {
+				updatedLog = existingLog.DeepCopy()
+				updatedLog.Spec.Transfers = append(updatedLog.Spec.Transfers, transfer)
+			}
+
+			update = &ConsoleTransferLogUpdate{
+				existingLog: existingLog,
+				updatedLog:  updatedLog,
+				user:        "current-user",
+				template:    template,
+			}
+
+			update.Attribute(metav1.Now())
+			err = update.Validate()
+		})
+
+		AfterEach(func() {
+			updatedLog = nil
+		})
+
+		Context("Appending a single transfer", func() {
+			It("Returns no errors", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Attributes the transfer to the current user", func() {
+				Expect(updatedLog.Spec.Transfers[1].User).To(Equal("current-user"))
+				Expect(updatedLog.Spec.Transfers[1].Time.IsZero()).To(BeFalse())
+			})
+
+			It("Leaves existing transfers untouched", func() {
+				Expect(updatedLog.Spec.Transfers[0]).To(Equal(recorded))
+			})
+		})
+
+		Context("Updating without changing the transfers", func() {
+			BeforeEach(func() {
+				updatedLog = existingLog.DeepCopy()
+				updatedLog.Labels = map[string]string{"new": "label"}
+			})
+
+			It("Returns no errors", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("Removing the contents of a completed transfer", func() {
+			BeforeEach(func() {
+				updatedLog = existingLog.DeepCopy()
+				updatedLog.Spec.Transfers[0].Content = nil
+			})
+
+			It("Returns no errors", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("Removing the contents of a transfer that hasn't been carried out", func() {
+			BeforeEach(func() {
+				existingLog.Status.Results = nil
+				updatedLog = existingLog.DeepCopy()
+				updatedLog.Spec.Transfers[0].Content = nil
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("cannot be modified")))
+			})
+		})
+
+		Context("Replacing the contents of an existing transfer", func() {
+			BeforeEach(func() {
+				updatedLog = existingLog.DeepCopy()
+				updatedLog.Spec.Transfers[0].Content = []byte("forged")
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("cannot be modified")))
+			})
+		})
+
+		Context("Modifying an existing transfer", func() {
+			BeforeEach(func() {
+				updatedLog = existingLog.DeepCopy()
+				updatedLog.Spec.Transfers[0].Path = "/etc/passwd"
+				updatedLog.Spec.Transfers = append(updatedLog.Spec.Transfers, transfer)
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("can only be appended to")))
+			})
+		})
+
+		Context("Appending multiple transfers", func() {
+			BeforeEach(func() {
+				updatedLog = existingLog.DeepCopy()
+				updatedLog.Spec.Transfers = append(updatedLog.Spec.Transfers, transfer, transfer)
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("can only be appended to")))
+			})
+		})
+
+		Context("While another transfer is in progress", func() {
+			BeforeEach(func() {
+				existingLog.Status.Results = nil
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("another file transfer is in progress")))
+			})
+		})
+
+		Context("When the template disables file transfers", func() {
+			BeforeEach(func() {
+				template.Spec.FileTransfer = &ConsoleFileTransferPolicy{Disabled: true}
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("file transfers are disabled")))
+			})
+		})
+
+		Context("When the transfer exceeds the template's size limit", func() {
+			BeforeEach(func() {
+				maxSizeBytes := int64(1024)
+				template.Spec.FileTransfer = &ConsoleFileTransferPolicy{MaxSizeBytes: &maxSizeBytes}
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("exceeds the limit of 1024 bytes")))
+			})
+		})
+
+		Context("When the template's size limit is above the default", func() {
+			BeforeEach(func() {
+				maxSizeBytes := 2 * DefaultMaxFileTransferBytes
+				template.Spec.FileTransfer = &ConsoleFileTransferPolicy{MaxSizeBytes: &maxSizeBytes}
+				transfer.Content = make([]byte, DefaultMaxFileTransferBytes+1)
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("exceeds the limit")))
+			})
+		})
+
+		Context("When the transfer exceeds the default size limit", func() {
+			BeforeEach(func() {
+				transfer.Content = make([]byte, DefaultMaxFileTransferBytes+1)
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("exceeds the limit")))
+			})
+		})
+
+		Context("Downloading with contents", func() {
+			BeforeEach(func() {
+				transfer.Direction = FileTransferDownload
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("content must not be set for downloads")))
+			})
+		})
+
+		Context("Without a path", func() {
+			BeforeEach(func() {
+				transfer.Path = ""
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("path must be set")))
+			})
+		})
+	})
+
+	Describe("Patch", func() {
+		It("Only sets the attribution of appended transfers", func() {
+			existingLog := &ConsoleTransferLog{
+				Spec: ConsoleTransferLogSpec{
+					Transfers: []ConsoleFileTransfer{{User: "someone-else", Path: "/tmp/report.csv"}},
+				},
+			}
+
+			updatedLog := existingLog.DeepCopy()
+			updatedLog.Spec.Transfers = append(updatedLog.Spec.Transfers, ConsoleFileTransfer{
+				User: "forged-user",
+				Path: "/tmp/input.csv",
+			})
+
+			update := &ConsoleTransferLogUpdate{
+				existingLog: existingLog,
+				updatedLog:  updatedLog,
+				user:        "current-user",
+			}
+			update.Attribute(metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+			webhooktest.ExpectGoldenPatch("testdata/console_transfer_log_append.patch.json", update.Patch())
+		})
+	})
+})
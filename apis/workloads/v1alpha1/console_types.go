@@ -50,6 +50,18 @@ type ConsoleSpec struct {
 	// situations, enabling the TTY on a container in the console causes
 	// breakage - in Tekton steps, for example.
 	Noninteractive bool `json:"noninteractive,omitempty"`
+
+	// A script to run in the console. The script is mounted into the console
+	// container and its path is appended to the command, which acts as the
+	// interpreter. If no command is specified the script is run with /bin/sh.
+	// +optional
+	Script string `json:"script,omitempty"`
+
+	// Hex-encoded SHA-256 checksum of the script. This is set by an admission
+	// webhook, so that authorisers and audit logs can refer to the exact
+	// content that was run.
+	// +optional
+	ScriptSHA256 string `json:"scriptSha256,omitempty"`
 }
 
 // ConsoleStatus defines the observed state of Console
@@ -59,6 +71,34 @@ type ConsoleStatus struct {
 	// Time at which the job completed successfully
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 	Phase          ConsolePhase `json:"phase"`
+	// Participants that have been granted access to the running console
+	Participants []ConsoleParticipantStatus `json:"participants,omitempty"`
+	// Number of file transfers that have been recorded in the audit log
+	AuditedTransfers int `json:"auditedTransfers,omitempty"`
+	// Why the console's pod is failing to start, such as when its image can't be
+	// pulled or it can't be scheduled. Cleared if the pod recovers.
+	PodFailure *ConsolePodFailure `json:"podFailure,omitempty"`
+	// Time at which the console was found to have been idle for longer than its
+	// template permits, after which it is stopped
+	IdleTime *metav1.Time `json:"idleTime,omitempty"`
+}
+
+// ConsolePodFailure describes why a console's pod is failing to start
+type ConsolePodFailure struct {
+	// A brief CamelCase reason, such as ImagePullBackOff, Unschedulable or
+	// CrashLoopBackOff
+	Reason string `json:"reason"`
+	// A human readable description of the failure, taken from the pod status
+	Message string `json:"message,omitempty"`
+	// Time at which the pod was first seen failing for this reason
+	Since metav1.Time `json:"since"`
+}
+
+// ConsoleParticipantStatus records when a participant joined the console
+type ConsoleParticipantStatus struct {
+	Name       string      `json:"name"`
+	ReadOnly   bool        `json:"readOnly,omitempty"`
+	JoinedTime metav1.Time `json:"joinedTime"`
 }
 
 // +kubebuilder:object:root=true
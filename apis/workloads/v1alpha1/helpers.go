@@ -1,10 +1,21 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/gocardless/theatre/v2/pkg/cron"
+	rbacutils "github.com/gocardless/theatre/v2/pkg/rbac"
 )
 
 // Creating returns true if the console has no status (the console has just been created)
@@ -43,14 +54,26 @@ func (c *Console) Destroyed() bool {
 	return c.Status.Phase == ConsoleDestroyed
 }
 
+// Failed returns true if the console's pod failed to start
+func (c *Console) Failed() bool {
+	return c.Status.Phase == ConsoleFailed
+}
+
+// Terminating returns true if the console has been deleted, and is waiting for
+// its pod to stop
+func (c *Console) Terminating() bool {
+	return c.Status.Phase == ConsoleTerminating
+}
+
 // PreRunning returns true if the console is in a phase before Running
 func (c *Console) PreRunning() bool {
 	return c.Creating() || c.PendingAuthorisation() || c.Pending()
 }
 
-// PostRunning returns true if the console is in a phase after Running
+// PostRunning returns true if the console is in a phase after Running, or one
+// that it can't run from
 func (c *Console) PostRunning() bool {
-	return c.Stopped() || c.Destroyed()
+	return c.Stopped() || c.Destroyed() || c.Failed()
 }
 
 // EligibleForGC returns whether a console can be garbage collected
@@ -69,8 +92,13 @@ func (c *Console) EligibleForGC() bool {
 // This will be the case if:
 // - TTLSecondsBeforeRunning has elapsed and the console hasn't progressed to running
 // - TTLSecondsAfterFinished has elapsed and the console is stopped or destroyed
+// - TTLSecondsAfterFinished has elapsed since the pod of a failed console started
+//   failing
 func (c *Console) GetGCTime() *time.Time {
 	switch {
+	case c.Failed() && c.Status.PodFailure != nil:
+		t := c.Status.PodFailure.Since.Add(c.TTLSecondsAfterFinished())
+		return &t
 	case c.PreRunning():
 		// When the console hasn't progressed to the running phase
 		t := c.CreationTimestamp.Add(c.TTLSecondsBeforeRunning())
@@ -82,8 +110,10 @@ func (c *Console) GetGCTime() *time.Time {
 			return &t
 		}
 		// When the console never completed
-		t := c.Status.ExpiryTime.Time.Add(c.TTLSecondsAfterFinished())
-		return &t
+		if c.Status.ExpiryTime != nil {
+			t := c.Status.ExpiryTime.Time.Add(c.TTLSecondsAfterFinished())
+			return &t
+		}
 	}
 
 	return nil
@@ -221,6 +251,53 @@ func (ct *ConsoleTemplate) HasAuthorisationRules() bool {
 	return false
 }
 
+// DefaultMaxFileTransferBytes is the largest file that can be copied into or out
+// of a console, unless the template specifies a smaller limit. File contents pass
+// through the transfer log, so this is kept well within the size of an object.
+const DefaultMaxFileTransferBytes int64 = 512 * 1024
+
+// FileTransferEnabled returns whether files can be copied into and out of
+// consoles created from this template.
+func (ct *ConsoleTemplate) FileTransferEnabled() bool {
+	return ct.Spec.FileTransfer == nil || !ct.Spec.FileTransfer.Disabled
+}
+
+// PodFailureTimeout returns how long the pod of a console created from this
+// template can fail to start before the console fails, and whether consoles should
+// fail at all.
+func (ct *ConsoleTemplate) PodFailureTimeout() (time.Duration, bool) {
+	if ct.Spec.PodFailureTimeoutSeconds == nil {
+		return 0, false
+	}
+
+	return time.Duration(*ct.Spec.PodFailureTimeoutSeconds) * time.Second, true
+}
+
+// IdleTimeout returns how long a running console created from this template can go
+// without activity before it is stopped, and whether idle consoles should be stopped
+// at all.
+func (ct *ConsoleTemplate) IdleTimeout() (time.Duration, bool) {
+	if ct.Spec.IdleTimeoutSeconds == nil {
+		return 0, false
+	}
+
+	return time.Duration(*ct.Spec.IdleTimeoutSeconds) * time.Second, true
+}
+
+// MaxFileTransferBytes returns the largest file that can be copied into or out
+// of consoles created from this template.
+func (ct *ConsoleTemplate) MaxFileTransferBytes() int64 {
+	if ct.Spec.FileTransfer == nil || ct.Spec.FileTransfer.MaxSizeBytes == nil {
+		return DefaultMaxFileTransferBytes
+	}
+
+	if *ct.Spec.FileTransfer.MaxSizeBytes > DefaultMaxFileTransferBytes {
+		return DefaultMaxFileTransferBytes
+	}
+
+	return *ct.Spec.FileTransfer.MaxSizeBytes
+}
+
 // Validate checks the console template object for correctness and returns a
 // list of errors.
 func (ct *ConsoleTemplate) Validate() error {
@@ -254,5 +331,616 @@ func (ct *ConsoleTemplate) Validate() error {
 		))
 	}
 
+	exceptions := []string{}
+	for rule := range ct.SecurityProfileExceptions() {
+		exceptions = append(exceptions, rule)
+	}
+
+	sort.Strings(exceptions)
+	for _, rule := range exceptions {
+		if !securityRules[rule] {
+			err = multierror.Append(err, errors.Errorf(
+				".metadata.annotations[%s]: unknown security profile rule %s",
+				SecurityProfileExceptionsAnnotation, rule,
+			))
+		}
+	}
+
+	return err
+}
+
+const (
+	// ConsoleScriptDirectory is where a console's script is mounted within the
+	// console container.
+	ConsoleScriptDirectory = "/var/run/theatre/console-script"
+	// ConsoleScriptKey is the key under which the script is stored in its
+	// ConfigMap, and therefore the name of the mounted file.
+	ConsoleScriptKey = "script"
+	// DefaultScriptInterpreter runs scripts when the console specifies no command.
+	DefaultScriptInterpreter = "/bin/sh"
+	// MaxScriptBytes is the largest script that a console can run.
+	MaxScriptBytes = 256 * 1024
+)
+
+const (
+	// ConsoleFinalizer is held by every console until the controller has stopped
+	// its pod and recorded the console's deletion in the audit log.
+	ConsoleFinalizer = "workloads.crd.gocardless.com/console-cleanup"
+	// DeletedByAnnotation records the user that deleted a console, so that it can
+	// be included in the audit log once the console has been cleaned up.
+	DeletedByAnnotation = "workloads.crd.gocardless.com/deleted-by"
+)
+
+// DeletedBy returns the user that deleted the console, if known.
+func (c *Console) DeletedBy() string {
+	return c.Annotations[DeletedByAnnotation]
+}
+
+// HasScript returns whether the console runs a script.
+func (c *Console) HasScript() bool {
+	return c.Spec.Script != ""
+}
+
+// ScriptPath returns the path of the console's script within the console container.
+func (c *Console) ScriptPath() string {
+	return ConsoleScriptDirectory + "/" + ConsoleScriptKey
+}
+
+// GetCommand returns the command that the console runs: either its own command
+// or the template default, with the path to the console's script appended if
+// it has one. This is the command that authorisation rules are matched against.
+func (c *Console) GetCommand(template *ConsoleTemplate) ([]string, error) {
+	if c.HasScript() {
+		command := c.Spec.Command
+		if len(command) == 0 {
+			command = []string{DefaultScriptInterpreter}
+		}
+
+		return append(append([]string{}, command...), c.ScriptPath()), nil
+	}
+
+	if len(c.Spec.Command) > 0 {
+		return c.Spec.Command, nil
+	}
+
+	return template.GetDefaultCommandWithArgs()
+}
+
+// podStartFailureReasons are the reasons a container waits with when it's failing
+// to start, rather than starting slowly. Kubelet retries all of them, but none
+// will resolve without someone changing the pod or the cluster.
+var podStartFailureReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+	"CrashLoopBackOff":           true,
+}
+
+// DetectPodFailure returns why a console's pod is failing to start, or nil if it's
+// starting normally. The Since time of the failure is left for the caller to set.
+func DetectPodFailure(pod *corev1.Pod) *ConsolePodFailure {
+	if pod == nil {
+		return nil
+	}
+
+	if pod.Status.Phase == corev1.PodFailed {
+		reason := pod.Status.Reason
+		if reason == "" {
+			reason = "PodFailed"
+		}
+
+		return &ConsolePodFailure{Reason: reason, Message: pod.Status.Message}
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == corev1.PodReasonUnschedulable {
+			return &ConsolePodFailure{Reason: condition.Reason, Message: condition.Message}
+		}
+	}
+
+	statuses := append(
+		append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...),
+		pod.Status.ContainerStatuses...,
+	)
+
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil && podStartFailureReasons[waiting.Reason] {
+			message := fmt.Sprintf("container %s is waiting", status.Name)
+			if waiting.Message != "" {
+				message = fmt.Sprintf("container %s: %s", status.Name, waiting.Message)
+			}
+
+			return &ConsolePodFailure{Reason: waiting.Reason, Message: message}
+		}
+	}
+
+	return nil
+}
+
+// PrioritySubject is a pod being admitted by the priority injector, along with the
+// context that priority rules match on.
+type PrioritySubject struct {
+	Pod       *corev1.Pod
+	Namespace *corev1.Namespace
+	// The kinds of the objects controlling the pod, starting with its immediate
+	// controller.
+	OwnerKinds []string
+}
+
+// ServiceAccountName returns the service account that the pod runs as, which is the
+// namespace default when the pod doesn't specify one.
+func (s PrioritySubject) ServiceAccountName() string {
+	if s.Pod.Spec.ServiceAccountName == "" {
+		return "default"
+	}
+
+	return s.Pod.Spec.ServiceAccountName
+}
+
+// Match returns the first rule in the policy that matches the subject, or nil if
+// none of them do.
+func (p *PriorityPolicy) Match(subject PrioritySubject) (*PriorityRule, error) {
+	for idx := range p.Spec.Rules {
+		rule := &p.Spec.Rules[idx]
+
+		matches, err := rule.Matches(subject)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %s", rule.Name)
+		}
+
+		if matches {
+			return rule, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// MatchesOwnerKinds returns whether any rule in the policy matches on owner kinds,
+// which are costly to discover as it means fetching the owners of the pod.
+func (p *PriorityPolicy) MatchesOwnerKinds() bool {
+	for _, rule := range p.Spec.Rules {
+		if len(rule.OwnerKinds) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches returns whether every condition of the rule matches the subject.
+func (r *PriorityRule) Matches(subject PrioritySubject) (bool, error) {
+	if matches, err := selectorMatches(r.PodSelector, subject.Pod.Labels); err != nil || !matches {
+		return false, errors.Wrap(err, "invalid pod selector")
+	}
+
+	if matches, err := selectorMatches(r.NamespaceSelector, subject.Namespace.Labels); err != nil || !matches {
+		return false, errors.Wrap(err, "invalid namespace selector")
+	}
+
+	if len(r.OwnerKinds) > 0 && !containsAny(r.OwnerKinds, subject.OwnerKinds) {
+		return false, nil
+	}
+
+	if len(r.ServiceAccountNames) > 0 && !containsAny(r.ServiceAccountNames, []string{subject.ServiceAccountName()}) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// selectorMatches returns whether the labels match the selector, where a nil selector
+// matches everything.
+func selectorMatches(selector *metav1.LabelSelector, set map[string]string) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+
+	return s.Matches(labels.Set(set)), nil
+}
+
+func containsAny(candidates, values []string) bool {
+	for _, candidate := range candidates {
+		for _, value := range values {
+			if candidate == value {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SecurityProfileExceptionsAnnotation lists, separated by commas, the security profile
+// rules that a console template is exempt from, such as "host-namespaces,volume-types".
+const SecurityProfileExceptionsAnnotation = "workloads.crd.gocardless.com/security-profile-exceptions"
+
+var securityRules = map[string]bool{
+	SecurityRuleHostNamespaces:         true,
+	SecurityRulePrivileged:             true,
+	SecurityRuleRunAsNonRoot:           true,
+	SecurityRuleReadOnlyRootFilesystem: true,
+	SecurityRuleCapabilities:           true,
+	SecurityRuleResourceLimits:         true,
+	SecurityRuleVolumeTypes:            true,
+}
+
+// SecurityProfileExceptions returns the security profile rules that the template is
+// exempt from
+func (ct *ConsoleTemplate) SecurityProfileExceptions() map[string]bool {
+	exceptions := map[string]bool{}
+	for _, rule := range strings.Split(ct.Annotations[SecurityProfileExceptionsAnnotation], ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			exceptions[rule] = true
+		}
+	}
+
+	return exceptions
+}
+
+// ApplySecurityProfiles hardens the pod spec of a console created from this template,
+// filling in anything that the profiles require which the spec leaves unset, and
+// returns an error describing any way in which the spec still breaks them. Rules that
+// the template is exempt from are ignored.
+func (ct *ConsoleTemplate) ApplySecurityProfiles(profiles []ConsoleSecurityProfile, spec *corev1.PodSpec) error {
+	var err error
+
+	exceptions := ct.SecurityProfileExceptions()
+	for _, profile := range profiles {
+		profile.harden(spec, exceptions)
+		for _, violation := range profile.violations(spec, exceptions) {
+			err = multierror.Append(err, errors.Errorf("security profile %s: %s", profile.Name, violation))
+		}
+	}
+
+	return err
+}
+
+func (p *ConsoleSecurityProfile) harden(spec *corev1.PodSpec, exceptions map[string]bool) {
+	if p.Spec.RequireRunAsNonRoot && !exceptions[SecurityRuleRunAsNonRoot] {
+		if spec.SecurityContext == nil {
+			spec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		if spec.SecurityContext.RunAsNonRoot == nil {
+			runAsNonRoot := true
+			spec.SecurityContext.RunAsNonRoot = &runAsNonRoot
+		}
+	}
+
+	for _, container := range podContainers(spec) {
+		if container.SecurityContext == nil {
+			container.SecurityContext = &corev1.SecurityContext{}
+		}
+		sc := container.SecurityContext
+
+		if p.Spec.DisallowPrivileged && !exceptions[SecurityRulePrivileged] && sc.AllowPrivilegeEscalation == nil {
+			allowPrivilegeEscalation := false
+			sc.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+		}
+
+		if p.Spec.RequireReadOnlyRootFilesystem && !exceptions[SecurityRuleReadOnlyRootFilesystem] && sc.ReadOnlyRootFilesystem == nil {
+			readOnlyRootFilesystem := true
+			sc.ReadOnlyRootFilesystem = &readOnlyRootFilesystem
+		}
+
+		if len(p.Spec.RequiredDropCapabilities) > 0 && !exceptions[SecurityRuleCapabilities] {
+			if sc.Capabilities == nil {
+				sc.Capabilities = &corev1.Capabilities{}
+			}
+			for _, capability := range p.Spec.RequiredDropCapabilities {
+				if !containsCapability(sc.Capabilities.Drop, capability) {
+					sc.Capabilities.Drop = append(sc.Capabilities.Drop, capability)
+				}
+			}
+		}
+
+		// Don't leave behind an empty security context for containers that we had
+		// nothing to set on
+		if reflect.DeepEqual(*sc, corev1.SecurityContext{}) {
+			container.SecurityContext = nil
+		}
+	}
+}
+
+func (p *ConsoleSecurityProfile) violations(spec *corev1.PodSpec, exceptions map[string]bool) []string {
+	var violations []string
+
+	if !exceptions[SecurityRuleHostNamespaces] {
+		shared := map[HostNamespace]bool{HostNetwork: spec.HostNetwork, HostPID: spec.HostPID, HostIPC: spec.HostIPC}
+		for _, namespace := range p.Spec.DisallowedHostNamespaces {
+			if shared[namespace] {
+				violations = append(violations, fmt.Sprintf("pod shares the host's %s namespace", namespace))
+			}
+		}
+	}
+
+	if len(p.Spec.ForbiddenVolumeTypes) > 0 && !exceptions[SecurityRuleVolumeTypes] {
+		for _, volume := range spec.Volumes {
+			if volumeType := getVolumeType(volume); containsAny(p.Spec.ForbiddenVolumeTypes, []string{volumeType}) {
+				violations = append(violations, fmt.Sprintf("volume %s is of forbidden type %s", volume.Name, volumeType))
+			}
+		}
+	}
+
+	for _, container := range podContainers(spec) {
+		sc := container.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+
+		if p.Spec.DisallowPrivileged && !exceptions[SecurityRulePrivileged] {
+			if sc.Privileged != nil && *sc.Privileged {
+				violations = append(violations, fmt.Sprintf("container %s is privileged", container.Name))
+			}
+			if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
+				violations = append(violations, fmt.Sprintf("container %s allows privilege escalation", container.Name))
+			}
+		}
+
+		if p.Spec.RequireRunAsNonRoot && !exceptions[SecurityRuleRunAsNonRoot] && mayRunAsRoot(spec.SecurityContext, sc) {
+			violations = append(violations, fmt.Sprintf("container %s may run as root", container.Name))
+		}
+
+		if p.Spec.RequireReadOnlyRootFilesystem && !exceptions[SecurityRuleReadOnlyRootFilesystem] &&
+			(sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem) {
+			violations = append(violations, fmt.Sprintf("container %s has a writable root filesystem", container.Name))
+		}
+
+		if len(p.Spec.RequiredDropCapabilities) > 0 && !exceptions[SecurityRuleCapabilities] && sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if containsCapability(p.Spec.RequiredDropCapabilities, capability) || containsCapability(p.Spec.RequiredDropCapabilities, "ALL") {
+					violations = append(violations, fmt.Sprintf("container %s adds capability %s", container.Name, capability))
+				}
+			}
+		}
+
+		if !exceptions[SecurityRuleResourceLimits] {
+			for _, resource := range p.Spec.RequiredResourceLimits {
+				if _, ok := container.Resources.Limits[resource]; !ok {
+					violations = append(violations, fmt.Sprintf("container %s has no %s limit", container.Name, resource))
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+// podContainers returns pointers to all of the containers in the pod spec, so that
+// they can be modified in place
+func podContainers(spec *corev1.PodSpec) []*corev1.Container {
+	var containers []*corev1.Container
+	for idx := range spec.InitContainers {
+		containers = append(containers, &spec.InitContainers[idx])
+	}
+	for idx := range spec.Containers {
+		containers = append(containers, &spec.Containers[idx])
+	}
+
+	return containers
+}
+
+// mayRunAsRoot returns whether a container could run as root, where the container's
+// security context takes precedence over the pod's
+func mayRunAsRoot(podSC *corev1.PodSecurityContext, sc *corev1.SecurityContext) bool {
+	runAsNonRoot, runAsUser := sc.RunAsNonRoot, sc.RunAsUser
+	if podSC != nil {
+		if runAsNonRoot == nil {
+			runAsNonRoot = podSC.RunAsNonRoot
+		}
+		if runAsUser == nil {
+			runAsUser = podSC.RunAsUser
+		}
+	}
+
+	if runAsUser != nil && *runAsUser == 0 {
+		return true
+	}
+
+	return runAsNonRoot == nil || !*runAsNonRoot
+}
+
+func containsCapability(capabilities []corev1.Capability, capability corev1.Capability) bool {
+	for _, candidate := range capabilities {
+		if strings.EqualFold(string(candidate), string(capability)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getVolumeType returns the name of the field that sets the volume's source, such as
+// hostPath or configMap
+func getVolumeType(volume corev1.Volume) string {
+	source := reflect.ValueOf(volume.VolumeSource)
+	for idx := 0; idx < source.NumField(); idx++ {
+		if field := source.Field(idx); field.Kind() == reflect.Ptr && !field.IsNil() {
+			return strings.SplitN(source.Type().Field(idx).Tag.Get("json"), ",", 2)[0]
+		}
+	}
+
+	return ""
+}
+
+const (
+	// ScheduledConsoleLabel is given to the consoles that a scheduled console
+	// creates, with the name of the scheduled console.
+	ScheduledConsoleLabel = "workloads.crd.gocardless.com/scheduled-console"
+	// ScheduledTimeAnnotation records the time at which a scheduled console was due
+	// to create the console, in RFC 3339 format.
+	ScheduledTimeAnnotation = "workloads.crd.gocardless.com/scheduled-time"
+	// MaxScheduledConsoleNameLength leaves room in the names of the consoles that a
+	// scheduled console creates for the time they were due, while keeping the names
+	// of their jobs short enough to be label values.
+	MaxScheduledConsoleNameLength = 46
+
+	DefaultSuccessfulConsolesHistoryLimit = 3
+	DefaultFailedConsolesHistoryLimit     = 1
+)
+
+// Validate checks the scheduled console for anything that the API server can't.
+func (sc *ScheduledConsole) Validate() error {
+	var err error
+
+	if len(sc.Name) > MaxScheduledConsoleNameLength {
+		err = multierror.Append(err, errors.Errorf(
+			".metadata.name: must be no more than %d characters", MaxScheduledConsoleNameLength,
+		))
+	}
+
+	if _, parseErr := cron.Parse(sc.Spec.Schedule); parseErr != nil {
+		err = multierror.Append(err, errors.Errorf(".spec.schedule: %v", parseErr))
+	}
+
 	return err
 }
+
+// GetConcurrencyPolicy returns the concurrency policy, defaulting to Forbid.
+func (sc *ScheduledConsole) GetConcurrencyPolicy() ConcurrencyPolicy {
+	if sc.Spec.ConcurrencyPolicy == "" {
+		return ForbidConcurrent
+	}
+
+	return sc.Spec.ConcurrencyPolicy
+}
+
+// SuccessfulConsolesHistoryLimit returns how many successful consoles to keep.
+func (sc *ScheduledConsole) SuccessfulConsolesHistoryLimit() int {
+	if sc.Spec.SuccessfulConsolesHistoryLimit == nil {
+		return DefaultSuccessfulConsolesHistoryLimit
+	}
+
+	return int(*sc.Spec.SuccessfulConsolesHistoryLimit)
+}
+
+// FailedConsolesHistoryLimit returns how many failed consoles to keep.
+func (sc *ScheduledConsole) FailedConsolesHistoryLimit() int {
+	if sc.Spec.FailedConsolesHistoryLimit == nil {
+		return DefaultFailedConsolesHistoryLimit
+	}
+
+	return int(*sc.Spec.FailedConsolesHistoryLimit)
+}
+
+// ConsoleName returns the name of the console that is due at the given time, which
+// is the same each time we try to create it.
+func (sc *ScheduledConsole) ConsoleName(scheduledTime time.Time) string {
+	return fmt.Sprintf("%s-%d", sc.Name, scheduledTime.Unix()/60)
+}
+
+// BuildConsole returns the console that is due at the given time.
+func (sc *ScheduledConsole) BuildConsole(scheduledTime time.Time) *Console {
+	tpl := sc.Spec.ConsoleTemplate
+
+	return &Console{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sc.ConsoleName(scheduledTime),
+			Namespace: sc.Namespace,
+			Labels:    labels.Merge(tpl.Labels, map[string]string{ScheduledConsoleLabel: sc.Name}),
+			Annotations: map[string]string{
+				ScheduledTimeAnnotation: scheduledTime.UTC().Format(time.RFC3339),
+			},
+			// The console's template is its controller, so we only own it in order for
+			// the console to be deleted along with us
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: GroupVersion.String(),
+					Kind:       "ScheduledConsole",
+					Name:       sc.Name,
+					UID:        sc.UID,
+				},
+			},
+		},
+		Spec: ConsoleSpec{
+			User:               sc.Spec.User,
+			Reason:             tpl.Reason,
+			TimeoutSeconds:     tpl.TimeoutSeconds,
+			ConsoleTemplateRef: tpl.ConsoleTemplateRef,
+			Command:            tpl.Command,
+			Noninteractive:     tpl.Noninteractive,
+		},
+	}
+}
+
+// ScheduledConsoleOwner returns the name of the scheduled console that owns the
+// console, if any.
+func (c *Console) ScheduledConsoleOwner() string {
+	for _, ref := range c.OwnerReferences {
+		if ref.APIVersion == GroupVersion.String() && ref.Kind == "ScheduledConsole" {
+			return ref.Name
+		}
+	}
+
+	return ""
+}
+
+// ScheduledTime returns the time at which the console was due to be created by its
+// scheduled console, if any.
+func (c *Console) ScheduledTime() (time.Time, bool) {
+	scheduledTime, err := time.Parse(time.RFC3339, c.Annotations[ScheduledTimeAnnotation])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return scheduledTime, true
+}
+
+// PreApprovedSubjects returns those that approved the scheduled console ahead of
+// time in a way that applies to the console.
+//
+// Anyone able to create consoles could create one that claims to have been created
+// by the scheduled console, so we only accept approvals for consoles that are
+// exactly what the scheduled console would have created itself: for its current
+// spec, at a time it was due, with approvals given within the window before then.
+func (sc *ScheduledConsole) PreApprovedSubjects(auth *ScheduledConsoleAuthorisation, csl *Console) []rbacv1.Subject {
+	if sc.Spec.PreApproval == nil || auth == nil {
+		return nil
+	}
+
+	scheduledTime, ok := csl.ScheduledTime()
+	if !ok || csl.CreationTimestamp.Time.Before(scheduledTime) {
+		return nil
+	}
+
+	schedule, err := cron.Parse(sc.Spec.Schedule)
+	if err != nil || !schedule.Next(scheduledTime.Add(-time.Minute)).Equal(scheduledTime) {
+		return nil
+	}
+
+	expected := sc.BuildConsole(scheduledTime)
+	if csl.Name != expected.Name || csl.Spec.User != expected.Spec.User ||
+		csl.Spec.Reason != expected.Spec.Reason || csl.Spec.Script != "" ||
+		!reflect.DeepEqual(csl.Spec.ConsoleTemplateRef, expected.Spec.ConsoleTemplateRef) ||
+		!reflect.DeepEqual(csl.Spec.Command, expected.Spec.Command) {
+		return nil
+	}
+
+	window := time.Duration(sc.Spec.PreApproval.WindowSeconds) * time.Second
+
+	var subjects []rbacv1.Subject
+	for _, approval := range auth.Spec.Approvals {
+		if approval.Generation != sc.Generation || approval.Subject.Name == csl.Spec.User {
+			continue
+		}
+
+		if scheduledTime.Before(approval.Time.Time) || scheduledTime.After(approval.Time.Add(window)) {
+			continue
+		}
+
+		if !rbacutils.IncludesSubject(subjects, approval.Subject) {
+			subjects = append(subjects, approval.Subject)
+		}
+	}
+
+	return subjects
+}
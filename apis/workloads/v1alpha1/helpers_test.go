@@ -1,8 +1,15 @@
 package v1alpha1
 
 import (
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = Describe("Helpers", func() {
@@ -265,5 +272,459 @@ var _ = Describe("Helpers", func() {
 				Expect(err).To(MatchError(ContainSubstring(".spec.defaultAuthorisationRule must be set if authorisation rules are defined")))
 			})
 		})
+
+		Context("with an exception to an unknown security profile rule", func() {
+			BeforeEach(func() {
+				template.Annotations = map[string]string{
+					SecurityProfileExceptionsAnnotation: "host-namespaces, all-of-them",
+				}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("unknown security profile rule all-of-them")))
+			})
+		})
+	})
+
+	Describe("ConsoleTemplate ApplySecurityProfiles", func() {
+		var (
+			template ConsoleTemplate
+			profile  ConsoleSecurityProfile
+			spec     *corev1.PodSpec
+			err      error
+		)
+
+		BeforeEach(func() {
+			template = ConsoleTemplate{}
+			profile = ConsoleSecurityProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "strict"},
+				Spec: ConsoleSecurityProfileSpec{
+					DisallowedHostNamespaces:      []HostNamespace{HostNetwork, HostPID},
+					DisallowPrivileged:            true,
+					RequireRunAsNonRoot:           true,
+					RequireReadOnlyRootFilesystem: true,
+					RequiredDropCapabilities:      []corev1.Capability{"ALL"},
+					RequiredResourceLimits:        []corev1.ResourceName{corev1.ResourceMemory},
+					ForbiddenVolumeTypes:          []string{"hostPath"},
+				},
+			}
+			spec = &corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "console",
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+						},
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			err = template.ApplySecurityProfiles([]ConsoleSecurityProfile{profile}, spec)
+		})
+
+		It("Hardens whatever the spec leaves unset", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*spec.SecurityContext.RunAsNonRoot).To(BeTrue())
+
+			sc := spec.Containers[0].SecurityContext
+			Expect(*sc.AllowPrivilegeEscalation).To(BeFalse())
+			Expect(*sc.ReadOnlyRootFilesystem).To(BeTrue())
+			Expect(sc.Capabilities.Drop).To(Equal([]corev1.Capability{"ALL"}))
+		})
+
+		Context("With a spec that breaks the profile", func() {
+			BeforeEach(func() {
+				privileged, runAsNonRoot := true, false
+				spec.HostNetwork = true
+				spec.Volumes = []corev1.Volume{
+					{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}},
+				}
+				spec.Containers[0].Resources = corev1.ResourceRequirements{}
+				spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+					Privileged:   &privileged,
+					RunAsNonRoot: &runAsNonRoot,
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"NET_ADMIN"}},
+				}
+			})
+
+			It("Describes each violation", func() {
+				Expect(err).To(HaveOccurred())
+				for _, violation := range []string{
+					"security profile strict: pod shares the host's Network namespace",
+					"volume host is of forbidden type hostPath",
+					"container console is privileged",
+					"container console may run as root",
+					"container console adds capability NET_ADMIN",
+					"container console has no memory limit",
+				} {
+					Expect(err.Error()).To(ContainSubstring(violation))
+				}
+			})
+
+			Context("When the template is exempt from some rules", func() {
+				BeforeEach(func() {
+					template.Annotations = map[string]string{
+						SecurityProfileExceptionsAnnotation: "host-namespaces,volume-types,privileged,run-as-non-root,capabilities",
+					}
+				})
+
+				It("Only describes the other violations", func() {
+					Expect(err).To(MatchError(ContainSubstring("container console has no memory limit")))
+					Expect(err.Error()).NotTo(ContainSubstring("Network namespace"))
+					Expect(err.Error()).NotTo(ContainSubstring("hostPath"))
+				})
+			})
+		})
+	})
+
+	Describe("Console GetCommand", func() {
+		var (
+			// Inputs
+			console  Console
+			template ConsoleTemplate
+
+			// Outputs
+			err     error
+			command []string
+		)
+
+		BeforeEach(func() {
+			console = Console{}
+			template = ConsoleTemplate{}
+			template.Spec.Template.Spec.Containers = []corev1.Container{
+				{Command: []string{"bin/rails"}, Args: []string{"console"}},
+			}
+		})
+
+		JustBeforeEach(func() {
+			command, err = console.GetCommand(&template)
+		})
+
+		Context("without a command or script", func() {
+			It("returns the template default", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(command).To(Equal([]string{"bin/rails", "console"}))
+			})
+		})
+
+		Context("with a command", func() {
+			BeforeEach(func() {
+				console.Spec.Command = []string{"bash"}
+			})
+
+			It("returns the command", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(command).To(Equal([]string{"bash"}))
+			})
+		})
+
+		Context("with a script", func() {
+			BeforeEach(func() {
+				console.Spec.Script = "echo hello"
+			})
+
+			It("runs the script with the default interpreter", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(command).To(Equal([]string{"/bin/sh", "/var/run/theatre/console-script/script"}))
+			})
+
+			Context("and a command", func() {
+				BeforeEach(func() {
+					console.Spec.Command = []string{"bin/rails", "runner"}
+				})
+
+				It("passes the script to the command", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(command).To(Equal([]string{"bin/rails", "runner", "/var/run/theatre/console-script/script"}))
+				})
+
+				It("does not modify the console's command", func() {
+					Expect(console.Spec.Command).To(Equal([]string{"bin/rails", "runner"}))
+				})
+			})
+		})
+	})
+
+	Describe("DetectPodFailure", func() {
+		var pod *corev1.Pod
+
+		BeforeEach(func() {
+			pod = &corev1.Pod{
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name:  "console-container-0",
+							State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+						},
+					},
+				},
+			}
+		})
+
+		It("returns nil for a pod that is starting normally", func() {
+			Expect(DetectPodFailure(pod)).To(BeNil())
+		})
+
+		It("returns nil without a pod", func() {
+			Expect(DetectPodFailure(nil)).To(BeNil())
+		})
+
+		It("detects containers that are failing to start", func() {
+			pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{
+				Reason:  "ImagePullBackOff",
+				Message: `Back-off pulling image "alpine:missing"`,
+			}
+
+			Expect(DetectPodFailure(pod)).To(Equal(&ConsolePodFailure{
+				Reason:  "ImagePullBackOff",
+				Message: `container console-container-0: Back-off pulling image "alpine:missing"`,
+			}))
+		})
+
+		It("detects pods that can't be scheduled", func() {
+			pod.Status.Conditions = []corev1.PodCondition{
+				{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  corev1.PodReasonUnschedulable,
+					Message: "0/3 nodes are available: 3 Insufficient cpu.",
+				},
+			}
+
+			Expect(DetectPodFailure(pod)).To(Equal(&ConsolePodFailure{
+				Reason:  "Unschedulable",
+				Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			}))
+		})
+
+		It("detects pods that have failed", func() {
+			pod.Status.Phase = corev1.PodFailed
+			pod.Status.Reason = "Evicted"
+
+			Expect(DetectPodFailure(pod)).To(Equal(&ConsolePodFailure{Reason: "Evicted"}))
+		})
+	})
+
+	Describe("Console GetGCTime", func() {
+		It("collects failed consoles TTLSecondsAfterFinished after their pod started failing", func() {
+			ttl := int32(60)
+			since := metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+			console := Console{
+				Spec: ConsoleSpec{TTLSecondsAfterFinished: &ttl},
+				Status: ConsoleStatus{
+					Phase:      ConsoleFailed,
+					PodFailure: &ConsolePodFailure{Reason: "ImagePullBackOff", Since: since},
+				},
+			}
+
+			Expect(*console.GetGCTime()).To(Equal(since.Add(time.Minute)))
+		})
+	})
+
+	Describe("ScheduledConsole Validate", func() {
+		It("Accepts a valid schedule", func() {
+			sc := ScheduledConsole{
+				ObjectMeta: metav1.ObjectMeta{Name: "maintenance"},
+				Spec:       ScheduledConsoleSpec{Schedule: "0 2 * * sat"},
+			}
+
+			Expect(sc.Validate()).To(Succeed())
+		})
+
+		It("Rejects schedules that can't be parsed and names that are too long", func() {
+			sc := ScheduledConsole{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", MaxScheduledConsoleNameLength+1)},
+				Spec:       ScheduledConsoleSpec{Schedule: "0 2 * *"},
+			}
+
+			err := sc.Validate()
+			Expect(err).To(MatchError(ContainSubstring(".spec.schedule: expected 5 fields")))
+			Expect(err).To(MatchError(ContainSubstring(".metadata.name: must be no more than 46 characters")))
+		})
+	})
+
+	Describe("ScheduledConsole PreApprovedSubjects", func() {
+		var (
+			sc            *ScheduledConsole
+			auth          *ScheduledConsoleAuthorisation
+			csl           *Console
+			scheduledTime time.Time
+		)
+
+		approver := rbacv1.Subject{Kind: rbacv1.UserKind, Name: "approver@example.com"}
+
+		BeforeEach(func() {
+			// Saturday
+			scheduledTime = time.Date(2020, 1, 4, 2, 0, 0, 0, time.UTC)
+
+			sc = &ScheduledConsole{
+				ObjectMeta: metav1.ObjectMeta{Name: "maintenance", Namespace: "default", Generation: 2},
+				Spec: ScheduledConsoleSpec{
+					User:        "owner@example.com",
+					Schedule:    "0 2 * * sat",
+					PreApproval: &ScheduledConsolePreApproval{WindowSeconds: 86400},
+					ConsoleTemplate: ScheduledConsoleTemplate{
+						ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+						Command:            []string{"bin/vacuum"},
+						Reason:             "Weekly maintenance",
+					},
+				},
+			}
+
+			auth = &ScheduledConsoleAuthorisation{
+				Spec: ScheduledConsoleAuthorisationSpec{
+					Approvals: []ScheduledConsoleApproval{
+						{Subject: approver, Time: metav1.NewTime(scheduledTime.Add(-time.Hour)), Generation: 2},
+					},
+				},
+			}
+
+			csl = sc.BuildConsole(scheduledTime)
+			csl.CreationTimestamp = metav1.NewTime(scheduledTime.Add(time.Second))
+		})
+
+		It("Returns approvals given within the window for the current spec", func() {
+			Expect(sc.PreApprovedSubjects(auth, csl)).To(ConsistOf(approver))
+		})
+
+		It("Ignores approvals given before the window", func() {
+			auth.Spec.Approvals[0].Time = metav1.NewTime(scheduledTime.Add(-25 * time.Hour))
+			Expect(sc.PreApprovedSubjects(auth, csl)).To(BeEmpty())
+		})
+
+		It("Ignores approvals of a previous spec", func() {
+			auth.Spec.Approvals[0].Generation = 1
+			Expect(sc.PreApprovedSubjects(auth, csl)).To(BeEmpty())
+		})
+
+		It("Ignores consoles that differ from the scheduled console", func() {
+			csl.Spec.Command = []string{"bin/drop-tables"}
+			Expect(sc.PreApprovedSubjects(auth, csl)).To(BeEmpty())
+		})
+
+		It("Ignores consoles created before they were due", func() {
+			csl.CreationTimestamp = metav1.NewTime(scheduledTime.Add(-time.Minute))
+			Expect(sc.PreApprovedSubjects(auth, csl)).To(BeEmpty())
+		})
+
+		It("Ignores consoles for times that the schedule isn't due", func() {
+			csl = sc.BuildConsole(scheduledTime.Add(time.Hour))
+			csl.CreationTimestamp = metav1.NewTime(scheduledTime.Add(time.Hour))
+			Expect(sc.PreApprovedSubjects(auth, csl)).To(BeEmpty())
+		})
+
+		It("Ignores approvals when pre-approval is disabled", func() {
+			sc.Spec.PreApproval = nil
+			Expect(sc.PreApprovedSubjects(auth, csl)).To(BeEmpty())
+		})
+	})
+
+	Describe("PriorityPolicy Match", func() {
+		var (
+			// Inputs
+			policy  PriorityPolicy
+			subject PrioritySubject
+
+			// Outputs
+			err  error
+			rule *PriorityRule
+		)
+
+		BeforeEach(func() {
+			policy = PriorityPolicy{
+				Spec: PriorityPolicySpec{
+					Rules: []PriorityRule{
+						{
+							Name:              "consoles",
+							PriorityClassName: "console",
+							OwnerKinds:        []string{"Console"},
+						},
+						{
+							Name:              "batch",
+							PriorityClassName: "best-effort",
+							PodSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"workload": "batch"},
+							},
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"team": "payments"},
+							},
+						},
+						{
+							Name:                "deployers",
+							PriorityClassName:   "critical",
+							ServiceAccountNames: []string{"deployer"},
+						},
+					},
+				},
+			}
+
+			subject = PrioritySubject{
+				Pod: &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"workload": "batch"}},
+				},
+				Namespace: &corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "payments"}},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			rule, err = policy.Match(subject)
+		})
+
+		It("returns the first rule whose conditions all match", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rule.Name).To(Equal("batch"))
+		})
+
+		Context("when the pod is controlled by a matching kind", func() {
+			BeforeEach(func() {
+				subject.OwnerKinds = []string{"Job", "Console"}
+			})
+
+			It("matches on any owner in the chain", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rule.Name).To(Equal("consoles"))
+			})
+		})
+
+		Context("when only some conditions match", func() {
+			BeforeEach(func() {
+				subject.Namespace.Labels = map[string]string{"team": "platform"}
+			})
+
+			It("returns no rule", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rule).To(BeNil())
+			})
+		})
+
+		Context("when the pod runs as a matching service account", func() {
+			BeforeEach(func() {
+				subject.Pod.Labels = nil
+				subject.Pod.Spec.ServiceAccountName = "deployer"
+			})
+
+			It("matches the service account rule", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rule.Name).To(Equal("deployers"))
+			})
+		})
+
+		Context("with an invalid selector", func() {
+			BeforeEach(func() {
+				policy.Spec.Rules[1].PodSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+					{Key: "workload", Operator: "Unknown"},
+				}
+			})
+
+			It("returns an error naming the rule", func() {
+				Expect(err).To(MatchError(ContainSubstring("rule batch: invalid pod selector")))
+			})
+		})
 	})
 })
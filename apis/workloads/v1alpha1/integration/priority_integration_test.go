@@ -12,6 +12,8 @@ import (
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -27,6 +29,8 @@ var _ = Describe("PriorityInjector", func() {
 		cancel          func()
 		namespace       string
 		labelValue      string
+		unlabelled      bool
+		podLabels       map[string]string
 		priorityClasses []*scheduling_v1beta1.PriorityClass
 
 		c client.Client
@@ -40,6 +44,7 @@ var _ = Describe("PriorityInjector", func() {
 		c, err = client.New(testEnv.Config, client.Options{})
 		Expect(err).NotTo(HaveOccurred())
 
+		unlabelled = false
 		namespace = uuid.New().String()
 		ns := &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
@@ -69,6 +74,10 @@ var _ = Describe("PriorityInjector", func() {
 	})
 
 	JustBeforeEach(func() {
+		if unlabelled {
+			return
+		}
+
 		ns := &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: namespace,
@@ -96,6 +105,7 @@ var _ = Describe("PriorityInjector", func() {
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "sample",
 				Namespace: namespace,
+				Labels:    podLabels,
 			},
 			Spec: corev1.PodSpec{
 				PriorityClassName: priorityClassName,
@@ -139,5 +149,145 @@ var _ = Describe("PriorityInjector", func() {
 				Expect(pod.Spec.PriorityClassName).To(Equal("default"))
 			})
 		})
+
+		Context("With a namespace label naming a missing priority class", func() {
+			BeforeEach(func() {
+				labelValue = "best-efort"
+			})
+
+			It("Leaves pod untouched", func() {
+				Expect(pod.Spec.PriorityClassName).To(Equal("default"))
+			})
+
+			It("Records an event on the namespace", func() {
+				Eventually(func() []string {
+					// Events about cluster-scoped objects are recorded in the default namespace
+					events := &corev1.EventList{}
+					Expect(c.List(ctx, events, client.InNamespace(metav1.NamespaceDefault))).To(Succeed())
+
+					reasons := []string{}
+					for _, event := range events.Items {
+						if event.InvolvedObject.Name == namespace {
+							reasons = append(reasons, event.Reason)
+						}
+					}
+
+					return reasons
+				}, timeout).Should(ContainElement(workloadsv1alpha1.EventInvalidPriorityClass))
+			})
+		})
+	})
+
+	Describe("Creating pods that choose their own priority class", func() {
+		var (
+			pod               *corev1.Pod
+			priorityClassName string
+		)
+
+		BeforeEach(func() {
+			labelValue = "default"
+		})
+
+		JustBeforeEach(func() {
+			pod = createPod(priorityClassName)
+		})
+
+		Context("When the class is allowed to be overridden", func() {
+			BeforeEach(func() {
+				priorityClassName = "best-effort"
+			})
+
+			It("Keeps the pod's priority class", func() {
+				Expect(pod.Spec.PriorityClassName).To(Equal("best-effort"))
+			})
+		})
+
+		Context("When the class isn't allowed to be overridden", func() {
+			BeforeEach(func() {
+				labelValue = "best-effort"
+				priorityClassName = "default"
+			})
+
+			It("Replaces the pod's priority class", func() {
+				Expect(pod.Spec.PriorityClassName).To(Equal("best-effort"))
+			})
+		})
+	})
+
+	Describe("Creating pods matched by a priority policy", func() {
+		var (
+			pod    *corev1.Pod
+			policy *workloadsv1alpha1.PriorityPolicy
+		)
+
+		BeforeEach(func() {
+			labelValue = "default"
+			podLabels = map[string]string{"workload": "batch"}
+
+			policy = &workloadsv1alpha1.PriorityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: namespace},
+				Spec: workloadsv1alpha1.PriorityPolicySpec{
+					Rules: []workloadsv1alpha1.PriorityRule{
+						{
+							Name:              "batch",
+							PriorityClassName: "best-effort",
+							PodSelector: &metav1.LabelSelector{
+								MatchLabels: podLabels,
+							},
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"theatre-priority-injector": "default"},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			By("Creating priority policy")
+			Expect(c.Create(ctx, policy)).To(Succeed())
+
+			pod = createPod("")
+		})
+
+		AfterEach(func() {
+			c.Delete(ctx, policy)
+			podLabels = nil
+		})
+
+		It("Sets priority class name from the matching rule", func() {
+			Expect(pod.Spec.PriorityClassName).To(Equal("best-effort"))
+		})
+
+		Context("When the pod doesn't match", func() {
+			BeforeEach(func() {
+				podLabels = map[string]string{"workload": "web"}
+			})
+
+			It("Falls back to the namespace label", func() {
+				Expect(pod.Spec.PriorityClassName).To(Equal("default"))
+			})
+		})
+
+		Context("When the namespace doesn't have the label", func() {
+			BeforeEach(func() {
+				unlabelled = true
+				policy.Spec.Rules[0].NamespaceSelector = nil
+			})
+
+			It("Sets priority class name from the matching rule", func() {
+				Expect(pod.Spec.PriorityClassName).To(Equal("best-effort"))
+			})
+		})
+
+		Context("When the policy is in dry-run mode", func() {
+			BeforeEach(func() {
+				policy.Spec.DryRun = true
+			})
+
+			It("Falls back to the namespace label", func() {
+				Expect(pod.Spec.PriorityClassName).To(Equal("default"))
+			})
+		})
 	})
 })
@@ -19,6 +19,7 @@ import (
 
 	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/webhook"
 )
 
 var (
@@ -73,9 +74,15 @@ var _ = BeforeSuite(func(done Done) {
 	mgr.GetWebhookServer().Register(
 		"/mutate-pods",
 		&admission.Webhook{
-			Handler: workloadsv1alpha1.NewPriorityInjector(
-				mgr.GetClient(),
-				ctrl.Log.WithName("webhooks").WithName("priority-injector"),
+			Handler: webhook.Instrument(
+				"priority-injector",
+				ctrl.Log.WithName("webhooks"),
+				workloadsv1alpha1.NewPriorityInjector(
+					mgr.GetClient(),
+					mgr.GetEventRecorderFor("priority-injector"),
+					ctrl.Log.WithName("webhooks").WithName("priority-injector"),
+					[]string{"best-effort"},
+				),
 			),
 		},
 	)
@@ -0,0 +1,79 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PriorityPolicySpec defines the desired state of PriorityPolicy
+type PriorityPolicySpec struct {
+	// Rules are evaluated in order, and the first rule that matches a pod sets its
+	// priority class.
+	Rules []PriorityRule `json:"rules"`
+
+	// When set, the priority injector only reports the priority class that this
+	// policy would have assigned, in its logs and metrics, and carries on as if the
+	// policy didn't match. This allows new policies to be tested against real
+	// workloads before they take effect.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// PriorityRule assigns a priority class to the pods that it matches. Every condition
+// that is set must match, and a rule without any conditions matches all pods.
+type PriorityRule struct {
+	// Name identifies the rule in logs and metrics
+	Name string `json:"name"`
+
+	// The priority class to assign to matching pods
+	PriorityClassName string `json:"priorityClassName"`
+
+	// Matches the labels of the pod
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// Matches the labels of the namespace that the pod is being created in
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Matches pods that are controlled, directly or indirectly, by an object of any of
+	// these kinds. For example, pods created by a Deployment are controlled by a
+	// ReplicaSet, which is controlled by the Deployment, so either kind will match.
+	// Likewise, console pods can be matched with Job or Console.
+	// +optional
+	OwnerKinds []string `json:"ownerKinds,omitempty"`
+
+	// Matches pods that run as any of these service accounts
+	// +optional
+	ServiceAccountNames []string `json:"serviceAccountNames,omitempty"`
+}
+
+// PriorityPolicyStatus defines the observed state of PriorityPolicy
+type PriorityPolicyStatus struct{}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
+
+// PriorityPolicy is the Schema for the prioritypolicies API. Policies are evaluated
+// in order of name, and the first non-dry-run policy with a matching rule sets the
+// priority class of a pod.
+type PriorityPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PriorityPolicySpec   `json:"spec,omitempty"`
+	Status PriorityPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PriorityPolicyList contains a list of PriorityPolicy
+type PriorityPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PriorityPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PriorityPolicy{}, &PriorityPolicyList{})
+}
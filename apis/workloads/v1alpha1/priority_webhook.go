@@ -2,99 +2,99 @@ package v1alpha1
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/logging"
+	"github.com/gocardless/theatre/v2/pkg/webhook"
 )
 
 const (
 	PriorityInjectorFQDN = "priority-injector.workloads.crd.gocardless.com"
 	NamespaceLabel       = "theatre-priority-injector"
+
+	// maxOwnerDepth bounds how far we follow the controllers of a pod when matching
+	// owner kinds. Pods are rarely more than two controllers away from the object
+	// that a person created, such as Pod -> ReplicaSet -> Deployment.
+	maxOwnerDepth = 5
+
+	// priorityClassCacheTTL is how long we remember that a priority class exists
+	priorityClassCacheTTL = 5 * time.Minute
+
+	// EventInvalidPriorityClass is recorded against namespaces and priority policies
+	// that name a priority class that doesn't exist
+	EventInvalidPriorityClass = "InvalidPriorityClass"
 )
 
 type priorityInjector struct {
-	client  client.Client
-	logger  logr.Logger
-	decoder *admission.Decoder
+	client          client.Client
+	recorder        record.EventRecorder
+	logger          logr.Logger
+	decoder         *admission.Decoder
+	overrideClasses map[string]bool
+	priorityClasses *priorityClassCache
 }
 
-func NewPriorityInjector(c client.Client, logger logr.Logger) *priorityInjector {
+// NewPriorityInjector builds the webhook that assigns priority classes to pods. Pods
+// that set one of the override classes themselves are left alone, while any other
+// priority class they set is replaced.
+func NewPriorityInjector(c client.Client, recorder record.EventRecorder, logger logr.Logger, overrideClasses []string) *priorityInjector {
+	overrides := map[string]bool{}
+	for _, class := range overrideClasses {
+		overrides[class] = true
+	}
+
 	return &priorityInjector{
-		client: c,
-		logger: logger,
+		client:          c,
+		recorder:        recorder,
+		logger:          logger,
+		overrideClasses: overrides,
+		priorityClasses: newPriorityClassCache(c, priorityClassCacheTTL),
 	}
 }
 
 var (
-	podLabels   = []string{"pod_namespace"}
-	handleTotal = promauto.NewCounterVec(
+	policyMatchTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "theatre_workloads_priority_injector_handle_total",
-			Help: "Count of requests handled by the webhook",
+			Name: "theatre_workloads_priority_injector_policy_match_total",
+			Help: "Count of pods matched by priority policy rules, including policies in dry-run mode",
 		},
-		podLabels,
-	)
-	mutateTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "theatre_workloads_priority_injector_mutate_total",
-			Help: "Count of pods mutated by the webhook",
-		},
-		podLabels,
-	)
-	skipTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "theatre_workloads_priority_injector_skip_total",
-			Help: "Count of pods skipped by the webhook",
-		},
-		podLabels,
-	)
-	errorsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "theatre_workloads_priority_injector_errors_total",
-			Help: "Count of not-allowed responses from webhook",
-		},
-		podLabels,
+		[]string{"pod_namespace", "policy", "rule", "priority_class", "dry_run"},
 	)
 )
 
+func init() {
+	// Register custom metrics with the global controller runtime prometheus registry
+	metrics.Registry.MustRegister(policyMatchTotal)
+}
+
 func (i *priorityInjector) InjectDecoder(d *admission.Decoder) error {
 	i.decoder = d
 	return nil
 }
 
-func (i *priorityInjector) Handle(ctx context.Context, req admission.Request) (resp admission.Response) {
-	labels := prometheus.Labels{"pod_namespace": req.Namespace}
+func (i *priorityInjector) Handle(ctx context.Context, req admission.Request) admission.Response {
 	logger := i.logger.WithValues(
 		"component", "PriorityInjector",
 		"uuid", string(req.UID),
 	)
-	logger.Info("starting request", "event", "request.start")
-	defer func(start time.Time) {
-		logger.Info("completed request", "event", "request.end", "duration", time.Since(start).Seconds())
-
-		handleTotal.With(labels).Inc()
-		{ // add 0 to initialise the metrics
-			mutateTotal.With(labels).Add(0)
-			skipTotal.With(labels).Add(0)
-			errorsTotal.With(labels).Add(0)
-		}
-
-		// Catch any Allowed=false responses, as this means we've failed to accept this pod
-		if !resp.Allowed {
-			errorsTotal.With(labels).Inc()
-		}
-	}(time.Now())
 
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -111,25 +111,197 @@ func (i *priorityInjector) Handle(ctx context.Context, req admission.Request) (r
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
-	priorityClassName, ok := ns.ObjectMeta.Labels[NamespaceLabel]
-	if !ok {
-		logger.Info("skipping pod without priority label", "event", "pod.skipped", "msg", "no priority label found")
-		skipTotal.With(labels).Inc()
-		return admission.Allowed("no priority label found")
+	if current := pod.Spec.PriorityClassName; current != "" {
+		if i.overrideClasses[current] {
+			logger.Info("skipping pod that chose its own priority class", "event", "pod.skipped", "class", current)
+			return admission.Allowed("pod is permitted to choose its own priority class")
+		}
+
+		logger.Info("replacing priority class chosen by pod", "event", "pod.replace_priority_class", "class", current)
+	}
+
+	priorityClassName, err := i.choosePriorityClass(ctx, logger, PrioritySubject{Pod: pod, Namespace: ns})
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	mutateTotal.With(labels).Inc() // we are committed to mutating this pod now
+	if priorityClassName == "" {
+		logger.Info("skipping pod without priority class", "event", "pod.skipped", "msg", "no priority class to assign")
+		return admission.Allowed("no priority class to assign")
+	}
 
 	logger.Info(fmt.Sprintf("pod assigned priority class %s", priorityClassName), "event", "pod.assign_priority_class", "class", priorityClassName)
-	copy := pod.DeepCopy()
-	copy.Spec.PriorityClassName = priorityClassName
-	copy.Spec.Priority = nil
+	return priorityClassPatch(pod, priorityClassName).Response("")
+}
+
+// priorityClassPatch sets the pod's priority class. The API server resolves the
+// priority from the class, and rejects pods with a priority that doesn't match it,
+// so we remove any priority that has already been set.
+func priorityClassPatch(pod *corev1.Pod, priorityClassName string) webhook.Patch {
+	patch := webhook.Patch{}
+	patch.Add("/spec/priorityClassName", priorityClassName)
+	if pod.Spec.Priority != nil {
+		patch.Remove("/spec/priority")
+	}
+
+	return patch
+}
+
+// choosePriorityClass returns the priority class for the pod, from the first priority
+// policy that matches it or otherwise the label on its namespace. An empty priority
+// class means the pod should be left alone.
+func (i *priorityInjector) choosePriorityClass(ctx context.Context, logger logr.Logger, subject PrioritySubject) (string, error) {
+	policies := &PriorityPolicyList{}
+	if err := i.client.List(ctx, policies); err != nil {
+		return "", err
+	}
+
+	sort.Slice(policies.Items, func(a, b int) bool {
+		return policies.Items[a].Name < policies.Items[b].Name
+	})
+
+	for idx := range policies.Items {
+		policy := &policies.Items[idx]
 
-	// TODO(jackatbancast): convert using JSON patch operations
-	marshaledPod, err := json.Marshal(copy)
+		if policy.MatchesOwnerKinds() && subject.OwnerKinds == nil {
+			subject.OwnerKinds = i.ownerKinds(ctx, logger, subject.Namespace.Name, subject.Pod)
+		}
+
+		rule, err := policy.Match(subject)
+		if err != nil {
+			// A broken policy shouldn't stop pods from being created, so we skip it
+			logger.Info("skipping invalid priority policy", "event", "policy.invalid", "policy", policy.Name, "error", err)
+			continue
+		}
+
+		if rule == nil {
+			continue
+		}
+
+		policyMatchTotal.With(prometheus.Labels{
+			"pod_namespace":  subject.Namespace.Name,
+			"policy":         policy.Name,
+			"rule":           rule.Name,
+			"priority_class": rule.PriorityClassName,
+			"dry_run":        fmt.Sprintf("%t", policy.Spec.DryRun),
+		}).Inc()
+
+		if policy.Spec.DryRun {
+			logger.Info(
+				fmt.Sprintf("dry-run policy would assign priority class %s", rule.PriorityClassName),
+				"event", "pod.dry_run_priority_class", "policy", policy.Name, "rule", rule.Name, "class", rule.PriorityClassName,
+			)
+			continue
+		}
+
+		exists, err := i.priorityClasses.Exists(ctx, rule.PriorityClassName)
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			logging.WithEventRecorder(logger, i.recorder, policy).Info(
+				"skipping priority policy rule with missing priority class",
+				"event", EventInvalidPriorityClass,
+				"error", fmt.Sprintf("priority class %s in rule %s does not exist", rule.PriorityClassName, rule.Name),
+			)
+			continue
+		}
+
+		logger.Info("pod matched priority policy", "event", "pod.match_policy", "policy", policy.Name, "rule", rule.Name)
+		return rule.PriorityClassName, nil
+	}
+
+	priorityClassName := subject.Namespace.Labels[NamespaceLabel]
+	if priorityClassName == "" {
+		return "", nil
+	}
+
+	exists, err := i.priorityClasses.Exists(ctx, priorityClassName)
 	if err != nil {
-		return admission.Errored(http.StatusInternalServerError, err)
+		return "", err
+	}
+
+	// Assigning a missing priority class would stop the pod from being created, so
+	// we leave the pod alone and let the namespace owners know about the problem
+	if !exists {
+		logging.WithEventRecorder(logger, i.recorder, subject.Namespace).Info(
+			"skipping namespace label with missing priority class",
+			"event", EventInvalidPriorityClass,
+			"error", fmt.Sprintf("priority class %s in label %s does not exist", priorityClassName, NamespaceLabel),
+		)
+		return "", nil
+	}
+
+	return priorityClassName, nil
+}
+
+// ownerKinds follows the controllers of the pod, returning the kind of each. We stop
+// at the first owner we can't fetch, as we might not be permitted to read it, and
+// the kinds we've found so far are still useful.
+func (i *priorityInjector) ownerKinds(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod) []string {
+	kinds := []string{}
+
+	var obj metav1.Object = pod
+	for len(kinds) < maxOwnerDepth {
+		ref := metav1.GetControllerOf(obj)
+		if ref == nil {
+			break
+		}
+
+		kinds = append(kinds, ref.Kind)
+
+		owner := &unstructured.Unstructured{}
+		owner.SetAPIVersion(ref.APIVersion)
+		owner.SetKind(ref.Kind)
+		if err := i.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, owner); err != nil {
+			logger.Info("failed to get pod owner", "event", "pod.owner_error", "kind", ref.Kind, "name", ref.Name, "error", err)
+			break
+		}
+
+		obj = owner
+	}
+
+	return kinds
+}
+
+// priorityClassCache remembers which priority classes exist, so we don't fetch them
+// for every pod. Missing classes aren't remembered, so that fixing a misconfigured
+// label or policy takes effect immediately.
+type priorityClassCache struct {
+	client client.Client
+	ttl    time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newPriorityClassCache(c client.Client, ttl time.Duration) *priorityClassCache {
+	return &priorityClassCache{client: c, ttl: ttl, seen: map[string]time.Time{}}
+}
+
+// Exists returns whether the priority class exists
+func (c *priorityClassCache) Exists(ctx context.Context, name string) (bool, error) {
+	c.mu.Lock()
+	seenAt, ok := c.seen[name]
+	c.mu.Unlock()
+
+	if ok && time.Since(seenAt) < c.ttl {
+		return true, nil
 	}
 
-	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
+	class := &schedulingv1.PriorityClass{}
+	if err := c.client.Get(ctx, client.ObjectKey{Name: name}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.seen[name] = time.Now()
+	c.mu.Unlock()
+
+	return true, nil
 }
@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook/webhooktest"
+)
+
+// newTestDecoder returns a decoder for webhooks that know about our types
+func newTestDecoder() (*runtime.Scheme, *admission.Decoder) {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(AddToScheme(scheme)).To(Succeed())
+
+	decoder, err := admission.NewDecoder(scheme)
+	Expect(err).NotTo(HaveOccurred())
+
+	return scheme, decoder
+}
+
+// newCreateRequest returns an admission request from the user to create the object
+func newCreateRequest(namespace, username string, obj runtime.Object) admission.Request {
+	raw, err := json.Marshal(obj)
+	Expect(err).NotTo(HaveOccurred())
+
+	return admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			UID:       "test",
+			Namespace: namespace,
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+			UserInfo:  authenticationv1.UserInfo{Username: username},
+		},
+	}
+}
+
+var _ = Describe("PriorityInjector", func() {
+	var (
+		pod  *corev1.Pod
+		resp admission.Response
+	)
+
+	BeforeEach(func() {
+		priority := int32(100)
+		pod = &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "payments"},
+			Spec: corev1.PodSpec{
+				PriorityClassName: "default",
+				Priority:          &priority,
+				Containers:        []corev1.Container{{Name: "app", Image: "app:latest"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme, decoder := newTestDecoder()
+		c := fake.NewFakeClientWithScheme(
+			scheme,
+			&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "payments",
+					Labels: map[string]string{NamespaceLabel: "best-effort"},
+				},
+			},
+			&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "best-effort"}},
+		)
+
+		injector := NewPriorityInjector(c, record.NewFakeRecorder(10), zap.LoggerTo(GinkgoWriter, true), nil)
+		Expect(injector.InjectDecoder(decoder)).To(Succeed())
+
+		resp = injector.Handle(context.Background(), newCreateRequest("payments", "", pod))
+	})
+
+	It("Patches only the priority fields", func() {
+		Expect(resp.Allowed).To(BeTrue())
+		webhooktest.ExpectGoldenPatch("testdata/priority_injector_replace.patch.json", resp.Patches)
+	})
+})
@@ -0,0 +1,61 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScheduledConsoleAuthorisationSpec defines the desired state of ScheduledConsoleAuthorisation
+type ScheduledConsoleAuthorisationSpec struct {
+	// The reference to the scheduled console by name that this authorisation
+	// belongs to.
+	ScheduledConsoleRef corev1.LocalObjectReference `json:"scheduledConsoleRef"`
+
+	// List of approvals that have been given to the referenced scheduled console
+	// ahead of time.
+	Approvals []ScheduledConsoleApproval `json:"approvals"`
+}
+
+// ScheduledConsoleApproval records an authoriser approving the consoles that a
+// scheduled console creates
+type ScheduledConsoleApproval struct {
+	Subject rbacv1.Subject `json:"subject"`
+
+	// Time at which the approval was given. This is set by an admission webhook.
+	// +optional
+	Time metav1.Time `json:"time,omitempty"`
+
+	// The generation of the scheduled console that was approved. This is set by
+	// an admission webhook.
+	// +optional
+	Generation int64 `json:"generation,omitempty"`
+}
+
+// ScheduledConsoleAuthorisationStatus defines the observed state of ScheduledConsoleAuthorisation
+type ScheduledConsoleAuthorisationStatus struct{}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+
+// ScheduledConsoleAuthorisation is the Schema for the scheduledconsoleauthorisations API
+type ScheduledConsoleAuthorisation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScheduledConsoleAuthorisationSpec   `json:"spec,omitempty"`
+	Status ScheduledConsoleAuthorisationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ScheduledConsoleAuthorisationList contains a list of ScheduledConsoleAuthorisation
+type ScheduledConsoleAuthorisationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScheduledConsoleAuthorisation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScheduledConsoleAuthorisation{}, &ScheduledConsoleAuthorisationList{})
+}
@@ -0,0 +1,148 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook"
+)
+
+// ScheduledConsoleAuthorisationWebhook ensures that authorisers can only approve a
+// scheduled console as themselves, and records when they did and what they approved.
+//
+// +kubebuilder:object:generate=false
+type ScheduledConsoleAuthorisationWebhook struct {
+	client  client.Client
+	logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+func NewScheduledConsoleAuthorisationWebhook(c client.Client, logger logr.Logger) *ScheduledConsoleAuthorisationWebhook {
+	return &ScheduledConsoleAuthorisationWebhook{
+		client: c,
+		logger: logger,
+	}
+}
+
+func (c *ScheduledConsoleAuthorisationWebhook) InjectDecoder(d *admission.Decoder) error {
+	c.decoder = d
+	return nil
+}
+
+func (c *ScheduledConsoleAuthorisationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := c.logger.WithValues("uuid", string(req.UID))
+
+	updatedAuth := &ScheduledConsoleAuthorisation{}
+	if err := c.decoder.DecodeRaw(req.Object, updatedAuth); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	existingAuth := &ScheduledConsoleAuthorisation{}
+	if err := c.decoder.DecodeRaw(req.OldObject, existingAuth); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	sc := &ScheduledConsole{}
+	err := c.client.Get(ctx, client.ObjectKey{Namespace: existingAuth.Namespace, Name: existingAuth.Spec.ScheduledConsoleRef.Name}, sc)
+	if err != nil {
+		return admission.ValidationResponse(false, fmt.Sprintf("failed to retrieve scheduled console for the authorisation: %v", err))
+	}
+
+	update := &ScheduledConsoleAuthorisationUpdate{
+		existingAuth: existingAuth,
+		updatedAuth:  updatedAuth,
+		user:         req.UserInfo.Username,
+		owner:        sc.Spec.User,
+	}
+
+	if err := update.Validate(); err != nil {
+		logger.Info("approval failed", "event", "approval.failure", "error", err)
+		return admission.ValidationResponse(false, fmt.Sprintf("the scheduled console authorisation spec is invalid: %v", err))
+	}
+
+	// Approve the scheduled console as it is now, so that changing it afterwards
+	// requires approving it again
+	update.Attribute(metav1.Now(), sc.Generation)
+
+	logger.Info("approval successful", "event", "approval.success", "user", update.user)
+	return update.Patch().Response("")
+}
+
+type ScheduledConsoleAuthorisationUpdate struct {
+	existingAuth *ScheduledConsoleAuthorisation
+	updatedAuth  *ScheduledConsoleAuthorisation
+	user         string
+	owner        string
+}
+
+// added returns the approvals appended by this update
+func (u *ScheduledConsoleAuthorisationUpdate) added() []ScheduledConsoleApproval {
+	existing, updated := u.existingAuth.Spec.Approvals, u.updatedAuth.Spec.Approvals
+	if len(updated) <= len(existing) {
+		return nil
+	}
+
+	return updated[len(existing):]
+}
+
+// Attribute sets the time and generation of any approvals appended by this update
+func (u *ScheduledConsoleAuthorisationUpdate) Attribute(now metav1.Time, generation int64) {
+	added := u.added()
+	for idx := range added {
+		added[idx].Time = now
+		added[idx].Generation = generation
+	}
+}
+
+// Patch returns the changes made by Attribute, for the webhook to respond with
+func (u *ScheduledConsoleAuthorisationUpdate) Patch() webhook.Patch {
+	patch := webhook.Patch{}
+	for idx, approval := range u.added() {
+		path := webhook.Path("spec", "approvals", webhook.Index(len(u.existingAuth.Spec.Approvals)+idx))
+		patch.Add(path+"/time", approval.Time)
+		patch.Add(path+"/generation", approval.Generation)
+	}
+
+	return patch
+}
+
+func (u *ScheduledConsoleAuthorisationUpdate) Validate() error {
+	var err error
+
+	// check immutable fields haven't been updated
+	if !reflect.DeepEqual(u.updatedAuth.Spec.ScheduledConsoleRef, u.existingAuth.Spec.ScheduledConsoleRef) {
+		err = multierror.Append(err, errors.New("the spec.scheduledConsoleRef field is immutable"))
+	}
+
+	// Updates that don't touch the approvals, such as to labels, are fine
+	existing, updated := u.existingAuth.Spec.Approvals, u.updatedAuth.Spec.Approvals
+	if len(updated) == len(existing) && (len(existing) == 0 || reflect.DeepEqual(updated, existing)) {
+		return err
+	}
+
+	// check no existing approvals have been modified and that a single approval has been added
+	if len(updated) != len(existing)+1 || !reflect.DeepEqual(updated[:len(existing)], existing) {
+		return multierror.Append(err, errors.New("the spec.approvals field can only be appended to (with one approval) per update"))
+	}
+
+	subject := updated[len(existing)].Subject
+	if subject.Kind != rbacv1.UserKind || subject.Name != u.user {
+		err = multierror.Append(err, errors.New("only the current user can be added as an approver"))
+	}
+
+	if subject.Name == u.owner {
+		err = multierror.Append(err, errors.New("an approver cannot approve their own scheduled console"))
+	}
+
+	return err
+}
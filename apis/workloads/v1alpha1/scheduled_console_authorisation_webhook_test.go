@@ -0,0 +1,183 @@
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook/webhooktest"
+)
+
+var _ = Describe("Scheduled console authorisation webhook", func() {
+	Describe("Validate", func() {
+		var (
+			existingAuth *ScheduledConsoleAuthorisation
+			updatedAuth  *ScheduledConsoleAuthorisation
+			approval     ScheduledConsoleApproval
+			user         string
+			err          error
+		)
+
+		recorded := ScheduledConsoleApproval{
+			Subject:    rbacv1.Subject{Kind: rbacv1.UserKind, Name: "someone-else"},
+			Time:       metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+			Generation: 1,
+		}
+
+		BeforeEach(func() {
+			existingAuth = &ScheduledConsoleAuthorisation{
+				Spec: ScheduledConsoleAuthorisationSpec{
+					ScheduledConsoleRef: corev1.LocalObjectReference{Name: "maintenance"},
+					Approvals:           []ScheduledConsoleApproval{recorded},
+				},
+			}
+
+			user = "current-user"
+			approval = ScheduledConsoleApproval{
+				Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "current-user"},
+			}
+		})
+
+		JustBeforeEach(func() {
+			if updatedAuth == nil {
+				updatedAuth = existingAuth.DeepCopy()
+				updatedAuth.Spec.Approvals = append(updatedAuth.Spec.Approvals, approval)
+			}
+
+			update := &ScheduledConsoleAuthorisationUpdate{
+				existingAuth: existingAuth,
+				updatedAuth:  updatedAuth,
+				user:         user,
+				owner:        "owner",
+			}
+
+			update.Attribute(metav1.Now(), 2)
+			err = update.Validate()
+		})
+
+		AfterEach(func() {
+			updatedAuth = nil
+		})
+
+		Context("Appending an approval as the current user", func() {
+			It("Returns no errors", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Records when the approval was given and what it approved", func() {
+				Expect(updatedAuth.Spec.Approvals[1].Time.IsZero()).To(BeFalse())
+				Expect(updatedAuth.Spec.Approvals[1].Generation).To(Equal(int64(2)))
+			})
+
+			It("Leaves existing approvals untouched", func() {
+				Expect(updatedAuth.Spec.Approvals[0]).To(Equal(recorded))
+			})
+		})
+
+		Context("Updating without changing the approvals", func() {
+			BeforeEach(func() {
+				updatedAuth = existingAuth.DeepCopy()
+				updatedAuth.Labels = map[string]string{"new": "label"}
+			})
+
+			It("Returns no errors", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("Changing the scheduled console reference", func() {
+			BeforeEach(func() {
+				updatedAuth = existingAuth.DeepCopy()
+				updatedAuth.Spec.ScheduledConsoleRef.Name = "another"
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("spec.scheduledConsoleRef field is immutable")))
+			})
+		})
+
+		Context("Removing an approval", func() {
+			BeforeEach(func() {
+				updatedAuth = existingAuth.DeepCopy()
+				updatedAuth.Spec.Approvals = []ScheduledConsoleApproval{approval}
+				existingAuth.Spec.Approvals = append(existingAuth.Spec.Approvals, recorded)
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("can only be appended to")))
+			})
+		})
+
+		Context("Appending multiple approvals", func() {
+			BeforeEach(func() {
+				updatedAuth = existingAuth.DeepCopy()
+				updatedAuth.Spec.Approvals = append(updatedAuth.Spec.Approvals, approval, approval)
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("can only be appended to")))
+			})
+		})
+
+		Context("Approving as another user", func() {
+			BeforeEach(func() {
+				approval.Subject.Name = "another-user"
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("only the current user can be added")))
+			})
+		})
+
+		Context("Approving as a group", func() {
+			BeforeEach(func() {
+				approval.Subject = rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "current-user"}
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("only the current user can be added")))
+			})
+		})
+
+		Context("Approving as the owner of the scheduled console", func() {
+			BeforeEach(func() {
+				user = "owner"
+				approval.Subject.Name = "owner"
+			})
+
+			It("Returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("cannot approve their own scheduled console")))
+			})
+		})
+	})
+
+	Describe("Patch", func() {
+		It("Only sets the time and generation of appended approvals", func() {
+			existingAuth := &ScheduledConsoleAuthorisation{
+				Spec: ScheduledConsoleAuthorisationSpec{
+					Approvals: []ScheduledConsoleApproval{
+						{Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "someone-else"}},
+					},
+				},
+			}
+
+			updatedAuth := existingAuth.DeepCopy()
+			updatedAuth.Spec.Approvals = append(updatedAuth.Spec.Approvals, ScheduledConsoleApproval{
+				Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "current-user"},
+			})
+
+			update := &ScheduledConsoleAuthorisationUpdate{
+				existingAuth: existingAuth,
+				updatedAuth:  updatedAuth,
+				user:         "current-user",
+			}
+			update.Attribute(metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)), 3)
+
+			webhooktest.ExpectGoldenPatch("testdata/scheduled_console_authorisation_approve.patch.json", update.Patch())
+		})
+	})
+})
@@ -0,0 +1,163 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConcurrencyPolicy describes what happens when a scheduled console is due while the
+// console it previously created is still running
+// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+type ConcurrencyPolicy string
+
+const (
+	// AllowConcurrent creates the console regardless
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+	// ForbidConcurrent skips the console that is due
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// ReplaceConcurrent deletes the running console, then creates the new one
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
+// ScheduledConsoleSpec defines the desired state of ScheduledConsole
+type ScheduledConsoleSpec struct {
+	// The user that consoles are created for, as if they had created them
+	// themselves. This is set by an admission webhook to whoever last changed
+	// the scheduled console.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// The groups of the user, which are recorded along with it so that the
+	// user's permission to create consoles can be checked as it would be had
+	// they created the console themselves.
+	// +optional
+	UserGroups []string `json:"userGroups,omitempty"`
+
+	// The schedule in cron format, such as "0 2 * * sat", interpreted in UTC
+	Schedule string `json:"schedule"`
+
+	// Number of seconds after a console was due within which it may still be
+	// created, such as when the workloads manager was unavailable at the time.
+	// Consoles that are missed by more than this are skipped. If not set, a
+	// missed console is created however late it is.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// What to do when a console is due while the previous one is still running.
+	// Defaults to Forbid, which skips the console that is due.
+	// +optional
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// Stops any further consoles from being created, without affecting those
+	// that already exist
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// The console to create each time the schedule is due
+	ConsoleTemplate ScheduledConsoleTemplate `json:"consoleTemplate"`
+
+	// Number of consoles that completed successfully to keep. Defaults to 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	SuccessfulConsolesHistoryLimit *int32 `json:"successfulConsolesHistoryLimit,omitempty"`
+
+	// Number of consoles that failed, or were never authorised, to keep.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	FailedConsolesHistoryLimit *int32 `json:"failedConsolesHistoryLimit,omitempty"`
+
+	// Allows authorisers to approve the scheduled console ahead of time, when
+	// the console template requires its command to be authorised. If not set,
+	// each console that is created must be authorised as it would be otherwise.
+	// +optional
+	PreApproval *ScheduledConsolePreApproval `json:"preApproval,omitempty"`
+}
+
+// ScheduledConsoleTemplate describes the consoles that a ScheduledConsole creates
+type ScheduledConsoleTemplate struct {
+	// Labels given to each console
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	ConsoleTemplateRef corev1.LocalObjectReference `json:"consoleTemplateRef"`
+
+	// The command and arguments to execute. If not specified the command from
+	// the template specification will be used.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	Reason string `json:"reason"`
+
+	// Number of seconds that each console should run for, subject to the
+	// maximum of the console template
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=604800
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// Disable TTY and STDIN on the underlying container, as nobody is attached
+	// when the console starts
+	// +optional
+	Noninteractive bool `json:"noninteractive,omitempty"`
+}
+
+// ScheduledConsolePreApproval configures approving a ScheduledConsole ahead of time
+type ScheduledConsolePreApproval struct {
+	// Number of seconds after it is given that an approval applies to the
+	// consoles that are due. Approvals only apply to the scheduled console as it
+	// was when they were given, so changing it requires approving it again.
+	// +kubebuilder:validation:Minimum=60
+	// +kubebuilder:validation:Maximum=2678400
+	WindowSeconds int32 `json:"windowSeconds"`
+}
+
+// ScheduledConsoleStatus defines the observed state of ScheduledConsole
+type ScheduledConsoleStatus struct {
+	// Consoles created by this scheduled console that have yet to finish
+	// +optional
+	Active []corev1.LocalObjectReference `json:"active,omitempty"`
+
+	// Time at which a console was last due, whether or not it was created
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Name of the role and directory role binding that permit authorisers to
+	// approve the scheduled console. It's generated rather than derived from the
+	// scheduled console's name, which a console could otherwise share.
+	// +optional
+	AuthorisationRoleName string `json:"authorisationRoleName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+
+// ScheduledConsole creates consoles on a cron schedule, on behalf of the user that
+// created it. The consoles are authorised as any other console would be, unless
+// the scheduled console has been approved ahead of time.
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule"
+// +kubebuilder:printcolumn:name="Suspend",type="boolean",JSONPath=".spec.suspend"
+// +kubebuilder:printcolumn:name="Last Schedule",type="date",JSONPath=".status.lastScheduleTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ScheduledConsole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScheduledConsoleSpec   `json:"spec,omitempty"`
+	Status ScheduledConsoleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ScheduledConsoleList contains a list of ScheduledConsole
+type ScheduledConsoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScheduledConsole `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScheduledConsole{}, &ScheduledConsoleList{})
+}
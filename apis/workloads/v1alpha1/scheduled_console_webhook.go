@@ -0,0 +1,76 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gocardless/theatre/v2/pkg/webhook"
+)
+
+// ScheduledConsoleWebhook records the user that created or last changed a scheduled
+// console, along with their groups, as the consoles it creates are created on their
+// behalf, and rejects schedules that can't be parsed.
+//
+// +kubebuilder:object:generate=false
+type ScheduledConsoleWebhook struct {
+	logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+func NewScheduledConsoleWebhook(logger logr.Logger) *ScheduledConsoleWebhook {
+	return &ScheduledConsoleWebhook{
+		logger: logger,
+	}
+}
+
+func (c *ScheduledConsoleWebhook) InjectDecoder(d *admission.Decoder) error {
+	c.decoder = d
+	return nil
+}
+
+func (c *ScheduledConsoleWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := c.logger.WithValues("uuid", string(req.UID))
+
+	sc := &ScheduledConsole{}
+	if err := c.decoder.DecodeRaw(req.Object, sc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := sc.Validate(); err != nil {
+		logger.Info("scheduled console rejected", "event", "scheduled-console.failure", "error", err)
+		return admission.Denied(fmt.Sprintf("the scheduled console is invalid: %v", err))
+	}
+
+	// Updates that leave the spec alone, such as to labels, don't change who the
+	// consoles are created for
+	if req.Operation == admissionv1beta1.Update {
+		existing := &ScheduledConsole{}
+		if err := c.decoder.DecodeRaw(req.OldObject, existing); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if reflect.DeepEqual(existing.Spec, sc.Spec) {
+			return admission.Allowed("spec unchanged")
+		}
+	}
+
+	user := req.UserInfo.Username
+	groups := req.UserInfo.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+
+	patch := webhook.Patch{}
+	patch.Add("/spec/user", user)
+	patch.Add("/spec/userGroups", groups)
+
+	logger.Info(fmt.Sprintf("scheduled console will create consoles for user %s", user), "event", "scheduled-console.success", "user", user)
+
+	return patch.Response("")
+}
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("ScheduledConsoleWebhook", func() {
+	var (
+		sc   *ScheduledConsole
+		req  admission.Request
+		resp admission.Response
+	)
+
+	BeforeEach(func() {
+		sc = &ScheduledConsole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "ScheduledConsole"},
+			ObjectMeta: metav1.ObjectMeta{Name: "maintenance", Namespace: "default"},
+			Spec: ScheduledConsoleSpec{
+				User:       "forged-user",
+				UserGroups: []string{"forged-group"},
+				Schedule:   "0 2 * * *",
+				ConsoleTemplate: ScheduledConsoleTemplate{
+					ConsoleTemplateRef: corev1.LocalObjectReference{Name: "template"},
+				},
+			},
+		}
+
+		req = newCreateRequest("default", "alice@example.com", sc)
+		req.UserInfo.Groups = []string{"engineers", "system:authenticated"}
+	})
+
+	JustBeforeEach(func() {
+		_, decoder := newTestDecoder()
+
+		wh := NewScheduledConsoleWebhook(zap.LoggerTo(GinkgoWriter, true))
+		Expect(wh.InjectDecoder(decoder)).To(Succeed())
+
+		resp = wh.Handle(context.Background(), req)
+	})
+
+	patched := func(path string) interface{} {
+		for _, op := range resp.Patches {
+			if op.Path == path {
+				return op.Value
+			}
+		}
+
+		return nil
+	}
+
+	It("Records the user and their groups", func() {
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(patched("/spec/user")).To(Equal("alice@example.com"))
+		Expect(patched("/spec/userGroups")).To(Equal([]string{"engineers", "system:authenticated"}))
+	})
+})
@@ -5,7 +5,9 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -36,6 +38,125 @@ func (in *Console) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleActivity) DeepCopyInto(out *ConsoleActivity) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleActivity.
+func (in *ConsoleActivity) DeepCopy() *ConsoleActivity {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleActivity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleActivity) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleActivityList) DeepCopyInto(out *ConsoleActivityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsoleActivity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleActivityList.
+func (in *ConsoleActivityList) DeepCopy() *ConsoleActivityList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleActivityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleActivityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleActivitySpec) DeepCopyInto(out *ConsoleActivitySpec) {
+	*out = *in
+	out.ConsoleRef = in.ConsoleRef
+	if in.LastActivityTime != nil {
+		in, out := &in.LastActivityTime, &out.LastActivityTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleActivitySpec.
+func (in *ConsoleActivitySpec) DeepCopy() *ConsoleActivitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleActivitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleActivityStatus) DeepCopyInto(out *ConsoleActivityStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleActivityStatus.
+func (in *ConsoleActivityStatus) DeepCopy() *ConsoleActivityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleActivityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleActivityUpdate) DeepCopyInto(out *ConsoleActivityUpdate) {
+	*out = *in
+	if in.existingActivity != nil {
+		in, out := &in.existingActivity, &out.existingActivity
+		*out = new(ConsoleActivity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.updatedActivity != nil {
+		in, out := &in.updatedActivity, &out.updatedActivity
+		*out = new(ConsoleActivity)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleActivityUpdate.
+func (in *ConsoleActivityUpdate) DeepCopy() *ConsoleActivityUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleActivityUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsoleAuthorisation) DeepCopyInto(out *ConsoleAuthorisation) {
 	*out = *in
@@ -197,6 +318,68 @@ func (in *ConsoleAuthorisers) DeepCopy() *ConsoleAuthorisers {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleFileTransfer) DeepCopyInto(out *ConsoleFileTransfer) {
+	*out = *in
+	if in.Content != nil {
+		in, out := &in.Content, &out.Content
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleFileTransfer.
+func (in *ConsoleFileTransfer) DeepCopy() *ConsoleFileTransfer {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleFileTransfer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleFileTransferPolicy) DeepCopyInto(out *ConsoleFileTransferPolicy) {
+	*out = *in
+	if in.MaxSizeBytes != nil {
+		in, out := &in.MaxSizeBytes, &out.MaxSizeBytes
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleFileTransferPolicy.
+func (in *ConsoleFileTransferPolicy) DeepCopy() *ConsoleFileTransferPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleFileTransferPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleFileTransferResult) DeepCopyInto(out *ConsoleFileTransferResult) {
+	*out = *in
+	if in.Content != nil {
+		in, out := &in.Content, &out.Content
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleFileTransferResult.
+func (in *ConsoleFileTransferResult) DeepCopy() *ConsoleFileTransferResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleFileTransferResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsoleList) DeepCopyInto(out *ConsoleList) {
 	*out = *in
@@ -230,61 +413,54 @@ func (in *ConsoleList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConsoleSpec) DeepCopyInto(out *ConsoleSpec) {
+func (in *ConsoleParticipant) DeepCopyInto(out *ConsoleParticipant) {
 	*out = *in
-	out.ConsoleTemplateRef = in.ConsoleTemplateRef
-	if in.TTLSecondsBeforeRunning != nil {
-		in, out := &in.TTLSecondsBeforeRunning, &out.TTLSecondsBeforeRunning
-		*out = new(int32)
-		**out = **in
-	}
-	if in.TTLSecondsAfterFinished != nil {
-		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
-		*out = new(int32)
-		**out = **in
-	}
-	if in.Command != nil {
-		in, out := &in.Command, &out.Command
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSpec.
-func (in *ConsoleSpec) DeepCopy() *ConsoleSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleParticipant.
+func (in *ConsoleParticipant) DeepCopy() *ConsoleParticipant {
 	if in == nil {
 		return nil
 	}
-	out := new(ConsoleSpec)
+	out := new(ConsoleParticipant)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConsoleStatus) DeepCopyInto(out *ConsoleStatus) {
+func (in *ConsoleParticipantStatus) DeepCopyInto(out *ConsoleParticipantStatus) {
 	*out = *in
-	if in.ExpiryTime != nil {
-		in, out := &in.ExpiryTime, &out.ExpiryTime
-		*out = (*in).DeepCopy()
-	}
-	if in.CompletionTime != nil {
-		in, out := &in.CompletionTime, &out.CompletionTime
-		*out = (*in).DeepCopy()
+	in.JoinedTime.DeepCopyInto(&out.JoinedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleParticipantStatus.
+func (in *ConsoleParticipantStatus) DeepCopy() *ConsoleParticipantStatus {
+	if in == nil {
+		return nil
 	}
+	out := new(ConsoleParticipantStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleStatus.
-func (in *ConsoleStatus) DeepCopy() *ConsoleStatus {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsolePodFailure) DeepCopyInto(out *ConsolePodFailure) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsolePodFailure.
+func (in *ConsolePodFailure) DeepCopy() *ConsolePodFailure {
 	if in == nil {
 		return nil
 	}
-	out := new(ConsoleStatus)
+	out := new(ConsolePodFailure)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConsoleTemplate) DeepCopyInto(out *ConsoleTemplate) {
+func (in *ConsoleSecurityProfile) DeepCopyInto(out *ConsoleSecurityProfile) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -292,18 +468,18 @@ func (in *ConsoleTemplate) DeepCopyInto(out *ConsoleTemplate) {
 	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplate.
-func (in *ConsoleTemplate) DeepCopy() *ConsoleTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSecurityProfile.
+func (in *ConsoleSecurityProfile) DeepCopy() *ConsoleSecurityProfile {
 	if in == nil {
 		return nil
 	}
-	out := new(ConsoleTemplate)
+	out := new(ConsoleSecurityProfile)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ConsoleTemplate) DeepCopyObject() runtime.Object {
+func (in *ConsoleSecurityProfile) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -311,31 +487,31 @@ func (in *ConsoleTemplate) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConsoleTemplateList) DeepCopyInto(out *ConsoleTemplateList) {
+func (in *ConsoleSecurityProfileList) DeepCopyInto(out *ConsoleSecurityProfileList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ConsoleTemplate, len(*in))
+		*out = make([]ConsoleSecurityProfile, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplateList.
-func (in *ConsoleTemplateList) DeepCopy() *ConsoleTemplateList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSecurityProfileList.
+func (in *ConsoleSecurityProfileList) DeepCopy() *ConsoleSecurityProfileList {
 	if in == nil {
 		return nil
 	}
-	out := new(ConsoleTemplateList)
+	out := new(ConsoleSecurityProfileList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ConsoleTemplateList) DeepCopyObject() runtime.Object {
+func (in *ConsoleSecurityProfileList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -343,76 +519,976 @@ func (in *ConsoleTemplateList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConsoleTemplateSpec) DeepCopyInto(out *ConsoleTemplateSpec) {
+func (in *ConsoleSecurityProfileSpec) DeepCopyInto(out *ConsoleSecurityProfileSpec) {
 	*out = *in
-	in.Template.DeepCopyInto(&out.Template)
-	if in.AdditionalAttachSubjects != nil {
-		in, out := &in.AdditionalAttachSubjects, &out.AdditionalAttachSubjects
-		*out = make([]v1.Subject, len(*in))
+	if in.DisallowedHostNamespaces != nil {
+		in, out := &in.DisallowedHostNamespaces, &out.DisallowedHostNamespaces
+		*out = make([]HostNamespace, len(*in))
 		copy(*out, *in)
 	}
-	if in.DefaultTTLSecondsBeforeRunning != nil {
-		in, out := &in.DefaultTTLSecondsBeforeRunning, &out.DefaultTTLSecondsBeforeRunning
-		*out = new(int32)
-		**out = **in
-	}
-	if in.DefaultTTLSecondsAfterFinished != nil {
-		in, out := &in.DefaultTTLSecondsAfterFinished, &out.DefaultTTLSecondsAfterFinished
-		*out = new(int32)
-		**out = **in
+	if in.RequiredDropCapabilities != nil {
+		in, out := &in.RequiredDropCapabilities, &out.RequiredDropCapabilities
+		*out = make([]corev1.Capability, len(*in))
+		copy(*out, *in)
 	}
-	if in.AuthorisationRules != nil {
-		in, out := &in.AuthorisationRules, &out.AuthorisationRules
-		*out = make([]ConsoleAuthorisationRule, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.RequiredResourceLimits != nil {
+		in, out := &in.RequiredResourceLimits, &out.RequiredResourceLimits
+		*out = make([]corev1.ResourceName, len(*in))
+		copy(*out, *in)
 	}
-	if in.DefaultAuthorisationRule != nil {
-		in, out := &in.DefaultAuthorisationRule, &out.DefaultAuthorisationRule
-		*out = new(ConsoleAuthorisers)
-		(*in).DeepCopyInto(*out)
+	if in.ForbiddenVolumeTypes != nil {
+		in, out := &in.ForbiddenVolumeTypes, &out.ForbiddenVolumeTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplateSpec.
-func (in *ConsoleTemplateSpec) DeepCopy() *ConsoleTemplateSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSecurityProfileSpec.
+func (in *ConsoleSecurityProfileSpec) DeepCopy() *ConsoleSecurityProfileSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ConsoleTemplateSpec)
+	out := new(ConsoleSecurityProfileSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ConsoleTemplateStatus) DeepCopyInto(out *ConsoleTemplateStatus) {
+func (in *ConsoleSecurityProfileStatus) DeepCopyInto(out *ConsoleSecurityProfileStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplateStatus.
-func (in *ConsoleTemplateStatus) DeepCopy() *ConsoleTemplateStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSecurityProfileStatus.
+func (in *ConsoleSecurityProfileStatus) DeepCopy() *ConsoleSecurityProfileStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ConsoleTemplateStatus)
+	out := new(ConsoleSecurityProfileStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodTemplatePreserveMetadataSpec) DeepCopyInto(out *PodTemplatePreserveMetadataSpec) {
+func (in *ConsoleShare) DeepCopyInto(out *ConsoleShare) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplatePreserveMetadataSpec.
-func (in *PodTemplatePreserveMetadataSpec) DeepCopy() *PodTemplatePreserveMetadataSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleShare.
+func (in *ConsoleShare) DeepCopy() *ConsoleShare {
 	if in == nil {
 		return nil
 	}
-	out := new(PodTemplatePreserveMetadataSpec)
+	out := new(ConsoleShare)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleShare) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleShareList) DeepCopyInto(out *ConsoleShareList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsoleShare, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleShareList.
+func (in *ConsoleShareList) DeepCopy() *ConsoleShareList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleShareList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleShareList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleShareSpec) DeepCopyInto(out *ConsoleShareSpec) {
+	*out = *in
+	out.ConsoleRef = in.ConsoleRef
+	if in.Participants != nil {
+		in, out := &in.Participants, &out.Participants
+		*out = make([]ConsoleParticipant, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleShareSpec.
+func (in *ConsoleShareSpec) DeepCopy() *ConsoleShareSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleShareSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleShareStatus) DeepCopyInto(out *ConsoleShareStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleShareStatus.
+func (in *ConsoleShareStatus) DeepCopy() *ConsoleShareStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleShareStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleSpec) DeepCopyInto(out *ConsoleSpec) {
+	*out = *in
+	out.ConsoleTemplateRef = in.ConsoleTemplateRef
+	if in.TTLSecondsBeforeRunning != nil {
+		in, out := &in.TTLSecondsBeforeRunning, &out.TTLSecondsBeforeRunning
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleSpec.
+func (in *ConsoleSpec) DeepCopy() *ConsoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleStatus) DeepCopyInto(out *ConsoleStatus) {
+	*out = *in
+	if in.ExpiryTime != nil {
+		in, out := &in.ExpiryTime, &out.ExpiryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Participants != nil {
+		in, out := &in.Participants, &out.Participants
+		*out = make([]ConsoleParticipantStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodFailure != nil {
+		in, out := &in.PodFailure, &out.PodFailure
+		*out = new(ConsolePodFailure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IdleTime != nil {
+		in, out := &in.IdleTime, &out.IdleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleStatus.
+func (in *ConsoleStatus) DeepCopy() *ConsoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTemplate) DeepCopyInto(out *ConsoleTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplate.
+func (in *ConsoleTemplate) DeepCopy() *ConsoleTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTemplateList) DeepCopyInto(out *ConsoleTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsoleTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplateList.
+func (in *ConsoleTemplateList) DeepCopy() *ConsoleTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTemplateSpec) DeepCopyInto(out *ConsoleTemplateSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.AdditionalAttachSubjects != nil {
+		in, out := &in.AdditionalAttachSubjects, &out.AdditionalAttachSubjects
+		*out = make([]v1.Subject, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultTTLSecondsBeforeRunning != nil {
+		in, out := &in.DefaultTTLSecondsBeforeRunning, &out.DefaultTTLSecondsBeforeRunning
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DefaultTTLSecondsAfterFinished != nil {
+		in, out := &in.DefaultTTLSecondsAfterFinished, &out.DefaultTTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetainFinishedConsolesPerUser != nil {
+		in, out := &in.RetainFinishedConsolesPerUser, &out.RetainFinishedConsolesPerUser
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PodFailureTimeoutSeconds != nil {
+		in, out := &in.PodFailureTimeoutSeconds, &out.PodFailureTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.IdleTimeoutSeconds != nil {
+		in, out := &in.IdleTimeoutSeconds, &out.IdleTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.AuthorisationRules != nil {
+		in, out := &in.AuthorisationRules, &out.AuthorisationRules
+		*out = make([]ConsoleAuthorisationRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DefaultAuthorisationRule != nil {
+		in, out := &in.DefaultAuthorisationRule, &out.DefaultAuthorisationRule
+		*out = new(ConsoleAuthorisers)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FileTransfer != nil {
+		in, out := &in.FileTransfer, &out.FileTransfer
+		*out = new(ConsoleFileTransferPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplateSpec.
+func (in *ConsoleTemplateSpec) DeepCopy() *ConsoleTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTemplateStatus) DeepCopyInto(out *ConsoleTemplateStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTemplateStatus.
+func (in *ConsoleTemplateStatus) DeepCopy() *ConsoleTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTransferLog) DeepCopyInto(out *ConsoleTransferLog) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTransferLog.
+func (in *ConsoleTransferLog) DeepCopy() *ConsoleTransferLog {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTransferLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleTransferLog) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTransferLogList) DeepCopyInto(out *ConsoleTransferLogList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsoleTransferLog, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTransferLogList.
+func (in *ConsoleTransferLogList) DeepCopy() *ConsoleTransferLogList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTransferLogList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsoleTransferLogList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTransferLogSpec) DeepCopyInto(out *ConsoleTransferLogSpec) {
+	*out = *in
+	out.ConsoleRef = in.ConsoleRef
+	if in.Transfers != nil {
+		in, out := &in.Transfers, &out.Transfers
+		*out = make([]ConsoleFileTransfer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTransferLogSpec.
+func (in *ConsoleTransferLogSpec) DeepCopy() *ConsoleTransferLogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTransferLogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTransferLogStatus) DeepCopyInto(out *ConsoleTransferLogStatus) {
+	*out = *in
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]ConsoleFileTransferResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTransferLogStatus.
+func (in *ConsoleTransferLogStatus) DeepCopy() *ConsoleTransferLogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTransferLogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleTransferLogUpdate) DeepCopyInto(out *ConsoleTransferLogUpdate) {
+	*out = *in
+	if in.existingLog != nil {
+		in, out := &in.existingLog, &out.existingLog
+		*out = new(ConsoleTransferLog)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.updatedLog != nil {
+		in, out := &in.updatedLog, &out.updatedLog
+		*out = new(ConsoleTransferLog)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.template != nil {
+		in, out := &in.template, &out.template
+		*out = new(ConsoleTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleTransferLogUpdate.
+func (in *ConsoleTransferLogUpdate) DeepCopy() *ConsoleTransferLogUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleTransferLogUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplatePreserveMetadataSpec) DeepCopyInto(out *PodTemplatePreserveMetadataSpec) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplatePreserveMetadataSpec.
+func (in *PodTemplatePreserveMetadataSpec) DeepCopy() *PodTemplatePreserveMetadataSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplatePreserveMetadataSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PriorityPolicy) DeepCopyInto(out *PriorityPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriorityPolicy.
+func (in *PriorityPolicy) DeepCopy() *PriorityPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PriorityPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PriorityPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PriorityPolicyList) DeepCopyInto(out *PriorityPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PriorityPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriorityPolicyList.
+func (in *PriorityPolicyList) DeepCopy() *PriorityPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(PriorityPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PriorityPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PriorityPolicySpec) DeepCopyInto(out *PriorityPolicySpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]PriorityRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriorityPolicySpec.
+func (in *PriorityPolicySpec) DeepCopy() *PriorityPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PriorityPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PriorityPolicyStatus) DeepCopyInto(out *PriorityPolicyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriorityPolicyStatus.
+func (in *PriorityPolicyStatus) DeepCopy() *PriorityPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PriorityPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PriorityRule) DeepCopyInto(out *PriorityRule) {
+	*out = *in
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OwnerKinds != nil {
+		in, out := &in.OwnerKinds, &out.OwnerKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceAccountNames != nil {
+		in, out := &in.ServiceAccountNames, &out.ServiceAccountNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriorityRule.
+func (in *PriorityRule) DeepCopy() *PriorityRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PriorityRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrioritySubject) DeepCopyInto(out *PrioritySubject) {
+	*out = *in
+	if in.Pod != nil {
+		in, out := &in.Pod, &out.Pod
+		*out = new(corev1.Pod)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(corev1.Namespace)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OwnerKinds != nil {
+		in, out := &in.OwnerKinds, &out.OwnerKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrioritySubject.
+func (in *PrioritySubject) DeepCopy() *PrioritySubject {
+	if in == nil {
+		return nil
+	}
+	out := new(PrioritySubject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsole) DeepCopyInto(out *ScheduledConsole) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsole.
+func (in *ScheduledConsole) DeepCopy() *ScheduledConsole {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledConsole) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleApproval) DeepCopyInto(out *ScheduledConsoleApproval) {
+	*out = *in
+	out.Subject = in.Subject
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleApproval.
+func (in *ScheduledConsoleApproval) DeepCopy() *ScheduledConsoleApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleAuthorisation) DeepCopyInto(out *ScheduledConsoleAuthorisation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleAuthorisation.
+func (in *ScheduledConsoleAuthorisation) DeepCopy() *ScheduledConsoleAuthorisation {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleAuthorisation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledConsoleAuthorisation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleAuthorisationList) DeepCopyInto(out *ScheduledConsoleAuthorisationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScheduledConsoleAuthorisation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleAuthorisationList.
+func (in *ScheduledConsoleAuthorisationList) DeepCopy() *ScheduledConsoleAuthorisationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleAuthorisationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledConsoleAuthorisationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleAuthorisationSpec) DeepCopyInto(out *ScheduledConsoleAuthorisationSpec) {
+	*out = *in
+	out.ScheduledConsoleRef = in.ScheduledConsoleRef
+	if in.Approvals != nil {
+		in, out := &in.Approvals, &out.Approvals
+		*out = make([]ScheduledConsoleApproval, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleAuthorisationSpec.
+func (in *ScheduledConsoleAuthorisationSpec) DeepCopy() *ScheduledConsoleAuthorisationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleAuthorisationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleAuthorisationStatus) DeepCopyInto(out *ScheduledConsoleAuthorisationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleAuthorisationStatus.
+func (in *ScheduledConsoleAuthorisationStatus) DeepCopy() *ScheduledConsoleAuthorisationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleAuthorisationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleAuthorisationUpdate) DeepCopyInto(out *ScheduledConsoleAuthorisationUpdate) {
+	*out = *in
+	if in.existingAuth != nil {
+		in, out := &in.existingAuth, &out.existingAuth
+		*out = new(ScheduledConsoleAuthorisation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.updatedAuth != nil {
+		in, out := &in.updatedAuth, &out.updatedAuth
+		*out = new(ScheduledConsoleAuthorisation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleAuthorisationUpdate.
+func (in *ScheduledConsoleAuthorisationUpdate) DeepCopy() *ScheduledConsoleAuthorisationUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleAuthorisationUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleList) DeepCopyInto(out *ScheduledConsoleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ScheduledConsole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleList.
+func (in *ScheduledConsoleList) DeepCopy() *ScheduledConsoleList {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ScheduledConsoleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsolePreApproval) DeepCopyInto(out *ScheduledConsolePreApproval) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsolePreApproval.
+func (in *ScheduledConsolePreApproval) DeepCopy() *ScheduledConsolePreApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsolePreApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleSpec) DeepCopyInto(out *ScheduledConsoleSpec) {
+	*out = *in
+	if in.UserGroups != nil {
+		in, out := &in.UserGroups, &out.UserGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	in.ConsoleTemplate.DeepCopyInto(&out.ConsoleTemplate)
+	if in.SuccessfulConsolesHistoryLimit != nil {
+		in, out := &in.SuccessfulConsolesHistoryLimit, &out.SuccessfulConsolesHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedConsolesHistoryLimit != nil {
+		in, out := &in.FailedConsolesHistoryLimit, &out.FailedConsolesHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PreApproval != nil {
+		in, out := &in.PreApproval, &out.PreApproval
+		*out = new(ScheduledConsolePreApproval)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleSpec.
+func (in *ScheduledConsoleSpec) DeepCopy() *ScheduledConsoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleStatus) DeepCopyInto(out *ScheduledConsoleStatus) {
+	*out = *in
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleStatus.
+func (in *ScheduledConsoleStatus) DeepCopy() *ScheduledConsoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledConsoleTemplate) DeepCopyInto(out *ScheduledConsoleTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.ConsoleTemplateRef = in.ConsoleTemplateRef
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledConsoleTemplate.
+func (in *ScheduledConsoleTemplate) DeepCopy() *ScheduledConsoleTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledConsoleTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
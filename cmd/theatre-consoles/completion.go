@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
+)
+
+const (
+	// kubectl runs plugins named kubectl-<plugin> as `kubectl <plugin>`, and asks for
+	// completions by running kubectl_complete-<plugin>, so we can be installed as both
+	// by linking to the same binary
+	kubectlPluginPrefix     = "kubectl-"
+	kubectlCompletionPrefix = "kubectl_complete-"
+
+	// completionTimeout bounds how long we'll wait on the cluster when completing, as
+	// the user is waiting on their shell, unless given a --request-timeout
+	completionTimeout = 5 * time.Second
+)
+
+// commandName returns how the user invoked us, for use in hints that suggest running
+// another command
+func commandName() string {
+	name := filepath.Base(os.Args[0])
+	for _, prefix := range []string{kubectlPluginPrefix, kubectlCompletionPrefix} {
+		if strings.HasPrefix(name, prefix) {
+			return "kubectl " + strings.TrimPrefix(name, prefix)
+		}
+	}
+
+	return name
+}
+
+func isKubectlPlugin() bool {
+	return strings.HasPrefix(commandName(), "kubectl ")
+}
+
+func isKubectlCompletion() bool {
+	return strings.HasPrefix(filepath.Base(os.Args[0]), kubectlCompletionPrefix)
+}
+
+// runKubectlCompletion answers kubectl when it asks us to complete the arguments typed
+// so far. kubectl expects the output of a cobra __complete command, which is one
+// candidate per line followed by a directive, where :4 stops the shell falling back
+// to completing file names.
+func runKubectlCompletion(args []string) {
+	cli.Terminate(func(status int) {
+		fmt.Printf("\n:4\n")
+		os.Exit(status)
+	})
+
+	cli.Parse(append([]string{"--completion-bash"}, completionArgs(args)...))
+}
+
+// completionArgs converts the arguments kubectl passes when asking for completions to
+// those kingpin expects. kubectl always passes the word being completed, even if it's
+// empty, whereas kingpin expects empty words to have been dropped by the shell.
+func completionArgs(args []string) []string {
+	if len(args) > 0 && args[len(args)-1] == "" {
+		return args[:len(args)-1]
+	}
+
+	return args
+}
+
+// completeConsoleNames offers the names of consoles in the target namespace. As with
+// all completions, failures result in no suggestions rather than an error, as there's
+// nowhere to show one.
+func completeConsoleNames() []string {
+	consoleRunner, namespace, err := newCompletionRunner()
+	if err != nil {
+		return nil
+	}
+
+	consoles, err := consoleRunner.ListConsolesByLabelsAndUser(context.Background(), namespace, "", "")
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+	for _, csl := range consoles {
+		names = append(names, csl.Name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// completeTemplateSelectors offers each label of the console templates in the target
+// namespace as a selector, which is how templates are usually chosen
+func completeTemplateSelectors() []string {
+	consoleRunner, namespace, err := newCompletionRunner()
+	if err != nil {
+		return nil
+	}
+
+	templates, err := consoleRunner.ListTemplatesBySelector(context.Background(), namespace, "")
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	selectors := []string{}
+	for _, tpl := range templates {
+		for key, value := range tpl.Labels {
+			selector := key + "=" + value
+			if !seen[selector] {
+				seen[selector] = true
+				selectors = append(selectors, selector)
+			}
+		}
+	}
+
+	sort.Strings(selectors)
+	return selectors
+}
+
+// newCompletionRunner builds a runner from the flags parsed so far. kingpin sets the
+// values of the flags it has seen before asking for completions, so we target the
+// same cluster and namespace as the command being completed.
+func newCompletionRunner() (*runner.Runner, string, error) {
+	configFlags := newConfigFlags()
+	config, err := newKubeConfig(configFlags)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = completionTimeout
+	}
+
+	namespace, err := targetNamespace(configFlags)
+	if err != nil {
+		return nil, "", err
+	}
+
+	consoleRunner, err := runner.New(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return consoleRunner, namespace, nil
+}
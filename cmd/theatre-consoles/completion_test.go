@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Detecting how we were invoked", func() {
+	var arg0 string
+
+	BeforeEach(func() {
+		arg0 = os.Args[0]
+	})
+
+	AfterEach(func() {
+		os.Args[0] = arg0
+	})
+
+	DescribeTable("Names the command from the binary that was run",
+		func(binary, name string, plugin, completion bool) {
+			os.Args[0] = binary
+
+			Expect(commandName()).To(Equal(name))
+			Expect(isKubectlPlugin()).To(Equal(plugin))
+			Expect(isKubectlCompletion()).To(Equal(completion))
+		},
+		Entry("run directly", "theatre-consoles", "theatre-consoles", false, false),
+		Entry("run directly by path", "/usr/local/bin/theatre-consoles", "theatre-consoles", false, false),
+		Entry("run as a kubectl plugin", "/usr/local/bin/kubectl-consoles", "kubectl consoles", true, false),
+		Entry("asked for completions by kubectl", "/usr/local/bin/kubectl_complete-consoles", "kubectl consoles", true, true),
+		Entry("a plugin with a different name", "kubectl-console", "kubectl console", true, false),
+		Entry("a name that only contains the prefix", "my-kubectl-consoles", "my-kubectl-consoles", false, false),
+	)
+})
+
+var _ = Describe("completionArgs", func() {
+	DescribeTable("Drops the empty word kubectl passes when completing",
+		func(args, expected []string) {
+			Expect(completionArgs(args)).To(Equal(expected))
+		},
+		Entry("no arguments", []string{}, []string{}),
+		Entry("completing a command", []string{""}, []string{}),
+		Entry("completing a flag", []string{"attach", "--name", ""}, []string{"attach", "--name"}),
+		Entry("completing a partial word", []string{"attach", "--name", "my-con"}, []string{"attach", "--name", "my-con"}),
+	)
+})
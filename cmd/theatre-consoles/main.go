@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	stdlog "log"
 	"os"
 	"strings"
@@ -11,10 +12,12 @@ import (
 	"github.com/alecthomas/kingpin"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // this is required to auth against GCP
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
+	"k8s.io/kubectl/pkg/util/term"
 
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
 	"github.com/gocardless/theatre/v2/cmd"
@@ -23,21 +26,35 @@ import (
 )
 
 var (
-	cli = kingpin.New("consoles", "Manages theatre consoles").Version(cmd.VersionStanza())
+	cli = kingpin.New(commandName(), "Manages theatre consoles").Version(cmd.VersionStanza())
 
+	cliKubeConfig = cli.Flag("kubeconfig", "Path to the kubeconfig file to use. If not provided defaults to $KUBECONFIG or ~/.kube/config").
+			String()
 	cliContext = cli.Flag("context", "Kubernetes context to target. If not provided defaults to current context").
 			Short('c').
 			Envar("KUBERNETES_CONTEXT").
 			String()
-	cliNamespace = cli.Flag("namespace", "Kubernetes namespace to target. If not provided defaults to target allnamespaces").
+	cliCluster = cli.Flag("cluster", "Name of the kubeconfig cluster to use").
+			String()
+	cliNamespace = cli.Flag("namespace", "Kubernetes namespace to target. If not provided defaults to all namespaces, or the namespace of the current context when run as a kubectl plugin").
 			Short('n').
 			Envar("KUBERNETES_NAMESPACE").
 			String()
+	cliAllNamespaces = cli.Flag("all-namespaces", "Target all namespaces, even when run as a kubectl plugin").
+				Short('A').
+				Bool()
+	cliImpersonate = cli.Flag("as", "Username to impersonate for the operation").
+			String()
+	cliImpersonateGroups = cli.Flag("as-group", "Group to impersonate for the operation. Can be given multiple times").
+				Strings()
+	cliRequestTimeout = cli.Flag("request-timeout", "How long to wait for a single request to the API server before giving up, such as 1s or 2m. Zero means don't time out").
+				Default("0").
+				String()
 
 	create         = cli.Command("create", "Creates a new console given a template")
-	createSelector = create.Flag("selector", "Selector to match a console template").
+	createSelector = create.Flag("selector", "Selector to match a console template. If not provided, you will be asked to choose one").
+			HintAction(completeTemplateSelectors).
 			Short('s').
-			Required().
 			String()
 	createTimeout = create.Flag("timeout", "Timeout for the new console").
 			Duration()
@@ -47,33 +64,112 @@ var (
 				Bool()
 	createAttach = create.Flag("attach", "Attach to the console if it starts successfully").
 			Bool()
+	createReconnectAttempts = create.Flag("reconnect-attempts", "Number of times to reconnect if the connection to an attached console drops").
+				Default("5").
+				Int()
+	createScript = create.Flag("script", "Run the script in this file non-interactively, passing it to the command if given").
+			ExistingFile()
+	createStdinScript = create.Flag("stdin-script", "Read the script to run from standard input").
+				Bool()
 	createCommand = create.Arg("command", "Command to run in console").
 			Strings()
 
 	attach     = cli.Command("attach", "Attach to a running console")
 	attachName = attach.Flag("name", "Console name").
-			Required().
+			HintAction(completeConsoleNames).
+			String()
+	attachLast = attach.Flag("last", "Attach to your most recently created running console").
+			Bool()
+	attachUser = attach.Flag("user", "When using --last, only consider consoles created by this user").
 			String()
+	attachScrollback = attach.Flag("scrollback", "Number of lines of recent console output to show before attaching").
+				Default("20").
+				Int64()
+	attachReconnectAttempts = attach.Flag("reconnect-attempts", "Number of times to reconnect if the connection to the console drops").
+				Default("5").
+				Int()
+	attachReadOnly = attach.Flag("read-only", "Follow the console output without sending any input, for consoles shared with you in read-only mode").
+			Bool()
 
 	list         = cli.Command("list", "List currently running consoles")
 	listUsername = list.Flag("user", "Kubernetes username. Not usually supplied, can be inferred from your gcloud login").
 			Short('u').
 			Default("").
 			String()
-	listSelector = list.Flag("selector", "Selector to match the console").
+	listSelector = list.Flag("selector", "Selector to match the console, such as app=myapp or 'access in (open,restricted)'").
 			Short('s').
 			Default("").
 			String()
+	listOutput = list.Flag("output", "Output format. One of: json|yaml|wide|jsonpath=...|go-template=...").
+			Short('o').
+			Default("").
+			String()
+	listWatch = list.Flag("watch", "After listing consoles, watch for phase transitions").
+			Short('w').
+			Bool()
+	listPhases = list.Flag("phase", "Only show consoles in this phase, such as Running or PendingAuthorisation. Can be given multiple times").
+			Strings()
+	listAuthoriser = list.Flag("authoriser", "Only show consoles awaiting authorisation that this user can authorise").
+			String()
+	listAuthoriserGroups = list.Flag("authoriser-group", "Only show consoles awaiting authorisation that members of this group can authorise. Can be given multiple times").
+				Strings()
+
+	templates         = cli.Command("templates", "List the console templates that consoles can be created from")
+	templatesSelector = templates.Flag("selector", "Selector to match console templates").
+				HintAction(completeTemplateSelectors).
+				Short('s').
+				Default("").
+				String()
+	templatesOutput = templates.Flag("output", "Output format. One of: json|yaml|jsonpath=...|go-template=...").
+			Short('o').
+			Default("").
+			String()
+
+	get     = cli.Command("get", "Get a single console")
+	getName = get.Flag("name", "Console name").
+		HintAction(completeConsoleNames).
+		Required().
+		String()
+	getOutput = get.Flag("output", "Output format. One of: json|yaml|wide|jsonpath=...|go-template=...").
+			Short('o').
+			Default("").
+			String()
+
+	describe     = cli.Command("describe", "Explain the state of a console, including its authorisation and a timeline of what has happened to it")
+	describeName = describe.Flag("name", "Console name").
+			HintAction(completeConsoleNames).
+			Required().
+			String()
+
+	share     = cli.Command("share", "Invite other users to join a running console that you own")
+	shareName = share.Flag("name", "Console to share").
+			HintAction(completeConsoleNames).
+			Required().
+			String()
+	shareUsers = share.Flag("user", "Name of the user to invite. Can be given multiple times").
+			Required().
+			Strings()
+	shareReadOnly = share.Flag("read-only", "Only allow the invited users to follow the console output, and not send any input").
+			Bool()
+
+	cp            = cli.Command("cp", "Copy a file into or out of a running console, such as `cp report.csv my-console:/tmp/` or `cp my-console:/tmp/out.csv .`")
+	cpSource      = cp.Arg("source", "File to copy, either a local path or <console-name>:<path>").Required().String()
+	cpDestination = cp.Arg("destination", "Where to copy the file to, either a local path or <console-name>:<path>").Required().String()
 
 	authorise     = cli.Command("authorise", "Authorise a peer-reviewed console request")
 	authoriseUser = authorise.Flag("user", "Name of the user to attribute to verification. This must match the username that the Kubernetes API recognises you as").
 			String()
 	authoriseName = authorise.Flag("name", "Console to authorise").
+			HintAction(completeConsoleNames).
 			Required().
 			String()
 )
 
 func main() {
+	if isKubectlCompletion() {
+		runKubectlCompletion(os.Args[1:])
+	}
+
 	// Set up logging
 	logger := kitlog.NewLogfmtLogger(os.Stderr)
 	logger = level.NewFilter(logger, level.AllowInfo())
@@ -95,7 +191,13 @@ func Run(ctx context.Context, logger kitlog.Logger) error {
 	// This is done here to bind the flags without creating multiple global variables.
 	cmd := kingpin.MustParse(cli.Parse(os.Args[1:]))
 
-	config, err := newKubeConfig(*cliContext)
+	configFlags := newConfigFlags()
+	config, err := newKubeConfig(configFlags)
+	if err != nil {
+		return err
+	}
+
+	namespace, err := targetNamespace(configFlags)
 	if err != nil {
 		return err
 	}
@@ -108,17 +210,32 @@ func Run(ctx context.Context, logger kitlog.Logger) error {
 	// Match on the kingpin command and enter the main command
 	switch cmd {
 	case create.FullCommand():
+		script, err := readScript(*createScript, *createStdinScript)
+		if err != nil {
+			return err
+		}
+
+		var template *workloadsv1alpha1.ConsoleTemplate
+		if *createSelector == "" {
+			if template, err = pickTemplate(ctx, consoleRunner, namespace, *createStdinScript); err != nil {
+				return err
+			}
+		}
+
 		_, err = consoleRunner.Create(
 			ctx,
 			runner.CreateOptions{
-				Namespace:      *cliNamespace,
-				Selector:       *createSelector,
-				Timeout:        *createTimeout,
-				Reason:         *createReason,
-				Command:        *createCommand,
-				Attach:         *createAttach,
-				Noninteractive: *createNoninteractive,
-				KubeConfig:     config,
+				Namespace:         namespace,
+				Selector:          *createSelector,
+				Template:          template,
+				Timeout:           *createTimeout,
+				Reason:            *createReason,
+				Command:           *createCommand,
+				Attach:            *createAttach,
+				Noninteractive:    *createNoninteractive,
+				Script:            script,
+				KubeConfig:        config,
+				ReconnectAttempts: *createReconnectAttempts,
 				IO: runner.IOStreams{
 					In:     os.Stdin,
 					Out:    os.Stdout,
@@ -127,14 +244,39 @@ func Run(ctx context.Context, logger kitlog.Logger) error {
 				Hook: LifecyclePrinter(logger),
 			},
 		)
+
+		// Point users who don't know which selector to use at the templates command
+		var templatesErr runner.MultipleConsoleTemplateError
+		if errors.As(err, &templatesErr) {
+			return fmt.Errorf("%w, run `%s templates` to see the available templates", err, commandName())
+		}
+
 		return err
 	case attach.FullCommand():
+		name := *attachName
+		switch {
+		case *attachLast && name != "":
+			return errors.New("--name and --last cannot be used together")
+		case *attachLast:
+			csl, err := consoleRunner.FindLatestAttachableConsole(ctx, namespace, *attachUser)
+			if err != nil {
+				return err
+			}
+
+			namespace, name = csl.Namespace, csl.Name
+		case name == "":
+			return errors.New("either --name or --last must be provided")
+		}
+
 		return consoleRunner.Attach(
 			ctx,
 			runner.AttachOptions{
-				Namespace:  *cliNamespace,
-				KubeConfig: config,
-				Name:       *attachName,
+				Namespace:         namespace,
+				KubeConfig:        config,
+				Name:              name,
+				Scrollback:        *attachScrollback,
+				ReconnectAttempts: *attachReconnectAttempts,
+				ReadOnly:          *attachReadOnly,
 				IO: runner.IOStreams{
 					In:     os.Stdin,
 					Out:    os.Stdout,
@@ -144,21 +286,147 @@ func Run(ctx context.Context, logger kitlog.Logger) error {
 			},
 		)
 	case list.FullCommand():
+		phases, err := parsePhases(*listPhases)
+		if err != nil {
+			return err
+		}
+
+		filter := runner.ConsoleFilter{
+			Phases:           phases,
+			Authoriser:       *listAuthoriser,
+			AuthoriserGroups: *listAuthoriserGroups,
+		}
+
+		if *listWatch {
+			return consoleRunner.Watch(
+				ctx,
+				runner.WatchOptions{
+					Namespace:    namespace,
+					Username:     *listUsername,
+					Selector:     *listSelector,
+					Filter:       filter,
+					Output:       os.Stdout,
+					OutputFormat: *listOutput,
+				},
+			)
+		}
+
 		_, err = consoleRunner.List(
 			ctx,
 			runner.ListOptions{
-				Namespace: *cliNamespace,
-				Username:  *listUsername,
-				Selector:  *listSelector,
-				Output:    os.Stdout,
+				Namespace:    namespace,
+				Username:     *listUsername,
+				Selector:     *listSelector,
+				Filter:       filter,
+				Output:       os.Stdout,
+				OutputFormat: *listOutput,
+			},
+		)
+		return err
+	case templates.FullCommand():
+		_, err = consoleRunner.ListTemplates(
+			ctx,
+			runner.ListTemplatesOptions{
+				Namespace:    namespace,
+				Selector:     *templatesSelector,
+				Output:       os.Stdout,
+				OutputFormat: *templatesOutput,
 			},
 		)
 		return err
+	case get.FullCommand():
+		if err := runner.ValidateOutputFormat(*getOutput); err != nil {
+			return err
+		}
+
+		csl, err := consoleRunner.Get(
+			ctx,
+			runner.GetOptions{
+				Namespace:   namespace,
+				ConsoleName: *getName,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		printOpts := runner.PrintOptions{Format: *getOutput}
+		if *getOutput == runner.OutputFormatWide {
+			printOpts.Authorisations, err = consoleRunner.AuthorisationProgress(ctx, csl.Namespace, runner.ConsoleSlice{*csl})
+			if err != nil {
+				return err
+			}
+		}
+
+		return runner.PrintConsole(os.Stdout, csl, printOpts)
+	case describe.FullCommand():
+		desc, err := consoleRunner.Describe(
+			ctx,
+			runner.DescribeOptions{
+				Namespace:   namespace,
+				ConsoleName: *describeName,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		return desc.Print(os.Stdout)
+	case share.FullCommand():
+		csl, err := consoleRunner.Share(
+			ctx,
+			runner.ShareOptions{
+				Namespace:   namespace,
+				ConsoleName: *shareName,
+				Users:       *shareUsers,
+				ReadOnly:    *shareReadOnly,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		attachCmd := fmt.Sprintf("%s attach --name %s --namespace %s", commandName(), csl.Name, csl.Namespace)
+		if *shareReadOnly {
+			attachCmd += " --read-only"
+		}
+
+		logger.Log(
+			"msg", "Shared console",
+			"prompt", fmt.Sprintf("Invited users can join by running `%s`", attachCmd),
+			"users", strings.Join(*shareUsers, ","),
+			"console", csl.Name,
+			"namespace", csl.Namespace,
+		)
+
+		return nil
+	case cp.FullCommand():
+		transfer, result, err := consoleRunner.Copy(
+			ctx,
+			runner.CopyOptions{
+				Namespace:   namespace,
+				Source:      *cpSource,
+				Destination: *cpDestination,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		logger.Log(
+			"msg", "Copied file",
+			"direction", transfer.Direction,
+			"path", transfer.Path,
+			"size_bytes", result.SizeBytes,
+			"sha256", result.SHA256,
+		)
+
+		return nil
 	case authorise.FullCommand():
 		err = consoleRunner.Authorise(
 			ctx,
 			runner.AuthoriseOptions{
-				Namespace:   *cliNamespace,
+				Namespace:   namespace,
 				ConsoleName: *authoriseName,
 				Username:    *authoriseUser,
 			},
@@ -188,14 +456,21 @@ func LifecyclePrinter(logger kitlog.Logger) runner.LifecycleHook {
 			}
 			authorisers := strings.Join(authoriserSlice, ",")
 
-			logger.Log(
+			keyvals := []interface{}{
 				"msg", "Console requires authorisation",
-				"prompt", fmt.Sprintf("Please get a user from the list of authorisers to approve by running `theatre-consoles authorise --name %s --namespace %s --username {THEIR_USERNAME}`", csl.Name, csl.Namespace),
+				"prompt", fmt.Sprintf("Please get a user from the list of authorisers to approve by running `%s authorise --name %s --namespace %s --user {THEIR_USERNAME}`", commandName(), csl.Name, csl.Namespace),
 				"authorisers", authorisers,
 				"console", csl.Name,
 				"namespace", csl.Namespace,
 				"pod", csl.Status.PodName,
-			)
+			}
+
+			// Authorisers can compare this against the script they were asked to approve
+			if csl.HasScript() {
+				keyvals = append(keyvals, "script_sha256", csl.Spec.ScriptSHA256)
+			}
+
+			logger.Log(keyvals...)
 			return nil
 		},
 		ConsoleReadyFunc: func(csl *workloadsv1alpha1.Console) error {
@@ -207,6 +482,16 @@ func LifecyclePrinter(logger kitlog.Logger) runner.LifecycleHook {
 			)
 			return nil
 		},
+		ReconnectingToConsoleFunc: func(csl *workloadsv1alpha1.Console, attempt int, err error) error {
+			logger.Log(
+				"msg", "Lost connection to console, reconnecting",
+				"console", csl.Name,
+				"namespace", csl.Namespace,
+				"attempt", attempt,
+				"error", err,
+			)
+			return nil
+		},
 		ConsoleCreatedFunc: func(csl *workloadsv1alpha1.Console) error {
 			logger.Log(
 				"msg", "Console has been requested",
@@ -218,19 +503,138 @@ func LifecyclePrinter(logger kitlog.Logger) runner.LifecycleHook {
 	}
 }
 
-// newKubeConfig first tries using internal kubernetes configuration, and then falls back
-// to ~/.kube/config
-func newKubeConfig(kctx string) (*rest.Config, error) {
-	if config, err := rest.InClusterConfig(); err == nil {
-		return config, nil
+// pickTemplate asks the user to choose a console template, for when create is run
+// without a selector. We can only ask when attached to a terminal, and when standard
+// input isn't being used to provide the script.
+func pickTemplate(ctx context.Context, consoleRunner *runner.Runner, namespace string, stdinScript bool) (*workloadsv1alpha1.ConsoleTemplate, error) {
+	if stdinScript || !(term.TTY{In: os.Stdin}).IsTerminalIn() {
+		return nil, fmt.Errorf("--selector is required when not running interactively, use `%s templates` to find one", commandName())
 	}
 
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		clientcmd.NewDefaultClientConfigLoadingRules(),
-		&clientcmd.ConfigOverrides{
-			CurrentContext: kctx,
-		},
-	).ClientConfig()
+	templates, err := consoleRunner.ListTemplatesBySelector(ctx, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.PickTemplate(runner.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}, templates)
+}
+
+// readScript loads the script to run in a console from either a file or standard
+// input, returning an empty script if neither was requested.
+func readScript(path string, fromStdin bool) (string, error) {
+	var (
+		content []byte
+		err     error
+	)
+
+	switch {
+	case path != "" && fromStdin:
+		return "", errors.New("--script and --stdin-script cannot be used together")
+	case path != "":
+		content, err = ioutil.ReadFile(path)
+	case fromStdin:
+		content, err = ioutil.ReadAll(os.Stdin)
+	default:
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read script: %w", err)
+	}
+
+	if len(content) == 0 {
+		return "", errors.New("script is empty")
+	}
+
+	return string(content), nil
+}
+
+// parsePhases converts phases given on the command line into console phases. Phases
+// such as "Pending Authorisation" contain spaces, so we ignore case, spaces, hyphens and
+// underscores to make them easier to type.
+func parsePhases(values []string) ([]workloadsv1alpha1.ConsolePhase, error) {
+	normalise := strings.NewReplacer(" ", "", "-", "", "_", "")
+	known := []workloadsv1alpha1.ConsolePhase{
+		workloadsv1alpha1.ConsolePendingAuthorisation,
+		workloadsv1alpha1.ConsolePending,
+		workloadsv1alpha1.ConsoleRunning,
+		workloadsv1alpha1.ConsoleStopped,
+		workloadsv1alpha1.ConsoleDestroyed,
+		workloadsv1alpha1.ConsoleFailed,
+		workloadsv1alpha1.ConsoleTerminating,
+	}
+
+	phases := []workloadsv1alpha1.ConsolePhase{}
+values:
+	for _, value := range values {
+		for _, phase := range known {
+			if strings.EqualFold(normalise.Replace(value), normalise.Replace(string(phase))) {
+				phases = append(phases, phase)
+				continue values
+			}
+		}
+
+		return nil, fmt.Errorf("unknown console phase: %s", value)
+	}
+
+	return phases, nil
+}
+
+// newConfigFlags collects the flags that configure our connection to the cluster into
+// the same ConfigFlags that kubectl uses, so we load kubeconfig files the same way
+func newConfigFlags() *genericclioptions.ConfigFlags {
+	flags := genericclioptions.NewConfigFlags(true)
+	flags.KubeConfig = cliKubeConfig
+	flags.Context = cliContext
+	flags.ClusterName = cliCluster
+	flags.Namespace = cliNamespace
+	flags.Impersonate = cliImpersonate
+	flags.ImpersonateGroup = cliImpersonateGroups
+	flags.Timeout = cliRequestTimeout
+
+	return flags
+}
+
+// newKubeConfig first tries using internal kubernetes configuration, unless we were
+// pointed at a kubeconfig file or context, and then falls back to ~/.kube/config
+func newKubeConfig(flags *genericclioptions.ConfigFlags) (*rest.Config, error) {
+	if *flags.KubeConfig == "" && *flags.Context == "" {
+		if config, err := rest.InClusterConfig(); err == nil {
+			return withInClusterOverrides(config, flags)
+		}
+	}
+
+	return flags.ToRESTConfig()
+}
+
+// withInClusterOverrides applies the impersonation and timeout flags to in-cluster
+// configuration, which client-go would otherwise ignore
+func withInClusterOverrides(config *rest.Config, flags *genericclioptions.ConfigFlags) (*rest.Config, error) {
+	timeout, err := clientcmd.ParseTimeout(*flags.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Timeout = timeout
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: *flags.Impersonate,
+		Groups:   *flags.ImpersonateGroup,
+	}
+
+	return config, nil
+}
+
+// targetNamespace returns the namespace to operate in, where an empty namespace means
+// all of them. When run directly we default to all namespaces, but as a kubectl
+// plugin we behave like kubectl and use the namespace of the current context.
+func targetNamespace(flags *genericclioptions.ConfigFlags) (string, error) {
+	switch {
+	case *cliNamespace != "" && *cliAllNamespaces:
+		return "", errors.New("--namespace and --all-namespaces cannot be used together")
+	case *cliNamespace != "", *cliAllNamespaces, !isKubectlPlugin():
+		return *cliNamespace, nil
+	}
 
-	return config, err
+	namespace, _, err := flags.ToRawKubeConfigLoader().Namespace()
+	return namespace, err
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: cluster
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: user
+  user:
+    token: token
+contexts:
+- name: staging
+  context:
+    cluster: cluster
+    user: user
+    namespace: staging-apps
+- name: production
+  context:
+    cluster: cluster
+    user: user
+    namespace: production-apps
+`
+
+var _ = Describe("targetNamespace", func() {
+	var (
+		arg0       string
+		dir        string
+		kubeConfig string
+	)
+
+	BeforeEach(func() {
+		arg0 = os.Args[0]
+
+		var err error
+		dir, err = ioutil.TempDir("", "theatre-consoles")
+		Expect(err).NotTo(HaveOccurred())
+
+		kubeConfig = filepath.Join(dir, "config")
+		Expect(ioutil.WriteFile(kubeConfig, []byte(testKubeConfig), 0600)).To(Succeed())
+
+		// kingpin only resets flags that have defaults between parses
+		*cliNamespace, *cliAllNamespaces, *cliContext = "", false, ""
+	})
+
+	AfterEach(func() {
+		os.Args[0] = arg0
+		os.RemoveAll(dir)
+	})
+
+	parseNamespace := func(binary string, args ...string) (string, error) {
+		os.Args[0] = binary
+
+		args = append([]string{"--kubeconfig", kubeConfig}, args...)
+		_, err := cli.Parse(append(args, "list"))
+		Expect(err).NotTo(HaveOccurred())
+
+		return targetNamespace(newConfigFlags())
+	}
+
+	DescribeTable("Resolves the namespace from the flags",
+		func(binary string, args []string, expected string) {
+			namespace, err := parseNamespace(binary, args...)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(namespace).To(Equal(expected))
+		},
+		Entry("run directly, defaulting to all namespaces", "theatre-consoles", []string{}, ""),
+		Entry("run directly with -n", "theatre-consoles", []string{"-n", "payments"}, "payments"),
+		Entry("run directly with --namespace", "theatre-consoles", []string{"--namespace", "payments"}, "payments"),
+		Entry("run directly with -A", "theatre-consoles", []string{"-A"}, ""),
+		Entry("run directly with --context", "theatre-consoles", []string{"--context", "production"}, ""),
+		Entry("as a plugin, defaulting to the current context", "kubectl-consoles", []string{}, "staging-apps"),
+		Entry("as a plugin with -n", "kubectl-consoles", []string{"-n", "payments"}, "payments"),
+		Entry("as a plugin with -A", "kubectl-consoles", []string{"-A"}, ""),
+		Entry("as a plugin with --all-namespaces", "kubectl-consoles", []string{"--all-namespaces"}, ""),
+		Entry("as a plugin with --context", "kubectl-consoles", []string{"--context", "production"}, "production-apps"),
+		Entry("as a plugin with -c", "kubectl-consoles", []string{"-c", "production"}, "production-apps"),
+		Entry("as a plugin with --context and -n", "kubectl-consoles", []string{"--context", "production", "-n", "payments"}, "payments"),
+	)
+
+	It("Rejects -n with -A", func() {
+		_, err := parseNamespace("kubectl-consoles", "-n", "payments", "-A")
+		Expect(err).To(MatchError("--namespace and --all-namespaces cannot be used together"))
+	})
+})
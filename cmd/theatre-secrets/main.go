@@ -10,15 +10,20 @@ import (
 	execpkg "os/exec"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/alecthomas/kingpin"
 	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/api"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
@@ -45,6 +50,19 @@ var (
 	execConfigFile              = exec.Flag("config-file", "App config file").String()
 	execServiceAccountTokenFile = exec.Flag("service-account-token-file", "Path to Kubernetes service account token file").String()
 	execCommand                 = exec.Arg("command", "Command to execute").Required().Strings()
+
+	plan                        = app.Command("plan", "Resolve secrets as exec would and report on each, without executing anything")
+	planVaultOptions            = newVaultOptions(plan)
+	planConfigFile              = plan.Flag("config-file", "App config file").String()
+	planServiceAccountTokenFile = plan.Flag("service-account-token-file", "Path to Kubernetes service account token file").String()
+
+	syncCmd                     = app.Command("sync", "Authenticate with vault and write secrets as files into a directory, refreshing them periodically")
+	syncVaultOptions            = newVaultOptions(syncCmd)
+	syncConfigFile              = syncCmd.Flag("config-file", "App config file").String()
+	syncServiceAccountTokenFile = syncCmd.Flag("service-account-token-file", "Path to Kubernetes service account token file").String()
+	syncOutputDir               = syncCmd.Flag("output-dir", "Directory to write secret files into").Required().String()
+	syncOnce                    = syncCmd.Flag("once", "Write secrets once and exit, rather than refreshing them").Default("false").Bool()
+	syncRefreshInterval         = syncCmd.Flag("refresh-interval", "How often to refresh secrets from vault").Default("5m").Duration()
 )
 
 type environment map[string]string
@@ -89,146 +107,27 @@ func mainError(ctx context.Context, command string) (err error) {
 	// for secret data. Once in possession of this secret data, set the environment
 	// variables and provision secret data to the filesystem as required.
 	case exec.FullCommand():
-		if execVaultOptions.Token == "" {
-			serviceAccountToken, err := getKubernetesToken(*execServiceAccountTokenFile)
-			if err != nil {
-				return errors.Wrap(err, "failed to authenticate within kubernetes")
-			}
-
-			execVaultOptions.Decorate(logger).Info("logging into vault", "event", "vault.login")
+		vaultCtx, cancel := context.WithTimeout(ctx, execVaultOptions.Deadline)
+		defer cancel()
 
-			vaultToken, err := execVaultOptions.Login(serviceAccountToken)
-			if err != nil {
-				return errors.Wrap(err, "failed to login to vault")
-			}
-
-			execVaultOptions.Token = vaultToken
-		}
-
-		client, err := execVaultOptions.Client()
+		secrets, secretEnv, err := execVaultOptions.Resolve(vaultCtx, logger, *execConfigFile, *execServiceAccountTokenFile)
 		if err != nil {
 			return err
 		}
 
-		env := environment{}
-
-		// Load all the environment variables we currently know from our process
-		for _, element := range os.Environ() {
-			nameValue := strings.SplitN(element, "=", 2)
-			env[nameValue[0]] = nameValue[1]
-		}
-
-		if *execConfigFile != "" {
-			logger.Info(
-				fmt.Sprintf("loading config from %s", *execConfigFile),
-				"event", "config.load",
-				"file_path", *execConfigFile,
-			)
-
-			config, err := loadConfigFromFile(*execConfigFile)
-			if err != nil {
-				return err
-			}
-
-			// Load all the values from our config, which will now override what is set in the
-			// environment variables of the current process
-			for key, value := range config.Environment {
-				env[key] = value
-			}
-		}
-
-		var (
-			// Use a set to describe the keys that we need to pull from Vault,
-			// ensuring that API requests aren't repeated if environment variables or
-			// secret files use the same Vault key.
-			keysToFetch  = map[string]bool{}
-			envPlain     = environment{}
-			envFromVault = environment{}
-			vaultFiles   = map[string]vaultFile{}
-		)
-
-		for key, value := range env {
-			switch {
-			// For all the environment values that look like they should be vault
-			// references, store the envvar -> vault path mapping, and add the vault
-			// path to our list to pull.
-			case strings.HasPrefix(value, "vault:"):
-				vaultKey := strings.TrimPrefix(value, "vault:")
-
-				keysToFetch[vaultKey] = true
-				envFromVault[key] = vaultKey
-
-			// Support 'vault-file:' prefixed env vars.
-			//
-			// For reference, the expected formats are
-			// 'vault-file:tls-key/2021010100' and
-			// 'vault-file:ssh-key/2021010100:/home/user/.ssh/id_rsa'
-			case strings.HasPrefix(value, "vault-file:"):
-				trimmed := strings.TrimSpace(
-					strings.TrimPrefix(value, "vault-file:"),
-				)
-				if len(trimmed) == 0 {
-					return fmt.Errorf("empty vault-file env var: %v", value)
-				}
-
-				split := strings.SplitN(trimmed, ":", 2)
-				vaultKey := split[0]
-				keysToFetch[vaultKey] = true
-
-				// determine if we define a path at which to place the file. For SplitN,
-				// N=2 so we only have two cases
-				switch len(split) {
-				case 2: // path and key
-					vaultFiles[key] = vaultFile{
-						filesystemPath: split[1],
-						vaultKey:       vaultKey,
-					}
-				case 1: // just key
-					vaultFiles[key] = vaultFile{
-						filesystemPath: "",
-						vaultKey:       vaultKey,
-					}
-				}
-			// For all environment variables that don't have a known prefix, store
-			// them in our map of plain envvars so that we can ensure that they're
-			// set before exec'ing the wrapped process, even if they've been defined
-			// in the configuration file rather than the process environment.
-			default:
-				envPlain[key] = value
-			}
-		}
-
-		secretEnv := environment{}
-
-		for key := range keysToFetch {
-			path := path.Join(execVaultOptions.PathPrefix, key)
-
-			resp, err := client.Logical().Read(path)
-			if err != nil {
-				return errors.Wrap(err, "failed to retrieve secret value from Vault")
-			}
-
-			if resp == nil {
-				return errors.Errorf("no secret data found at Vault KV path: %s", path)
-			}
-
-			value := resp.Data["data"].(map[string]interface{})["data"].(string)
-			secretEnv[key] = value
-		}
-
 		// Set all our environment variables which will proxy through to our exec'd process
-		for key, value := range envPlain {
+		for key, value := range secrets.envPlain {
 			os.Setenv(key, value)
 		}
 
-		for key, value := range envFromVault {
+		for key, value := range secrets.envFromVault {
 			os.Setenv(key, secretEnv[value])
 		}
 
 		// For every 'vault file' defined in our configuration or environment variables, write
 		// the value out to the specified location on the filesystem, or a random path if not
 		// specified.
-		for key, file := range vaultFiles {
+		for key, file := range secrets.vaultFiles {
 			path := file.filesystemPath
 			if path == "" {
 				// generate file path prefixed by key
@@ -282,6 +181,84 @@ func mainError(ctx context.Context, command string) (err error) {
 			return errors.Wrap(err, "failed to execute wrapped program")
 		}
 
+	// Perform the same resolution as exec, including authenticating with Vault and reading
+	// every secret, but instead of exec'ing print a summary of where each secret would
+	// come from and whether we can read it. Secret values are never printed. This helps
+	// debug applications that fail to start because of their secrets configuration.
+	case plan.FullCommand():
+		vaultCtx, cancel := context.WithTimeout(ctx, planVaultOptions.Deadline)
+		defer cancel()
+
+		// Resolve each Vault key once, in the same way exec deduplicates reads
+		secrets, _, readErrors, err := planVaultOptions.ResolveEach(vaultCtx, logger, *planConfigFile, *planServiceAccountTokenFile)
+		if err != nil {
+			return err
+		}
+
+		statuses := map[string]string{}
+		for key := range secrets.keysToFetch {
+			if err, ok := readErrors[key]; ok {
+				// Vault errors span several lines, which would break our table
+				statuses[key] = fmt.Sprintf("error: %s", strings.Join(strings.Fields(err.Error()), " "))
+			} else {
+				statuses[key] = "ok"
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "ENV\tVAULT PATH\tFILE\tSTATUS")
+		for _, key := range secrets.SortedKeys() {
+			var vaultKey, filePath string
+			if file, ok := secrets.vaultFiles[key]; ok {
+				vaultKey, filePath = file.vaultKey, file.filesystemPath
+				if filePath == "" {
+					filePath = "<temporary file>"
+				}
+			} else {
+				vaultKey, filePath = secrets.envFromVault[key], "-"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				key, path.Join(planVaultOptions.PathPrefix, vaultKey), filePath, statuses[vaultKey])
+		}
+		w.Flush()
+
+		if len(readErrors) > 0 {
+			return errors.Errorf("failed to resolve %d of %d Vault paths", len(readErrors), len(secrets.keysToFetch))
+		}
+
+	// Resolve secrets in the same way as exec, but write each one to a file named after its
+	// environment variable in the output directory. This supports images where we can't
+	// wrap the entrypoint, such as distroless images, by running in a sidecar that shares
+	// an in-memory volume with the application.
+	case syncCmd.FullCommand():
+		// If we were given a token we must keep using it, otherwise we log in again on every
+		// refresh, as both our Vault token and the projected service account token expire.
+		staticToken := syncVaultOptions.Token != ""
+
+		for {
+			if !staticToken {
+				syncVaultOptions.Token = ""
+			}
+
+			err := syncSecretFiles(ctx, syncVaultOptions, *syncConfigFile, *syncServiceAccountTokenFile, *syncOutputDir)
+			if *syncOnce {
+				return err
+			}
+
+			// Keep serving the secrets we last wrote, in the hope Vault recovers before our
+			// next refresh.
+			if err != nil {
+				logger.Error(err, "failed to refresh secrets", "event", "secret_file.refresh_error")
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(*syncRefreshInterval):
+			}
+		}
+
 	default:
 		panic("unrecognised command")
 	}
@@ -289,6 +266,237 @@ func mainError(ctx context.Context, command string) (err error) {
 	return nil
 }
 
+// syncSecretFiles resolves secrets from Vault and writes them into the output directory,
+// bounded by the vault deadline.
+func syncSecretFiles(ctx context.Context, opts *vaultOptions, configFile, serviceAccountTokenFile, outputDir string) error {
+	vaultCtx, cancel := context.WithTimeout(ctx, opts.Deadline)
+	defer cancel()
+
+	secrets, secretEnv, err := opts.Resolve(vaultCtx, logger, configFile, serviceAccountTokenFile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create output directory")
+	}
+
+	// Paths given in vault-file references are relative to the application container,
+	// which we can't write to, so every secret is written to the output directory.
+	files := map[string]string{}
+	for key, vaultKey := range secrets.envFromVault {
+		files[key] = secretEnv[vaultKey]
+	}
+	for key, file := range secrets.vaultFiles {
+		files[key] = secretEnv[file.vaultKey]
+	}
+
+	for key, value := range files {
+		if err := writeSecretFile(filepath.Join(outputDir, key), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSecretFile atomically replaces the file at path with value, if it has changed.
+// Writing to a temporary file and renaming it into place ensures the application never
+// reads a partially written secret.
+func writeSecretFile(path, value string) error {
+	if existing, err := ioutil.ReadFile(path); err == nil && string(existing) == value {
+		return nil
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(path), fmt.Sprintf(".%s-*", filepath.Base(path)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file for %s", path)
+	}
+	defer os.Remove(tempFile.Name()) // no-op once renamed
+
+	if _, err := tempFile.WriteString(value); err != nil {
+		tempFile.Close()
+		return errors.Wrapf(err, "failed to write temporary file for %s", path)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write temporary file for %s", path)
+	}
+
+	// The application may run as a different user to us, so we ensure the file is
+	// readable by anyone in the pod, much as we do for the service account token.
+	if err := os.Chmod(tempFile.Name(), 0444); err != nil {
+		return errors.Wrapf(err, "failed to set permissions on %s", path)
+	}
+
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return errors.Wrapf(err, "failed to move secret file into place at %s", path)
+	}
+
+	logger.Info(
+		"wrote vault secret file",
+		"event", "secret_file.write",
+		"path", path,
+	)
+
+	return nil
+}
+
+// loadEnvironment builds the environment that theatre-secrets will resolve, starting
+// with the variables of the current process and overriding them with any values found in
+// the config file, if supplied.
+func loadEnvironment(configFile string) (environment, error) {
+	env := environment{}
+
+	// Load all the environment variables we currently know from our process
+	for _, element := range os.Environ() {
+		nameValue := strings.SplitN(element, "=", 2)
+		env[nameValue[0]] = nameValue[1]
+	}
+
+	if configFile != "" {
+		logger.Info(
+			fmt.Sprintf("loading config from %s", configFile),
+			"event", "config.load",
+			"file_path", configFile,
+		)
+
+		config, err := loadConfigFromFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+
+		// Load all the values from our config, which will now override what is set in the
+		// environment variables of the current process
+		for key, value := range config.Environment {
+			env[key] = value
+		}
+	}
+
+	return env, nil
+}
+
+// secretReferences is the result of parsing an environment for Vault references,
+// describing where each environment variable should take its value from.
+type secretReferences struct {
+	// Use a set to describe the keys that we need to pull from Vault,
+	// ensuring that API requests aren't repeated if environment variables or
+	// secret files use the same Vault key.
+	keysToFetch  map[string]bool
+	envPlain     environment
+	envFromVault environment
+	vaultFiles   map[string]vaultFile
+}
+
+// SortedKeys returns the names of all environment variables that reference Vault, in a
+// stable order.
+func (s secretReferences) SortedKeys() []string {
+	keys := []string{}
+	for key := range s.envFromVault {
+		keys = append(keys, key)
+	}
+	for key := range s.vaultFiles {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedKeys(errs map[string]error) []string {
+	keys := []string{}
+	for key := range errs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func parseSecretReferences(env environment) (secretReferences, error) {
+	s := secretReferences{
+		keysToFetch:  map[string]bool{},
+		envPlain:     environment{},
+		envFromVault: environment{},
+		vaultFiles:   map[string]vaultFile{},
+	}
+
+	for key, value := range env {
+		switch {
+		// For all the environment values that look like they should be vault
+		// references, store the envvar -> vault path mapping, and add the vault
+		// path to our list to pull.
+		case strings.HasPrefix(value, "vault:"):
+			vaultKey := strings.TrimPrefix(value, "vault:")
+
+			s.keysToFetch[vaultKey] = true
+			s.envFromVault[key] = vaultKey
+
+		// Support 'vault-file:' prefixed env vars.
+		//
+		// For reference, the expected formats are
+		// 'vault-file:tls-key/2021010100' and
+		// 'vault-file:ssh-key/2021010100:/home/user/.ssh/id_rsa'
+		case strings.HasPrefix(value, "vault-file:"):
+			trimmed := strings.TrimSpace(
+				strings.TrimPrefix(value, "vault-file:"),
+			)
+			if len(trimmed) == 0 {
+				return s, fmt.Errorf("empty vault-file env var: %v", value)
+			}
+
+			split := strings.SplitN(trimmed, ":", 2)
+			vaultKey := split[0]
+			s.keysToFetch[vaultKey] = true
+
+			// determine if we define a path at which to place the file. For SplitN,
+			// N=2 so we only have two cases
+			switch len(split) {
+			case 2: // path and key
+				s.vaultFiles[key] = vaultFile{
+					filesystemPath: split[1],
+					vaultKey:       vaultKey,
+				}
+			case 1: // just key
+				s.vaultFiles[key] = vaultFile{
+					filesystemPath: "",
+					vaultKey:       vaultKey,
+				}
+			}
+		// For all environment variables that don't have a known prefix, store
+		// them in our map of plain envvars so that we can ensure that they're
+		// set before exec'ing the wrapped process, even if they've been defined
+		// in the configuration file rather than the process environment.
+		default:
+			s.envPlain[key] = value
+		}
+	}
+
+	return s, nil
+}
+
+// secretValue extracts the secret material from a Vault KV read response, returning an
+// error if there was no secret at the path or it was not in the format we expect.
+func secretValue(resp *api.Secret) (string, error) {
+	if resp == nil {
+		return "", errors.New("no secret data found")
+	}
+
+	data, ok := resp.Data["data"].(map[string]interface{})
+	if !ok {
+		return "", errors.New("secret has no data field")
+	}
+
+	value, ok := data["data"].(string)
+	if !ok {
+		return "", errors.New("secret data field is not a string")
+	}
+
+	return value, nil
+}
+
 // getKubernetesToken attempts to construct a Kubernetes client configuration, preferring
 // in cluster auth but falling back to other detection methods if that fails.
 func getKubernetesToken(tokenFileOverride string) (string, error) {
@@ -350,6 +558,11 @@ type vaultOptions struct {
 	AuthBackendRole       string
 	PathPrefix            string
 	Timeout               time.Duration
+	Deadline              time.Duration
+	MaxRetries            int
+	RetryBackoff          time.Duration
+	RetryMaxBackoff       time.Duration
+	ReadConcurrency       int
 }
 
 func newVaultOptions(cmd *kingpin.CmdClause) *vaultOptions {
@@ -363,6 +576,11 @@ func newVaultOptions(cmd *kingpin.CmdClause) *vaultOptions {
 	cmd.Flag("vault-insecure-skip-verify", "Skip TLS certificate verification when connecting to Vault").Default("false").BoolVar(&opt.InsecureSkipVerify)
 	cmd.Flag("vault-path-prefix", "Path prefix to read Vault secret from").Default("").StringVar(&opt.PathPrefix)
 	cmd.Flag("vault-http-timeout", "Timeout in seconds when making requests to vault").Default("2s").DurationVar(&opt.Timeout)
+	cmd.Flag("vault-deadline", "Overall deadline for logging into vault and reading all secrets").Default("60s").DurationVar(&opt.Deadline)
+	cmd.Flag("vault-max-retries", "Number of times to retry a failed vault request").Default("5").IntVar(&opt.MaxRetries)
+	cmd.Flag("vault-retry-backoff", "Initial backoff between retries of vault requests, doubled after each attempt").Default("250ms").DurationVar(&opt.RetryBackoff)
+	cmd.Flag("vault-retry-max-backoff", "Maximum backoff between retries of vault requests").Default("5s").DurationVar(&opt.RetryMaxBackoff)
+	cmd.Flag("vault-read-concurrency", "Maximum number of secrets to read from vault in parallel").Default("8").IntVar(&opt.ReadConcurrency)
 
 	return opt
 }
@@ -376,6 +594,10 @@ func (o *vaultOptions) Client() (*api.Client, error) {
 	// significantly slow the container start time.
 	cfg.Timeout = o.Timeout
 
+	// We handle retries ourselves, so that every request is subject to the same backoff
+	// policy and overall deadline.
+	cfg.MaxRetries = 0
+
 	transport := cfg.HttpClient.Transport.(*http.Transport)
 	if o.InsecureSkipVerify {
 		transport.TLSClientConfig.InsecureSkipVerify = true
@@ -405,23 +627,54 @@ func (o *vaultOptions) Decorate(logger logr.Logger) logr.Logger {
 	)
 }
 
+// LoginIfRequired exchanges our Kubernetes service account token for a Vault token,
+// unless we've already been given a Vault token to use.
+func (o *vaultOptions) LoginIfRequired(ctx context.Context, logger logr.Logger, serviceAccountTokenFile string) error {
+	if o.Token != "" {
+		return nil
+	}
+
+	serviceAccountToken, err := getKubernetesToken(serviceAccountTokenFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to authenticate within kubernetes")
+	}
+
+	o.Decorate(logger).Info("logging into vault", "event", "vault.login")
+
+	vaultToken, err := o.Login(ctx, logger, serviceAccountToken)
+	if err != nil {
+		return errors.Wrap(err, "failed to login to vault")
+	}
+
+	o.Token = vaultToken
+
+	return nil
+}
+
 // Login uses the kubernetes service account token to authenticate against the Vault
 // server. The Vault server is configured with a specific authentication backend that can
 // validate the service account token we provide is valid. We are asking Vault to assign
 // us the specified role.
-func (o *vaultOptions) Login(jwt string) (string, error) {
+func (o *vaultOptions) Login(ctx context.Context, logger logr.Logger, jwt string) (string, error) {
 	client, err := o.Client()
 	if err != nil {
 		return "", err
 	}
 
-	req := client.NewRequest("POST", fmt.Sprintf("/v1/auth/%s/login", o.AuthBackendMountPoint))
-	req.SetJSONBody(map[string]string{
-		"jwt":  jwt,
-		"role": o.AuthBackendRole,
-	})
+	resp, err := o.withRetries(ctx, logger, func(ctx context.Context) (*api.Response, error) {
+		req := client.NewRequest("POST", fmt.Sprintf("/v1/auth/%s/login", o.AuthBackendMountPoint))
+		if err := req.SetJSONBody(map[string]string{
+			"jwt":  jwt,
+			"role": o.AuthBackendRole,
+		}); err != nil {
+			return nil, err
+		}
 
-	resp, err := client.RawRequest(req)
+		return client.RawRequestWithContext(ctx, req)
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
 	if err != nil {
 		return "", errors.Wrap(err, "failed to perform login POST request against Vault auth backend mount")
 	}
@@ -438,6 +691,173 @@ func (o *vaultOptions) Login(jwt string) (string, error) {
 	return secret.Auth.ClientToken, nil
 }
 
+// Resolve logs into Vault if required, determines the secrets referenced by the
+// environment and config file, and reads them. It fails if any secret can't be read.
+func (o *vaultOptions) Resolve(ctx context.Context, logger logr.Logger, configFile, serviceAccountTokenFile string) (secretReferences, environment, error) {
+	secrets, secretEnv, readErrors, err := o.ResolveEach(ctx, logger, configFile, serviceAccountTokenFile)
+	if err != nil {
+		return secretReferences{}, nil, err
+	}
+
+	var readErr error
+	for _, key := range sortedKeys(readErrors) {
+		readErr = multierror.Append(readErr, errors.Wrapf(
+			readErrors[key], "failed to retrieve secret value from Vault KV path: %s",
+			path.Join(o.PathPrefix, key),
+		))
+	}
+
+	if readErr != nil {
+		return secretReferences{}, nil, readErr
+	}
+
+	return secrets, secretEnv, nil
+}
+
+// ResolveEach is Resolve, except that failing to read a secret is not an error. Instead
+// it returns the values it could read, and the error for each Vault key it could not.
+func (o *vaultOptions) ResolveEach(ctx context.Context, logger logr.Logger, configFile, serviceAccountTokenFile string) (secretReferences, environment, map[string]error, error) {
+	if err := o.LoginIfRequired(ctx, logger, serviceAccountTokenFile); err != nil {
+		return secretReferences{}, nil, nil, err
+	}
+
+	client, err := o.Client()
+	if err != nil {
+		return secretReferences{}, nil, nil, err
+	}
+
+	env, err := loadEnvironment(configFile)
+	if err != nil {
+		return secretReferences{}, nil, nil, err
+	}
+
+	secrets, err := parseSecretReferences(env)
+	if err != nil {
+		return secretReferences{}, nil, nil, err
+	}
+
+	secretEnv, readErrors := o.ReadSecrets(ctx, logger, client, secrets.keysToFetch)
+
+	return secrets, secretEnv, readErrors, nil
+}
+
+// ReadSecrets reads the given keys from Vault, relative to our path prefix, with at most
+// ReadConcurrency requests in flight. It returns the values it could read, and the errors
+// for any keys it could not.
+func (o *vaultOptions) ReadSecrets(ctx context.Context, logger logr.Logger, client *api.Client, keys map[string]bool) (environment, map[string]error) {
+	concurrency := o.ReadConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		values    = environment{}
+		errs      = map[string]error{}
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, concurrency)
+	)
+
+	for key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			resp, err := o.Read(ctx, logger, client, path.Join(o.PathPrefix, key))
+			var value string
+			if err == nil {
+				value, err = secretValue(resp)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs[key] = err
+			} else {
+				values[key] = value
+			}
+		}(key)
+	}
+
+	wg.Wait()
+
+	return values, errs
+}
+
+// Read fetches a secret from the given Vault path, retrying according to our retry
+// policy. Much like the Vault client, a path that does not exist returns a nil secret.
+func (o *vaultOptions) Read(ctx context.Context, logger logr.Logger, client *api.Client, path string) (*api.Secret, error) {
+	resp, err := o.withRetries(ctx, logger.WithValues("path", path), func(ctx context.Context) (*api.Response, error) {
+		return client.RawRequestWithContext(ctx, client.NewRequest("GET", "/v1/"+path))
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return api.ParseSecret(resp.Body)
+}
+
+// withRetries calls the given Vault request until it succeeds, fails with an error that
+// retrying won't fix, or we exhaust our retries or deadline. Between attempts we back off
+// exponentially, with jitter to avoid many pods hitting Vault in lockstep after an outage.
+func (o *vaultOptions) withRetries(ctx context.Context, logger logr.Logger, request func(context.Context) (*api.Response, error)) (*api.Response, error) {
+	backoff := wait.Backoff{
+		Duration: o.RetryBackoff,
+		Factor:   2.0,
+		Jitter:   0.5,
+		Steps:    o.MaxRetries,
+		Cap:      o.RetryMaxBackoff,
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := request(ctx)
+		if err == nil || !isRetryable(resp) || attempt > o.MaxRetries {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		delay := backoff.Step()
+		logger.Info(
+			"vault request failed, retrying",
+			"event", "vault.retry",
+			"attempt", attempt,
+			"delay", delay.Seconds(),
+			"error", err.Error(),
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(err, "giving up on vault request after %d attempts (%v)", attempt, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isRetryable decides if a failed request is worth retrying. Errors without a response
+// are network failures or timeouts, which are often transient, as are server errors and
+// rate limiting. Anything else, such as permission denied, will fail again.
+func isRetryable(resp *api.Response) bool {
+	if resp == nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
 // Config is the configuration file format that the exec command will use to parse the
 // Vault references that define where to pull secret material from. We expect application
 // developers to include this file within their applications.
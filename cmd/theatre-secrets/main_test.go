@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseSecretReferences", func() {
+	DescribeTable("Parses references to Vault from the environment",
+		func(env environment, expected secretReferences) {
+			refs, err := parseSecretReferences(env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(refs).To(Equal(expected))
+		},
+		Entry("plain values",
+			environment{"PORT": "8080"},
+			secretReferences{
+				keysToFetch:  map[string]bool{},
+				envPlain:     environment{"PORT": "8080"},
+				envFromVault: environment{},
+				vaultFiles:   map[string]vaultFile{},
+			},
+		),
+		Entry("vault references",
+			environment{"DATABASE_URL": "vault:app/database-url"},
+			secretReferences{
+				keysToFetch:  map[string]bool{"app/database-url": true},
+				envPlain:     environment{},
+				envFromVault: environment{"DATABASE_URL": "app/database-url"},
+				vaultFiles:   map[string]vaultFile{},
+			},
+		),
+		Entry("vault-file references without a path",
+			environment{"TLS_KEY": "vault-file:tls-key/2021010100"},
+			secretReferences{
+				keysToFetch:  map[string]bool{"tls-key/2021010100": true},
+				envPlain:     environment{},
+				envFromVault: environment{},
+				vaultFiles:   map[string]vaultFile{"TLS_KEY": {vaultKey: "tls-key/2021010100"}},
+			},
+		),
+		Entry("vault-file references with a path",
+			environment{"SSH_KEY": "vault-file:ssh-key/2021010100:/home/user/.ssh/id_rsa"},
+			secretReferences{
+				keysToFetch:  map[string]bool{"ssh-key/2021010100": true},
+				envPlain:     environment{},
+				envFromVault: environment{},
+				vaultFiles: map[string]vaultFile{
+					"SSH_KEY": {vaultKey: "ssh-key/2021010100", filesystemPath: "/home/user/.ssh/id_rsa"},
+				},
+			},
+		),
+		Entry("references that share a Vault key",
+			environment{"DATABASE_URL": "vault:app/database", "DATABASE_FILE": "vault-file:app/database"},
+			secretReferences{
+				keysToFetch:  map[string]bool{"app/database": true},
+				envPlain:     environment{},
+				envFromVault: environment{"DATABASE_URL": "app/database"},
+				vaultFiles:   map[string]vaultFile{"DATABASE_FILE": {vaultKey: "app/database"}},
+			},
+		),
+	)
+
+	DescribeTable("Rejects bad references",
+		func(env environment) {
+			_, err := parseSecretReferences(env)
+			Expect(err).To(MatchError(ContainSubstring("empty vault-file env var")))
+		},
+		Entry("empty vault-file reference", environment{"TLS_KEY": "vault-file:"}),
+		Entry("blank vault-file reference", environment{"TLS_KEY": "vault-file:   "}),
+	)
+})
+
+var _ = Describe("isRetryable", func() {
+	DescribeTable("Classifies failed Vault requests",
+		func(resp *api.Response, expected bool) {
+			Expect(isRetryable(resp)).To(Equal(expected))
+		},
+		Entry("no response", nil, true),
+		Entry("rate limited", &api.Response{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}, true),
+		Entry("internal server error", &api.Response{Response: &http.Response{StatusCode: http.StatusInternalServerError}}, true),
+		Entry("service unavailable", &api.Response{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}, true),
+		Entry("bad request", &api.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, false),
+		Entry("permission denied", &api.Response{Response: &http.Response{StatusCode: http.StatusForbidden}}, false),
+		Entry("not found", &api.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, false),
+	)
+})
+
+// fakeVault serves KV reads, answering each request with the status returned by
+// respond. A zero status hangs until the client gives up on the request.
+type fakeVault struct {
+	*httptest.Server
+
+	respond func(attempt int) int
+
+	mu        sync.Mutex
+	attempts  map[string]int
+	inFlight  int32
+	maxFlight int32
+}
+
+func newFakeVault(respond func(attempt int) int) *fakeVault {
+	v := &fakeVault{respond: respond, attempts: map[string]int{}}
+	v.Server = httptest.NewServer(http.HandlerFunc(v.serveHTTP))
+
+	return v
+}
+
+func (v *fakeVault) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	inFlight := atomic.AddInt32(&v.inFlight, 1)
+	defer atomic.AddInt32(&v.inFlight, -1)
+
+	for {
+		max := atomic.LoadInt32(&v.maxFlight)
+		if inFlight <= max || atomic.CompareAndSwapInt32(&v.maxFlight, max, inFlight) {
+			break
+		}
+	}
+
+	v.mu.Lock()
+	v.attempts[r.URL.Path]++
+	attempt := v.attempts[r.URL.Path]
+	v.mu.Unlock()
+
+	status := v.respond(attempt)
+	if status == 0 {
+		<-r.Context().Done()
+		return
+	}
+
+	// Hold each request briefly, so that concurrent reads overlap
+	time.Sleep(10 * time.Millisecond)
+
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		key := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		fmt.Fprintf(w, `{"data":{"data":{"data":"value-of-%s"}}}`, key)
+	}
+}
+
+func (v *fakeVault) Attempts(key string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.attempts["/v1/secret/data/"+key]
+}
+
+var _ = Describe("Reading secrets from Vault", func() {
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		logger  logr.Logger
+		vault   *fakeVault
+		opts    *vaultOptions
+		respond func(attempt int) int
+		keys    map[string]bool
+		values  environment
+		errs    map[string]error
+		elapsed time.Duration
+	)
+
+	BeforeEach(func() {
+		logger = zap.LoggerTo(GinkgoWriter, true)
+		respond = func(int) int { return http.StatusOK }
+		keys = map[string]bool{"app/database-url": true}
+
+		opts = &vaultOptions{
+			Token:           "vault-token",
+			PathPrefix:      "secret/data",
+			Timeout:         100 * time.Millisecond,
+			Deadline:        5 * time.Second,
+			MaxRetries:      3,
+			RetryBackoff:    time.Millisecond,
+			RetryMaxBackoff: 5 * time.Millisecond,
+			ReadConcurrency: 8,
+		}
+	})
+
+	JustBeforeEach(func() {
+		vault = newFakeVault(func(attempt int) int { return respond(attempt) })
+		opts.Address = vault.URL
+
+		// The deadline applies across all reads, as it does for each command
+		ctx, cancel = context.WithTimeout(context.Background(), opts.Deadline)
+
+		client, err := opts.Client()
+		Expect(err).NotTo(HaveOccurred())
+
+		start := time.Now()
+		values, errs = opts.ReadSecrets(ctx, logger, client, keys)
+		elapsed = time.Since(start)
+	})
+
+	AfterEach(func() {
+		cancel()
+		vault.Close()
+	})
+
+	It("Reads each secret", func() {
+		Expect(errs).To(BeEmpty())
+		Expect(values).To(Equal(environment{"app/database-url": "value-of-app/database-url"}))
+		Expect(vault.Attempts("app/database-url")).To(Equal(1))
+	})
+
+	Context("When Vault fails and then recovers", func() {
+		BeforeEach(func() {
+			respond = func(attempt int) int {
+				if attempt < 3 {
+					return http.StatusServiceUnavailable
+				}
+
+				return http.StatusOK
+			}
+		})
+
+		It("Retries until the read succeeds", func() {
+			Expect(errs).To(BeEmpty())
+			Expect(values).To(HaveKeyWithValue("app/database-url", "value-of-app/database-url"))
+			Expect(vault.Attempts("app/database-url")).To(Equal(3))
+		})
+	})
+
+	Context("When a request hangs", func() {
+		BeforeEach(func() {
+			respond = func(attempt int) int {
+				if attempt == 1 {
+					return 0
+				}
+
+				return http.StatusOK
+			}
+		})
+
+		It("Times out the request and retries", func() {
+			Expect(errs).To(BeEmpty())
+			Expect(values).To(HaveKeyWithValue("app/database-url", "value-of-app/database-url"))
+			Expect(vault.Attempts("app/database-url")).To(Equal(2))
+		})
+	})
+
+	Context("When Vault keeps failing", func() {
+		BeforeEach(func() {
+			respond = func(int) int { return http.StatusInternalServerError }
+		})
+
+		It("Gives up after the maximum number of retries", func() {
+			Expect(values).To(BeEmpty())
+			Expect(errs).To(HaveKey("app/database-url"))
+			Expect(vault.Attempts("app/database-url")).To(Equal(opts.MaxRetries + 1))
+		})
+	})
+
+	Context("When Vault fails with an error that retrying won't fix", func() {
+		BeforeEach(func() {
+			respond = func(int) int { return http.StatusForbidden }
+		})
+
+		It("Doesn't retry", func() {
+			Expect(errs).To(HaveKey("app/database-url"))
+			Expect(vault.Attempts("app/database-url")).To(Equal(1))
+		})
+	})
+
+	Context("When requests hang past the deadline", func() {
+		BeforeEach(func() {
+			respond = func(int) int { return 0 }
+			opts.Deadline = 250 * time.Millisecond
+			opts.MaxRetries = 100
+		})
+
+		It("Stops retrying at the deadline", func() {
+			Expect(errs).To(HaveKey("app/database-url"))
+			Expect(errs["app/database-url"].Error()).To(ContainSubstring("giving up on vault request"))
+			Expect(elapsed).To(BeNumerically("<", opts.Deadline+opts.Timeout+100*time.Millisecond))
+			Expect(vault.Attempts("app/database-url")).To(BeNumerically("<", opts.MaxRetries))
+		})
+	})
+
+	Context("When reading many secrets", func() {
+		BeforeEach(func() {
+			opts.ReadConcurrency = 3
+
+			keys = map[string]bool{}
+			for idx := 0; idx < 20; idx++ {
+				keys[fmt.Sprintf("app/secret-%d", idx)] = true
+			}
+		})
+
+		It("Reads them all", func() {
+			Expect(errs).To(BeEmpty())
+			Expect(values).To(HaveLen(20))
+		})
+
+		It("Keeps no more than the read concurrency in flight", func() {
+			Expect(atomic.LoadInt32(&vault.maxFlight)).To(BeNumerically("<=", 3))
+			Expect(atomic.LoadInt32(&vault.maxFlight)).To(BeNumerically(">", 1))
+		})
+	})
+})
@@ -46,15 +46,17 @@ func (r *Runner) Name() string {
 //
 // It does several things:
 //
-// - Mounts a kv2 secrets engine at secret/
-// - Creates a Kubernetes auth backend mounted at auth/kubernetes
-// - Configures the Kubernetes backend to authenticate against the currently detected
-//   Kubernetes API server (the current cluster, if run from within)
-// - For all successful Kubernetes logins, the user is assigned a token that maps to a
-//   cluster-reader policy, which permits reading of secrets from:
+//   - Mounts a kv2 secrets engine at secret/
 //
-//   secret/data/kubernetes/{namespace}/{service-account-name}/*
+//   - Creates a Kubernetes auth backend mounted at auth/kubernetes
 //
+//   - Configures the Kubernetes backend to authenticate against the currently detected
+//     Kubernetes API server (the current cluster, if run from within)
+//
+//   - For all successful Kubernetes logins, the user is assigned a token that maps to a
+//     cluster-reader policy, which permits reading of secrets from:
+//
+//     secret/data/kubernetes/{namespace}/{service-account-name}/*
 func (r *Runner) Prepare(logger kitlog.Logger, config *rest.Config) error {
 	cfg := api.DefaultConfig()
 	cfg.Address = "http://localhost:8200"
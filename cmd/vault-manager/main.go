@@ -13,6 +13,7 @@ import (
 	vaultv1alpha1 "github.com/gocardless/theatre/v2/apis/vault/v1alpha1"
 	"github.com/gocardless/theatre/v2/cmd"
 	"github.com/gocardless/theatre/v2/pkg/signals"
+	"github.com/gocardless/theatre/v2/pkg/webhook"
 )
 
 var (
@@ -20,14 +21,16 @@ var (
 
 	commonOpts = cmd.NewCommonOptions(app).WithMetrics(app)
 
-	namespace               = app.Flag("namespace", "Kubernetes webhook service namespace").Default("theatre-system").String()
-	serviceName             = app.Flag("service-name", "Name of service for webhook").Default("theatre-vault-manager").String()
-	webhookName             = app.Flag("webhook-name", "Name of webhook").Default("theatre-vault").String()
-	theatreImage            = app.Flag("theatre-image", "Set to the same image as current binary").Required().String()
-	installPath             = app.Flag("install-path", "Location to install theatre binaries").Default("/var/run/theatre").String()
-	namespaceLabel          = app.Flag("namespace-label", "Namespace label that enables webhook to operate on").Default("theatre-secrets-injector").String()
-	vaultConfigMapName      = app.Flag("vault-configmap-name", "Vault configMap name containing vault configuration").Default("vault-config").String()
-	vaultConfigMapNamespace = app.Flag("vault-configmap-namespace", "Namespace of vault configMap").Default("vault-system").String()
+	namespace                   = app.Flag("namespace", "Kubernetes webhook service namespace").Default("theatre-system").String()
+	serviceName                 = app.Flag("service-name", "Name of service for webhook").Default("theatre-vault-manager").String()
+	webhookName                 = app.Flag("webhook-name", "Name of webhook").Default("theatre-vault").String()
+	theatreImage                = app.Flag("theatre-image", "Set to the same image as current binary").Required().String()
+	installPath                 = app.Flag("install-path", "Location to install theatre binaries").Default("/var/run/theatre").String()
+	secretsPath                 = app.Flag("secrets-path", "Location to mount secret files, for pods using the sidecar injection mode").Default("/var/run/secrets/theatre").String()
+	namespaceLabel              = app.Flag("namespace-label", "Namespace label that enables webhook to operate on").Default("theatre-secrets-injector").String()
+	vaultConfigMapName          = app.Flag("vault-configmap-name", "Vault configMap name containing vault configuration").Default("vault-config").String()
+	vaultConfigMapNamespace     = app.Flag("vault-configmap-namespace", "Namespace of vault configMap").Default("vault-system").String()
+	namespaceVaultConfigMapName = app.Flag("namespace-vault-configmap-name", "Name of the optional configMap in each pod's namespace that overrides the vault configMap (empty to disable)").Default("vault-config").String()
 
 	// These configuration parameters alter how the injector mounts service account tokens.
 	// We expect tokens to be sent to Vault, outside of the Kubernetes cluster, so we ensure
@@ -68,16 +71,23 @@ func main() {
 			Namespace: *vaultConfigMapNamespace,
 			Name:      *vaultConfigMapName,
 		},
+		NamespaceVaultConfigMapName: *namespaceVaultConfigMapName,
 		ServiceAccountTokenFile:     *serviceAccountTokenFile,
 		ServiceAccountTokenExpiry:   *serviceAccountTokenExpiry,
 		ServiceAccountTokenAudience: *serviceAccountTokenAudience,
+		SecretsPath:                 *secretsPath,
 	}
 
 	mgr.GetWebhookServer().Register("/mutate-pods", &admission.Webhook{
-		Handler: vaultv1alpha1.NewSecretsInjector(
-			mgr.GetClient(),
-			logger.WithName("webhooks").WithName("secrets-injector"),
-			injectorOpts,
+		Handler: webhook.Instrument(
+			"secrets-injector",
+			logger.WithName("webhooks"),
+			vaultv1alpha1.NewSecretsInjector(
+				mgr.GetClient(),
+				mgr.GetAPIReader(),
+				logger.WithName("webhooks").WithName("secrets-injector"),
+				injectorOpts,
+			),
 		),
 	})
 
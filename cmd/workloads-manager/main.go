@@ -15,7 +15,9 @@ import (
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
 	"github.com/gocardless/theatre/v2/cmd"
 	consolecontroller "github.com/gocardless/theatre/v2/controllers/workloads/console"
+	scheduledconsolecontroller "github.com/gocardless/theatre/v2/controllers/workloads/scheduledconsole"
 	"github.com/gocardless/theatre/v2/pkg/signals"
+	"github.com/gocardless/theatre/v2/pkg/webhook"
 )
 
 var (
@@ -24,6 +26,13 @@ var (
 	app = kingpin.New("workloads-manager", "Manages workloads.crd.gocardless.com resources").Version(cmd.VersionStanza())
 
 	commonOpts = cmd.NewCommonOptions(app).WithMetrics(app)
+
+	priorityOverrideClasses = app.Flag("priority-class-override", "Priority class that pods may choose for themselves, rather than having one assigned by the priority injector. Can be given multiple times").
+				Strings()
+	consoleGCInterval           = app.Flag("console-gc-interval", "Interval between scans for consoles to garbage collect").Default(consolecontroller.DefaultGCInterval.String()).Duration()
+	consoleGCDeletionsPerSecond = app.Flag("console-gc-deletions-per-second", "Maximum rate at which expired consoles are deleted").Default(fmt.Sprint(consolecontroller.DefaultGCDeletionsPerSecond)).Float32()
+	managerUsername             = app.Flag("manager-username", "Username that the manager authenticates as, whose consoles are created on behalf of the user they name").
+					Default("system:serviceaccount:theatre-system:theatre-workloads-manager").String()
 )
 
 func init() {
@@ -36,6 +45,13 @@ func main() {
 	kingpin.MustParse(app.Parse(os.Args[1:]))
 	logger := commonOpts.Logger()
 
+	if *consoleGCInterval <= 0 {
+		app.Fatalf("--console-gc-interval must be greater than zero")
+	}
+	if *consoleGCDeletionsPerSecond <= 0 {
+		app.Fatalf("--console-gc-deletions-per-second must be greater than zero")
+	}
+
 	ctx, cancel := signals.SetupSignalHandler()
 	defer cancel()
 
@@ -50,42 +66,146 @@ func main() {
 		app.Fatalf("failed to create manager: %v", err)
 	}
 
+	// The controller copies files into and out of consoles on behalf of users
+	exec, err := consolecontroller.NewPodExecutor(mgr.GetConfig())
+	if err != nil {
+		app.Fatalf("failed to create pod executor: %v", err)
+	}
+
 	// controller
 	if err = (&consolecontroller.ConsoleReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("console"),
 		Scheme: mgr.GetScheme(),
+		Exec:   exec,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		app.Fatalf("failed to create controller: %v", err)
 	}
 
+	// scheduled console controller
+	if err = (&scheduledconsolecontroller.ScheduledConsoleReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("scheduled-console"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(ctx, mgr); err != nil {
+		app.Fatalf("failed to create scheduled console controller: %v", err)
+	}
+
+	// console garbage collector
+	if err = mgr.Add(&consolecontroller.ConsoleGarbageCollector{
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("console-gc"),
+		Interval:           *consoleGCInterval,
+		DeletionsPerSecond: *consoleGCDeletionsPerSecond,
+	}); err != nil {
+		app.Fatalf("failed to create console garbage collector: %v", err)
+	}
+
 	// console authenticator webhook
 	mgr.GetWebhookServer().Register("/mutate-consoles", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewConsoleAuthenticatorWebhook(
-			logger.WithName("webhooks").WithName("console-authenticator"),
+		Handler: webhook.Instrument(
+			"console-authenticator",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleAuthenticatorWebhook(
+				logger.WithName("webhooks").WithName("console-authenticator"),
+				*managerUsername,
+			),
 		),
 	})
 
 	// console authorisation webhook
 	mgr.GetWebhookServer().Register("/validate-consoleauthorisations", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewConsoleAuthorisationWebhook(
-			mgr.GetClient(),
-			logger.WithName("webhooks").WithName("console-authorisation"),
+		Handler: webhook.Instrument(
+			"console-authorisation",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleAuthorisationWebhook(
+				mgr.GetClient(),
+				logger.WithName("webhooks").WithName("console-authorisation"),
+			),
+		),
+	})
+
+	// console transfer log webhook
+	mgr.GetWebhookServer().Register("/mutate-consoletransferlogs", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"console-transfer-log",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleTransferLogWebhook(
+				mgr.GetClient(),
+				logger.WithName("webhooks").WithName("console-transfer-log"),
+			),
+		),
+	})
+
+	// console activity webhook
+	mgr.GetWebhookServer().Register("/mutate-consoleactivities", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"console-activity",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleActivityWebhook(
+				logger.WithName("webhooks").WithName("console-activity"),
+			),
+		),
+	})
+
+	// console deletion webhook
+	mgr.GetWebhookServer().Register("/validate-consoles-delete", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"console-deletion",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleDeletionWebhook(
+				mgr.GetClient(),
+				logger.WithName("webhooks").WithName("console-deletion"),
+			),
 		),
 	})
 
 	// console template webhook
 	mgr.GetWebhookServer().Register("/validate-consoletemplates", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewConsoleTemplateValidationWebhook(
-			logger.WithName("webhooks").WithName("console-template"),
+		Handler: webhook.Instrument(
+			"console-template",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleTemplateValidationWebhook(
+				mgr.GetClient(),
+				logger.WithName("webhooks").WithName("console-template"),
+			),
+		),
+	})
+
+	// scheduled console webhook
+	mgr.GetWebhookServer().Register("/mutate-scheduledconsoles", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"scheduled-console",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewScheduledConsoleWebhook(
+				logger.WithName("webhooks").WithName("scheduled-console"),
+			),
+		),
+	})
+
+	// scheduled console authorisation webhook
+	mgr.GetWebhookServer().Register("/mutate-scheduledconsoleauthorisations", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"scheduled-console-authorisation",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewScheduledConsoleAuthorisationWebhook(
+				mgr.GetClient(),
+				logger.WithName("webhooks").WithName("scheduled-console-authorisation"),
+			),
 		),
 	})
 
 	// priority webhook
 	mgr.GetWebhookServer().Register("/mutate-pods", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewPriorityInjector(
-			mgr.GetClient(),
-			logger.WithName("webhooks").WithName("priority-injector"),
+		Handler: webhook.Instrument(
+			"priority-injector",
+			logger.WithName("webhooks"),
+			workloadsv1alpha1.NewPriorityInjector(
+				mgr.GetClient(),
+				mgr.GetEventRecorderFor("priority-injector"),
+				logger.WithName("webhooks").WithName("priority-injector"),
+				*priorityOverrideClasses,
+			),
 		),
 	})
 
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -14,6 +16,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -47,6 +50,7 @@ const (
 	EventUnknownOutcome       = "UnknownOutcome"
 	EventInvalidSpecification = "InvalidSpecification"
 	EventTemplateUnsupported  = "TemplateUnsupported"
+	EventSecurityProfile      = "SecurityProfileViolation"
 
 	// Console log keys
 
@@ -55,16 +59,43 @@ const (
 	ConsoleStarted              = "ConsoleStarted"
 	ConsoleEnded                = "ConsoleEnded"
 	ConsoleDestroyed            = "ConsoleDestroyed"
+	ConsoleTerminating          = "ConsoleTerminating"
+	ConsolePodFailing           = "ConsolePodFailing"
+	ConsoleParticipantJoined    = "ConsoleParticipantJoined"
+	ConsoleParticipantLeft      = "ConsoleParticipantLeft"
+	ConsoleFileTransferred      = "ConsoleFileTransferred"
+	ConsoleIdle                 = "ConsoleIdle"
+	ConsoleScriptRejected       = "ConsoleScriptRejected"
+
+	// Reasons given with ConsoleEnded, for why the console ended
+
+	EndReasonCompleted     = "completed"
+	EndReasonExpired       = "expired"
+	EndReasonIdle          = "idle"
+	EndReasonFailedToStart = "failed-to-start"
+	EndReasonUnauthorised  = "unauthorised"
+	EndReasonUnknown       = "unknown"
 
 	Job                  = "job"
+	Pod                  = "pod"
 	Console              = "console"
 	ConsoleAuthorisation = "consoleauthorisation"
+	ConsoleShare         = "consoleshare"
+	ConsoleTransferLog   = "consoletransferlog"
+	ConsoleActivity      = "consoleactivity"
+	ConfigMap            = "configmap"
 	ConsoleTemplate      = "consoletemplate"
 	Role                 = "role"
 	DirectoryRoleBinding = "directoryrolebinding"
 
+	scriptVolumeName = "console-script"
+
 	DefaultTTLBeforeRunning = 1 * time.Hour
 	DefaultTTLAfterFinished = 24 * time.Hour
+
+	// How long past the end of its grace period we wait for the pod of a deleted
+	// console to be reported as stopped, before giving up on it
+	PodStopTimeout = 30 * time.Second
 )
 
 type IgnoreCreatePredicate struct {
@@ -79,6 +110,7 @@ type ConsoleReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	Exec   PodExecutor
 }
 
 func (r *ConsoleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
@@ -99,6 +131,30 @@ func (r *ConsoleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manag
 			// authorisation object.
 			builder.WithPredicates(IgnoreCreatePredicate{}),
 		).
+		Watches(
+			&source.Kind{Type: &workloadsv1alpha1.ConsoleShare{}},
+			&handler.EnqueueRequestForOwner{
+				IsController: true,
+				OwnerType:    &workloadsv1alpha1.Console{},
+			},
+			builder.WithPredicates(IgnoreCreatePredicate{}),
+		).
+		Watches(
+			&source.Kind{Type: &workloadsv1alpha1.ConsoleTransferLog{}},
+			&handler.EnqueueRequestForOwner{
+				IsController: true,
+				OwnerType:    &workloadsv1alpha1.Console{},
+			},
+			builder.WithPredicates(IgnoreCreatePredicate{}),
+		).
+		Watches(
+			&source.Kind{Type: &workloadsv1alpha1.ConsoleActivity{}},
+			&handler.EnqueueRequestForOwner{
+				IsController: true,
+				OwnerType:    &workloadsv1alpha1.Console{},
+			},
+			builder.WithPredicates(IgnoreCreatePredicate{}),
+		).
 		Watches(
 			&source.Kind{Type: &batchv1.Job{}},
 			&handler.EnqueueRequestForOwner{
@@ -107,8 +163,14 @@ func (r *ConsoleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manag
 			},
 		).
 		Complete(
-			recutil.ResolveAndReconcile(
+			recutil.ResolveAndReconcileWithFinalizer(
 				ctx, logger, mgr, &workloadsv1alpha1.Console{},
+				&recutil.Finalizer{
+					Name: workloadsv1alpha1.ConsoleFinalizer,
+					Finalize: func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error) {
+						return r.Finalize(logger, ctx, request, obj.(*workloadsv1alpha1.Console))
+					},
+				},
 				func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error) {
 					return r.Reconcile(logger, ctx, request, obj.(*workloadsv1alpha1.Console))
 				},
@@ -172,7 +234,15 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		}
 
 		authRule = &rule
-		if err := r.createAuthorisationObjects(ctx, logger, csl, req.NamespacedName, authRule.Subjects); err != nil {
+
+		// Consoles created by a scheduled console may have been approved ahead of
+		// time, in which case they start out with those approvals
+		preApproved, err := r.getPreApprovedSubjects(ctx, csl)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to determine pre-approvals for console")
+		}
+
+		if err := r.createAuthorisationObjects(ctx, logger, csl, req.NamespacedName, authRule.Subjects, preApproved); err != nil {
 			return ctrl.Result{}, err
 		}
 
@@ -191,14 +261,92 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 	// creation or when a job already exists, i.e. if we've already passed the
 	// Creating phase, but the job no longer exists (it's been destroyed external
 	// to this controller) then don't recreate it.
-	authorised := isConsoleAuthorised(authRule, authorisation)
-	if (authorised && csl.PendingJob()) || job != nil {
-		job = r.buildJob(logger, req.NamespacedName, csl, tpl)
+	authorised := isConsoleAuthorised(csl, authRule, authorisation)
+	if !csl.Failed() && ((authorised && csl.PendingJob()) || job != nil) {
+		if csl.HasScript() {
+			err := r.createScriptConfigMap(ctx, logger, csl, req.NamespacedName)
+			if err == errScriptChecksumMismatch {
+				statusCtx := consoleStatusContext{
+					Command:           command,
+					IsAuthorised:      authorised,
+					Authorisation:     authorisation,
+					AuthorisationRule: authRule,
+					Template:          tpl,
+				}
+
+				checksum := sha256.Sum256([]byte(csl.Spec.Script))
+				getAuditLogger(logger, csl, statusCtx).Info(
+					"Refusing to run console script that was changed after the console was created",
+					"event", ConsoleScriptRejected,
+					"script_actual_sha256", hex.EncodeToString(checksum[:]),
+				)
+
+				return ctrl.Result{}, nil
+			} else if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		expectedJob := r.buildJob(logger, req.NamespacedName, csl, tpl)
+
+		// The pod template of a job can't be changed, so the security profiles can
+		// only be enforced on jobs that we're yet to create
+		if job == nil {
+			if err := r.applySecurityProfiles(ctx, logger, tpl, expectedJob); err != nil {
+				return ctrl.Result{}, err
+			}
+		} else if deadline := idleDeadlineSeconds(csl, job); deadline != nil && *deadline < *expectedJob.Spec.ActiveDeadlineSeconds {
+			// Bringing the job's deadline forward to when the console became idle has
+			// the job controller stop it, just as it would once the console expired
+			expectedJob.Spec.ActiveDeadlineSeconds = deadline
+		}
+
+		job = expectedJob
 		if err := r.createOrUpdate(ctx, logger, csl, job, Job, jobDiff); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
+	// The share object only exists once the console is running, and lists any
+	// participants that the owner has invited to join it
+	var participants []workloadsv1alpha1.ConsoleParticipant
+	share, err := r.getConsoleShare(ctx, req.NamespacedName)
+	if err == nil {
+		participants = share.Spec.Participants
+	} else if !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, errors.Wrap(err, "failed to retrieve console share")
+	}
+
+	// The transfer log also only exists once the console is running, and records
+	// files to copy into and out of the console. We carry out the transfers
+	// ourselves, then audit them.
+	var (
+		transfers       []workloadsv1alpha1.ConsoleFileTransfer
+		transferResults []workloadsv1alpha1.ConsoleFileTransferResult
+		transferRequeue time.Duration
+	)
+	transferLog, err := r.getConsoleTransferLog(ctx, req.NamespacedName)
+	if err == nil {
+		transferRequeue, err = r.performTransfers(ctx, logger, csl, tpl, transferLog)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		transfers = transferLog.Spec.Transfers
+		transferResults = transferLog.Status.Results
+	} else if !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, errors.Wrap(err, "failed to retrieve console transfer log")
+	}
+
+	// The activity object also only exists once the console is running, and only
+	// if the template stops idle consoles
+	activity, err := r.getConsoleActivity(ctx, req.NamespacedName)
+	if apierrors.IsNotFound(err) {
+		activity = nil
+	} else if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to retrieve console activity")
+	}
+
 	// Update the status fields in case they're out of sync, or the console spec
 	// has been updated
 	statusCtx := consoleStatusContext{
@@ -207,6 +355,11 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		Authorisation:     authorisation,
 		AuthorisationRule: authRule,
 		Job:               job,
+		Participants:      participants,
+		Transfers:         transfers,
+		TransferResults:   transferResults,
+		Activity:          activity,
+		Template:          tpl,
 	}
 
 	csl, err = r.generateStatusAndAuditEvents(ctx, logger, req.NamespacedName, csl, statusCtx)
@@ -218,12 +371,10 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 		return ctrl.Result{}, err
 	}
 
+	// Consoles are deleted once they expire by the ConsoleGarbageCollector, so
+	// there's no need to requeue for when that happens
 	var res ctrl.Result
 	switch {
-	case csl.PendingAuthorisation():
-		// Requeue for when the console has reached its before-running TTL, so that
-		// it can be deleted if it has not yet been authorised by that point.
-		res = requeueAfterInterval(logger, time.Until(*csl.GetGCTime()))
 	case csl.Pending():
 		// Requeue every second while job has been created but there is not yet a
 		// running pod: we won't receive an event via the job watcher when this
@@ -239,33 +390,277 @@ func (r *ConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, r
 			return res, err
 		}
 
-		// Create or update the directory role binding
+		// Create or update the directory role binding, which includes any
+		// participants the owner has invited to join with full access
 		subjects := append(
 			tpl.Spec.AdditionalAttachSubjects,
 			rbacv1.Subject{Kind: "User", Name: csl.Spec.User},
 		)
+		subjects = append(subjects, participantSubjects(participants, false)...)
 
 		drb := buildDirectoryRoleBinding(req.NamespacedName, role, subjects)
 		if err := r.createOrUpdate(ctx, logger, csl, drb, DirectoryRoleBinding, recutil.DirectoryRoleBindingDiff); err != nil {
 			return ctrl.Result{}, err
 		}
-	case csl.PostRunning():
-		// Requeue for when the console has reached its after finished TTL so it can be deleted
-		res = requeueAfterInterval(logger, time.Until(*csl.GetGCTime()))
-	}
 
-	if csl.EligibleForGC() {
-		logger.Info("Deleting expired console", "event", EventDelete, "kind", Console)
-		if err = r.Delete(ctx, csl, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+		// Read-only participants are bound to a separate role, which lets them
+		// follow the console output but not attach to it
+		readOnlyName := types.NamespacedName{
+			Name:      fmt.Sprintf("%s-%s", req.Name, "readonly"),
+			Namespace: req.Namespace,
+		}
+
+		readOnlyRole := buildReadOnlyRole(readOnlyName, csl.Status.PodName)
+		if err := r.createOrUpdate(ctx, logger, csl, readOnlyRole, Role, recutil.RoleDiff); err != nil {
+			return res, err
+		}
+
+		readOnlyDrb := buildDirectoryRoleBinding(readOnlyName, readOnlyRole, participantSubjects(participants, true))
+		if err := r.createOrUpdate(ctx, logger, csl, readOnlyDrb, DirectoryRoleBinding, recutil.DirectoryRoleBindingDiff); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := r.createShareObjects(ctx, logger, csl, req.NamespacedName); err != nil {
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{Requeue: false}, nil
+		// Anyone that can attach to the console may copy files into and out of it,
+		// unless the template forbids it
+		if tpl.FileTransferEnabled() {
+			if err := r.createTransferLogObjects(ctx, logger, csl, req.NamespacedName, subjects); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Anyone that can attach to the console reports their activity, so that we
+		// can stop it once it has been idle for too long
+		if timeout, ok := tpl.IdleTimeout(); ok {
+			if err := r.createActivityObjects(ctx, logger, csl, req.NamespacedName, subjects); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			// We're told about any activity by the activity watcher, but need to
+			// check again for when the console will become idle without any
+			if csl.Status.IdleTime == nil {
+				res = requeueAfterInterval(logger, time.Until(lastActivity(activity, csl).Add(timeout)))
+			}
+		}
+	case csl.Failed():
+		// We've given up on the pod starting, so delete the job to stop it retrying
+		if job != nil {
+			logger.Info("Deleting job of failed console", "event", EventDelete, "kind", Job)
+			err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// Downloaded contents are removed from the transfer log once the user has had
+	// the chance to read them
+	if transferRequeue > 0 && (res.RequeueAfter == 0 || transferRequeue < res.RequeueAfter) {
+		res = requeueAfterInterval(logger, transferRequeue)
 	}
 
 	return res, err
 }
 
+// Finalize stops the pod of a deleted console and records the deletion in the audit
+// log, before the console is released. We don't leave the pod to the garbage
+// collector, which would neither wait for it to stop nor give it the grace period
+// from the console's template.
+func (r *ConsoleReconciler) Finalize(logger logr.Logger, ctx context.Context, req ctrl.Request, csl *workloadsv1alpha1.Console) (ctrl.Result, error) {
+	logger = logger.WithValues("console", req.NamespacedName)
+
+	// The template is missing when its deletion is what caused the console to be
+	// deleted, in which case we can still clean up, but with less to audit
+	tpl, err := r.getConsoleTemplate(ctx, csl, req.NamespacedName)
+	if apierrors.IsNotFound(err) {
+		tpl = nil
+	} else if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to retrieve console template")
+	}
+
+	statusCtx := consoleStatusContext{Template: tpl}
+	if tpl != nil {
+		statusCtx, err = r.getFinalStatusContext(ctx, req.NamespacedName, csl, tpl)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	job, err := r.getJob(ctx, req.NamespacedName)
+	if apierrors.IsNotFound(err) {
+		job = nil
+	} else if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to retrieve console job")
+	}
+
+	// Once the job has been deleted the pod is no longer its child, so we find it
+	// by the label that the job gave it
+	var podList corev1.PodList
+	inNamespace := client.InNamespace(req.Namespace)
+	matchLabels := client.MatchingLabels(map[string]string{"job-name": getJobName(req.Name)})
+	if err := r.List(ctx, &podList, inNamespace, matchLabels); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list pods for console job")
+	}
+
+	var pod *corev1.Pod
+	if len(podList.Items) > 0 {
+		pod = &podList.Items[0]
+	}
+
+	statusCtx.Job = job
+	statusCtx.Pod = pod
+	auditLogger := getAuditLogger(logger, csl, statusCtx).WithValues("console_deleted_by", csl.DeletedBy())
+
+	if !csl.Terminating() {
+		auditLogger.Info("Console terminating", "event", ConsoleTerminating, "console_previous_phase", csl.Status.Phase)
+
+		csl.Status.Phase = workloadsv1alpha1.ConsoleTerminating
+		if err := r.createOrUpdate(ctx, logger, csl, csl, Console, consoleDiff); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Orphan the pod rather than letting the job take it down, and wait for the job
+	// to be gone so that it can't replace the pod once we've stopped it
+	if job != nil {
+		if job.DeletionTimestamp.IsZero() {
+			logger.Info("Deleting job of terminating console", "event", EventDelete, "kind", Job)
+			err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+			if err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return requeueAfterInterval(logger, time.Second), nil
+	}
+
+	if pod != nil {
+		// Deleting the pod sends SIGTERM to its containers, which are killed if they
+		// haven't stopped by the end of the grace period
+		if pod.DeletionTimestamp.IsZero() {
+			var opts []client.DeleteOption
+			if tpl != nil && tpl.Spec.TerminationGracePeriodSeconds != nil {
+				opts = append(opts, client.GracePeriodSeconds(*tpl.Spec.TerminationGracePeriodSeconds))
+			}
+
+			logger.Info("Stopping pod of terminating console", "event", EventDelete, "kind", Pod)
+			err := r.Delete(ctx, pod, opts...)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+
+			return requeueAfterInterval(logger, time.Second), nil
+		}
+
+		// A pod's deletion timestamp is the end of its grace period, after which the
+		// kubelet should soon report it as stopped, unless its node has gone away
+		if !podStopped(pod) && time.Now().Before(pod.DeletionTimestamp.Add(PodStopTimeout)) {
+			return requeueAfterInterval(logger, time.Second), nil
+		}
+	}
+
+	auditLogger.Info("Console destroyed", append([]interface{}{"event", ConsoleDestroyed}, finalPodState(pod)...)...)
+
+	return ctrl.Result{}, nil
+}
+
+// getFinalStatusContext gathers what we need to describe a deleted console in the
+// audit log, as we would for one that's still being reconciled
+func (r *ConsoleReconciler) getFinalStatusContext(ctx context.Context, name types.NamespacedName, csl *workloadsv1alpha1.Console, tpl *workloadsv1alpha1.ConsoleTemplate) (consoleStatusContext, error) {
+	statusCtx := consoleStatusContext{Template: tpl}
+
+	command, err := r.getCommand(csl, tpl)
+	if err != nil {
+		return statusCtx, errors.Wrap(err, "neither the console or template have a command to evaluate")
+	}
+
+	statusCtx.Command = command
+	if tpl.HasAuthorisationRules() {
+		rule, err := tpl.GetAuthorisationRuleForCommand(command)
+		if err != nil {
+			return statusCtx, errors.Wrap(err, "failed to determine authorisation rule for console command")
+		}
+
+		statusCtx.AuthorisationRule = &rule
+		authorisation, err := r.getConsoleAuthorisation(ctx, name)
+		if err == nil {
+			statusCtx.Authorisation = authorisation
+		} else if !apierrors.IsNotFound(err) {
+			return statusCtx, errors.Wrap(err, "failed to retrieve console authorisation")
+		}
+	}
+
+	statusCtx.IsAuthorised = isConsoleAuthorised(csl, statusCtx.AuthorisationRule, statusCtx.Authorisation)
+
+	return statusCtx, nil
+}
+
+// podStopped returns whether all of the pod's containers have stopped, even if the
+// kubelet has yet to remove the pod
+func podStopped(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return false
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// finalPodState describes the pod of a deleted console for the audit log, when it
+// was still around to be seen
+func finalPodState(pod *corev1.Pod) []interface{} {
+	if pod == nil {
+		return nil
+	}
+
+	values := []interface{}{
+		"console_pod_phase", pod.Status.Phase,
+		"console_pod_stopped", podStopped(pod),
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil {
+			values = append(values,
+				"console_pod_exit_code", terminated.ExitCode,
+				"console_pod_exit_reason", terminated.Reason,
+			)
+			break
+		}
+	}
+
+	return values
+}
+
+// applySecurityProfiles hardens the pod of the console's job according to the console
+// security profiles, and refuses to create it if it still breaks them. The template
+// webhook rejects such templates, but they may predate the profiles.
+func (r *ConsoleReconciler) applySecurityProfiles(ctx context.Context, logger logr.Logger, tpl *workloadsv1alpha1.ConsoleTemplate, job *batchv1.Job) error {
+	profiles := &workloadsv1alpha1.ConsoleSecurityProfileList{}
+	if err := r.List(ctx, profiles); err != nil {
+		return errors.Wrap(err, "failed to list console security profiles")
+	}
+
+	if err := tpl.ApplySecurityProfiles(profiles.Items, &job.Spec.Template.Spec); err != nil {
+		msg := fmt.Sprintf("Console template breaks the console security profiles: %v", err)
+		logger.Info(msg, "event", EventSecurityProfile, "error", msg)
+		return errors.Wrap(err, "console template breaks the console security profiles")
+	}
+
+	return nil
+}
+
 func (r *ConsoleReconciler) getConsoleTemplate(ctx context.Context, csl *workloadsv1alpha1.Console, name types.NamespacedName) (*workloadsv1alpha1.ConsoleTemplate, error) {
 	tplName := types.NamespacedName{
 		Name:      csl.Spec.ConsoleTemplateRef.Name,
@@ -277,10 +672,7 @@ func (r *ConsoleReconciler) getConsoleTemplate(ctx context.Context, csl *workloa
 }
 
 func (r *ConsoleReconciler) getCommand(csl *workloadsv1alpha1.Console, template *workloadsv1alpha1.ConsoleTemplate) ([]string, error) {
-	if len(csl.Spec.Command) > 0 {
-		return csl.Spec.Command, nil
-	}
-	return template.GetDefaultCommandWithArgs()
+	return csl.GetCommand(template)
 }
 
 func (r *ConsoleReconciler) getConsoleAuthorisation(ctx context.Context, name types.NamespacedName) (*workloadsv1alpha1.ConsoleAuthorisation, error) {
@@ -288,6 +680,52 @@ func (r *ConsoleReconciler) getConsoleAuthorisation(ctx context.Context, name ty
 	return auth, r.Get(ctx, name, auth)
 }
 
+// getPreApprovedSubjects returns the approvals given ahead of time to the scheduled
+// console that created this console, if any, which apply to this console.
+func (r *ConsoleReconciler) getPreApprovedSubjects(ctx context.Context, csl *workloadsv1alpha1.Console) ([]rbacv1.Subject, error) {
+	owner := csl.ScheduledConsoleOwner()
+	if owner == "" {
+		return nil, nil
+	}
+
+	name := types.NamespacedName{Namespace: csl.Namespace, Name: owner}
+
+	sc := &workloadsv1alpha1.ScheduledConsole{}
+	if err := r.Get(ctx, name, sc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	auth := &workloadsv1alpha1.ScheduledConsoleAuthorisation{}
+	if err := r.Get(ctx, name, auth); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return sc.PreApprovedSubjects(auth, csl), nil
+}
+
+func (r *ConsoleReconciler) getConsoleShare(ctx context.Context, name types.NamespacedName) (*workloadsv1alpha1.ConsoleShare, error) {
+	share := &workloadsv1alpha1.ConsoleShare{}
+	return share, r.Get(ctx, name, share)
+}
+
+func (r *ConsoleReconciler) getConsoleTransferLog(ctx context.Context, name types.NamespacedName) (*workloadsv1alpha1.ConsoleTransferLog, error) {
+	transferLog := &workloadsv1alpha1.ConsoleTransferLog{}
+	return transferLog, r.Get(ctx, name, transferLog)
+}
+
+func (r *ConsoleReconciler) getConsoleActivity(ctx context.Context, name types.NamespacedName) (*workloadsv1alpha1.ConsoleActivity, error) {
+	activity := &workloadsv1alpha1.ConsoleActivity{}
+	return activity, r.Get(ctx, name, activity)
+}
+
 func (r *ConsoleReconciler) getJob(ctx context.Context, name types.NamespacedName) (*batchv1.Job, error) {
 	jobName := types.NamespacedName{
 		Name:      getJobName(name.Name),
@@ -400,7 +838,7 @@ func (r *ConsoleReconciler) setConsoleTimeout(logger logr.Logger, console *workl
 	return updatedCsl
 }
 
-func isConsoleAuthorised(rule *workloadsv1alpha1.ConsoleAuthorisationRule, auth *workloadsv1alpha1.ConsoleAuthorisation) bool {
+func isConsoleAuthorised(csl *workloadsv1alpha1.Console, rule *workloadsv1alpha1.ConsoleAuthorisationRule, auth *workloadsv1alpha1.ConsoleAuthorisation) bool {
 	if rule == nil {
 		return true
 	}
@@ -408,6 +846,12 @@ func isConsoleAuthorised(rule *workloadsv1alpha1.ConsoleAuthorisationRule, auth
 		return false
 	}
 
+	// Authorisers approved the script recorded when the authorisation was created,
+	// so anything else running would not be authorised
+	if auth.Spec.ScriptSHA256 != csl.Spec.ScriptSHA256 {
+		return false
+	}
+
 	if len(auth.Spec.Authorisations) >= rule.ConsoleAuthorisers.AuthorisationsRequired {
 		return true
 	}
@@ -425,6 +869,11 @@ type consoleStatusContext struct {
 	AuthorisationRule *workloadsv1alpha1.ConsoleAuthorisationRule
 	Pod               *corev1.Pod
 	Job               *batchv1.Job
+	Participants      []workloadsv1alpha1.ConsoleParticipant
+	Transfers         []workloadsv1alpha1.ConsoleFileTransfer
+	TransferResults   []workloadsv1alpha1.ConsoleFileTransferResult
+	Activity          *workloadsv1alpha1.ConsoleActivity
+	Template          *workloadsv1alpha1.ConsoleTemplate
 }
 
 func (r *ConsoleReconciler) generateStatusAndAuditEvents(ctx context.Context, logger logr.Logger, name types.NamespacedName, csl *workloadsv1alpha1.Console, statusCtx consoleStatusContext) (*workloadsv1alpha1.Console, error) {
@@ -448,8 +897,9 @@ func (r *ConsoleReconciler) generateStatusAndAuditEvents(ctx context.Context, lo
 
 	statusCtx.Pod = pod
 
+	now := metav1.Now()
 	logger = getAuditLogger(logger, csl, statusCtx)
-	newStatus := calculateStatus(csl, statusCtx)
+	newStatus := calculateStatus(csl, statusCtx, now)
 
 	if csl.Creating() && newStatus.Phase == workloadsv1alpha1.ConsolePendingAuthorisation {
 		logger.Info("Console pending authorisation", "event", ConsolePendingAuthorisation)
@@ -465,35 +915,62 @@ func (r *ConsoleReconciler) generateStatusAndAuditEvents(ctx context.Context, lo
 		logger.Info("Console started", "event", ConsoleStarted)
 	}
 
+	// The pod has started failing, or is failing for a different reason to before
+	if failure := newStatus.PodFailure; failure != nil && newStatus.Phase == workloadsv1alpha1.ConsolePending &&
+		(csl.Status.PodFailure == nil || csl.Status.PodFailure.Reason != failure.Reason) {
+		logger.Info(
+			"Console pod failing to start", "event", ConsolePodFailing,
+			"pod_failure_reason", failure.Reason, "pod_failure_message", failure.Message,
+		)
+	}
+
+	// The console has gone without activity for too long, and is about to be stopped
+	if csl.Status.IdleTime == nil && newStatus.IdleTime != nil {
+		logger.Info(
+			"Console idle", "event", ConsoleIdle,
+			"last_activity_time", lastActivity(statusCtx.Activity, csl),
+			"last_activity_user", lastActivityUser(statusCtx.Activity),
+		)
+	}
+
+	// Console phase to Failed, as its pod has failed to start for too long
+	if !csl.Failed() && newStatus.Phase == workloadsv1alpha1.ConsoleFailed {
+		logger.Info(
+			"Console failed to start", "event", ConsoleEnded, "end_reason", EndReasonFailedToStart,
+			"pod_failure_reason", newStatus.PodFailure.Reason, "pod_failure_message", newStatus.PodFailure.Message,
+		)
+	}
+
 	// Console phase from Running to Stopped, with a CompletionTime: the job
 	// completed successfully
 	if csl.Running() && newStatus.Phase == workloadsv1alpha1.ConsoleStopped &&
 		newStatus.CompletionTime != nil {
 		duration := statusCtx.Job.Status.CompletionTime.Sub(statusCtx.Job.Status.StartTime.Time).Seconds()
-		logger.Info("Console ended", "event", ConsoleEnded, "duration", duration)
+		logger.Info("Console ended", "event", ConsoleEnded, "end_reason", EndReasonCompleted, "duration", duration)
 	}
 
 	// Console phase from Running to Stopped without CompletionTime.
 	// Either:
 	// - The job's activeDeadlineSeconds was reached, and the job was marked as
-	//   failed and the pod deleted.
+	//   failed and the pod deleted. This deadline is brought forward when the
+	//   console becomes idle.
 	// - The pod ended with a non-zero exit code, and the job was marked as failed.
 	if csl.Running() && newStatus.Phase == workloadsv1alpha1.ConsoleStopped &&
 		newStatus.CompletionTime == nil {
-		duration := csl.Status.ExpiryTime.Sub(statusCtx.Job.Status.StartTime.Time).Seconds()
-		logger.Info("Console ended due to expiration", "event", ConsoleEnded, "duration", duration)
+		if idleTime := csl.Status.IdleTime; idleTime != nil {
+			duration := idleTime.Sub(statusCtx.Job.Status.StartTime.Time).Seconds()
+			logger.Info("Console ended due to inactivity", "event", ConsoleEnded, "end_reason", EndReasonIdle, "duration", duration)
+		} else {
+			duration := csl.Status.ExpiryTime.Sub(statusCtx.Job.Status.StartTime.Time).Seconds()
+			logger.Info("Console ended due to expiration", "event", ConsoleEnded, "end_reason", EndReasonExpired, "duration", duration)
+		}
 	}
 
 	// Console phase transitioned to Stopped, but wasn't Running or Stopped beforehand.
 	// This could indicate a bug, or the console may have transitioned through
 	// more than one phase in between reconciliation loops.
 	if !csl.Running() && !csl.Stopped() && newStatus.Phase == workloadsv1alpha1.ConsoleStopped {
-		logger.Info("Console ended: duration unknown", "event", ConsoleEnded)
-	}
-
-	// Console was in PendingAuthorisation phase, but is about to be deleted.
-	if csl.PendingAuthorisation() && csl.EligibleForGC() {
-		logger.Info("Console expired due to lack of authorisation", "event", ConsoleEnded)
+		logger.Info("Console ended: duration unknown", "event", ConsoleEnded, "end_reason", EndReasonUnknown)
 	}
 
 	// Console phase has changed to destroyed (i.e. the job has been removed)
@@ -501,13 +978,38 @@ func (r *ConsoleReconciler) generateStatusAndAuditEvents(ctx context.Context, lo
 		logger.Info("Console destroyed", "event", ConsoleDestroyed)
 	}
 
+	// Participants are only granted access to the console while it is running,
+	// so that's when we consider them to have joined.
+	if newStatus.Phase == workloadsv1alpha1.ConsoleRunning {
+		newStatus.Participants = calculateParticipants(logger, csl, statusCtx.Participants, now)
+	}
+
+	// Results are appended to the log as we carry out transfers, so anything past
+	// those we've already audited is new
+	for idx := csl.Status.AuditedTransfers; idx < len(statusCtx.TransferResults) && idx < len(statusCtx.Transfers); idx++ {
+		transfer, result := statusCtx.Transfers[idx], statusCtx.TransferResults[idx]
+		logger.Info(
+			"File transferred", "event", ConsoleFileTransferred,
+			"transfer_user", transfer.User,
+			"transfer_direction", transfer.Direction,
+			"transfer_path", transfer.Path,
+			"transfer_phase", result.Phase,
+			"transfer_message", result.Message,
+			"transfer_size_bytes", result.SizeBytes,
+			"transfer_sha256", result.SHA256,
+			"transfer_time", transfer.Time,
+		)
+
+		newStatus.AuditedTransfers = idx + 1
+	}
+
 	updatedCsl := csl.DeepCopy()
 	updatedCsl.Status = newStatus
 
 	return updatedCsl, nil
 }
 
-func calculateStatus(csl *workloadsv1alpha1.Console, statusCtx consoleStatusContext) workloadsv1alpha1.ConsoleStatus {
+func calculateStatus(csl *workloadsv1alpha1.Console, statusCtx consoleStatusContext, now metav1.Time) workloadsv1alpha1.ConsoleStatus {
 	newStatus := csl.DeepCopy().Status
 
 	if statusCtx.Job != nil {
@@ -528,11 +1030,120 @@ func calculateStatus(csl *workloadsv1alpha1.Console, statusCtx consoleStatusCont
 		newStatus.PodName = statusCtx.Pod.ObjectMeta.Name
 	}
 
+	// A failed console has had its job deleted, so we keep the phase and the reason
+	// it failed, rather than considering it destroyed
+	if csl.Failed() {
+		return newStatus
+	}
+
 	newStatus.Phase = calculatePhase(statusCtx)
+	newStatus.PodFailure = nil
+
+	if newStatus.Phase == workloadsv1alpha1.ConsolePending {
+		newStatus.PodFailure = calculatePodFailure(csl.Status.PodFailure, statusCtx.Pod, now)
+
+		if podFailureTimedOut(newStatus.PodFailure, statusCtx.Template, now) {
+			newStatus.Phase = workloadsv1alpha1.ConsoleFailed
+		}
+	}
+
+	// Once idle, a console stays idle until it stops, even if someone attaches in
+	// the meantime
+	if newStatus.Phase == workloadsv1alpha1.ConsoleRunning && newStatus.IdleTime == nil &&
+		idleTimedOut(csl, statusCtx.Activity, statusCtx.Template, now) {
+		newStatus.IdleTime = &now
+	}
 
 	return newStatus
 }
 
+// idleTimedOut returns whether the console has gone without activity for longer than
+// the template permits. Consoles are only idle once we've started tracking their
+// activity, which we do from when they start running.
+func idleTimedOut(csl *workloadsv1alpha1.Console, activity *workloadsv1alpha1.ConsoleActivity, template *workloadsv1alpha1.ConsoleTemplate, now metav1.Time) bool {
+	if activity == nil || template == nil {
+		return false
+	}
+
+	timeout, ok := template.IdleTimeout()
+	if !ok {
+		return false
+	}
+
+	return !now.Time.Before(lastActivity(activity, csl).Add(timeout))
+}
+
+// lastActivity returns when activity was last reported in the console, or when we
+// started tracking it if there has been none since
+func lastActivity(activity *workloadsv1alpha1.ConsoleActivity, csl *workloadsv1alpha1.Console) time.Time {
+	if activity == nil {
+		return csl.CreationTimestamp.Time
+	}
+
+	last := activity.CreationTimestamp.Time
+	if reported := activity.Spec.LastActivityTime; reported != nil && reported.Time.After(last) {
+		last = reported.Time
+	}
+
+	return last
+}
+
+func lastActivityUser(activity *workloadsv1alpha1.ConsoleActivity) string {
+	if activity == nil {
+		return ""
+	}
+
+	return activity.Spec.LastActivityUser
+}
+
+// idleDeadlineSeconds returns the active deadline that stops the console's job as
+// soon as possible once the console has become idle, or nil if it hasn't
+func idleDeadlineSeconds(csl *workloadsv1alpha1.Console, job *batchv1.Job) *int64 {
+	if csl.Status.IdleTime == nil || job.Status.StartTime == nil {
+		return nil
+	}
+
+	// A deadline of zero is rejected, and one that has already passed stops the job
+	// immediately
+	deadline := int64(csl.Status.IdleTime.Sub(job.Status.StartTime.Time).Seconds())
+	if deadline < 1 {
+		deadline = 1
+	}
+
+	return &deadline
+}
+
+// calculatePodFailure determines whether the pod is failing to start, keeping the time
+// we first saw it fail if it's still failing for the same reason
+func calculatePodFailure(previous *workloadsv1alpha1.ConsolePodFailure, pod *corev1.Pod, now metav1.Time) *workloadsv1alpha1.ConsolePodFailure {
+	failure := workloadsv1alpha1.DetectPodFailure(pod)
+	if failure == nil {
+		return nil
+	}
+
+	failure.Since = now
+	if previous != nil && previous.Reason == failure.Reason {
+		failure.Since = previous.Since
+	}
+
+	return failure
+}
+
+// podFailureTimedOut returns whether the pod has been failing to start for longer
+// than the template permits, if the template permits consoles to fail at all
+func podFailureTimedOut(failure *workloadsv1alpha1.ConsolePodFailure, template *workloadsv1alpha1.ConsoleTemplate, now metav1.Time) bool {
+	if failure == nil || template == nil {
+		return false
+	}
+
+	timeout, ok := template.PodFailureTimeout()
+	if !ok {
+		return false
+	}
+
+	return !now.Time.Before(failure.Since.Add(timeout))
+}
+
 func calculatePhase(statusCtx consoleStatusContext) workloadsv1alpha1.ConsolePhase {
 	if !statusCtx.IsAuthorised {
 		return workloadsv1alpha1.ConsolePendingAuthorisation
@@ -559,6 +1170,50 @@ func calculatePhase(statusCtx consoleStatusContext) workloadsv1alpha1.ConsolePha
 	return workloadsv1alpha1.ConsolePending
 }
 
+// calculateParticipants compares the participants that the console owner has
+// invited with those that we've already granted access to, generating audit
+// events for anyone that has joined or left the console.
+func calculateParticipants(logger logr.Logger, csl *workloadsv1alpha1.Console, invited []workloadsv1alpha1.ConsoleParticipant, now metav1.Time) []workloadsv1alpha1.ConsoleParticipantStatus {
+	existing := map[string]workloadsv1alpha1.ConsoleParticipantStatus{}
+	for _, participant := range csl.Status.Participants {
+		existing[participant.Name] = participant
+	}
+
+	var participants []workloadsv1alpha1.ConsoleParticipantStatus
+	for _, participant := range invited {
+		status, ok := existing[participant.Name]
+		delete(existing, participant.Name)
+
+		// Changing whether a participant is read-only is treated as them joining
+		// again, as their access to the console changes.
+		if !ok || status.ReadOnly != participant.ReadOnly {
+			status = workloadsv1alpha1.ConsoleParticipantStatus{
+				Name:       participant.Name,
+				ReadOnly:   participant.ReadOnly,
+				JoinedTime: now,
+			}
+
+			logger.Info(
+				"Participant joined console", "event", ConsoleParticipantJoined,
+				"participant", participant.Name, "participant_read_only", participant.ReadOnly,
+			)
+		}
+
+		participants = append(participants, status)
+	}
+
+	for _, participant := range csl.Status.Participants {
+		if _, ok := existing[participant.Name]; ok {
+			logger.Info(
+				"Participant left console", "event", ConsoleParticipantLeft,
+				"participant", participant.Name, "participant_read_only", participant.ReadOnly,
+			)
+		}
+	}
+
+	return participants
+}
+
 func requeueAfterInterval(logger logr.Logger, interval time.Duration) reconcile.Result {
 	logging.WithNoRecord(logger).Info(
 		"Reconciliation requeued",
@@ -581,10 +1236,20 @@ func (r *ConsoleReconciler) buildJob(logger logr.Logger, name types.NamespacedNa
 	if numContainers > 0 {
 		container := &jobTemplate.Spec.Containers[0]
 
-		// Only replace the template command if one is specified
-		if len(csl.Spec.Command) > 0 {
-			container.Command = csl.Spec.Command[:1]
-			container.Args = csl.Spec.Command[1:]
+		// Only replace the template command if one is specified, or if we need to
+		// pass it a script
+		if len(csl.Spec.Command) > 0 || csl.HasScript() {
+			command, _ := csl.GetCommand(template)
+			container.Command = command[:1]
+			container.Args = command[1:]
+		}
+
+		if csl.HasScript() {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      scriptVolumeName,
+				MountPath: workloadsv1alpha1.ConsoleScriptDirectory,
+				ReadOnly:  true,
+			})
 		}
 
 		if !csl.Spec.Noninteractive {
@@ -617,6 +1282,19 @@ func (r *ConsoleReconciler) buildJob(logger logr.Logger, name types.NamespacedNa
 	backoffLimit := int32(0)
 	jobTemplate.Spec.RestartPolicy = corev1.RestartPolicyNever
 
+	if csl.HasScript() {
+		mode := int32(0555)
+		jobTemplate.Spec.Volumes = append(jobTemplate.Spec.Volumes, corev1.Volume{
+			Name: scriptVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: getScriptConfigMapName(name.Name)},
+					DefaultMode:          &mode,
+				},
+			},
+		})
+	}
+
 	jobName := getJobName(name.Name)
 
 	// Merged labels from the console template and console. In case of
@@ -681,6 +1359,38 @@ func buildRole(name types.NamespacedName, podName string) *rbacv1.Role {
 	}
 }
 
+// buildReadOnlyRole grants permission to follow the logs of the console pod,
+// without being able to attach to it.
+func buildReadOnlyRole(name types.NamespacedName, podName string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:         []string{"get"},
+				APIGroups:     []string{""},
+				Resources:     []string{"pods", "pods/log"},
+				ResourceNames: []string{podName},
+			},
+		},
+	}
+}
+
+// participantSubjects returns a subject for each of the participants that are,
+// or are not, read-only.
+func participantSubjects(participants []workloadsv1alpha1.ConsoleParticipant, readOnly bool) []rbacv1.Subject {
+	subjects := []rbacv1.Subject{}
+	for _, participant := range participants {
+		if participant.ReadOnly == readOnly {
+			subjects = append(subjects, rbacv1.Subject{Kind: "User", Name: participant.Name})
+		}
+	}
+
+	return subjects
+}
+
 func buildDirectoryRoleBinding(name types.NamespacedName, role *rbacv1.Role, subjects []rbacv1.Subject) *rbacv1alpha1.DirectoryRoleBinding {
 	return &rbacv1alpha1.DirectoryRoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
@@ -698,7 +1408,12 @@ func buildDirectoryRoleBinding(name types.NamespacedName, role *rbacv1.Role, sub
 	}
 }
 
-func (r *ConsoleReconciler) createAuthorisationObjects(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, name types.NamespacedName, subjects []rbacv1.Subject) error {
+func (r *ConsoleReconciler) createAuthorisationObjects(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, name types.NamespacedName, subjects, preApproved []rbacv1.Subject) error {
+	// The authorisations are only set when the consoleauthorisation is created, as
+	// authorisationDiff leaves them to the authorising users from then on
+	authorisations := []rbacv1.Subject{}
+	authorisations = append(authorisations, preApproved...)
+
 	authorisation := &workloadsv1alpha1.ConsoleAuthorisation{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name.Name,
@@ -707,7 +1422,8 @@ func (r *ConsoleReconciler) createAuthorisationObjects(ctx context.Context, logg
 		},
 		Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
 			ConsoleRef:     corev1.LocalObjectReference{Name: name.Name},
-			Authorisations: []rbacv1.Subject{},
+			Authorisations: authorisations,
+			ScriptSHA256:   csl.Spec.ScriptSHA256,
 		},
 	}
 
@@ -753,6 +1469,275 @@ func (r *ConsoleReconciler) createAuthorisationObjects(ctx context.Context, logg
 	return nil
 }
 
+// createShareObjects creates the object that the console owner updates to invite
+// other users to join their console, along with the RBAC resources that allow
+// only the owner to do so.
+func (r *ConsoleReconciler) createShareObjects(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, name types.NamespacedName) error {
+	share := &workloadsv1alpha1.ConsoleShare{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    csl.Labels,
+		},
+		Spec: workloadsv1alpha1.ConsoleShareSpec{
+			ConsoleRef: corev1.LocalObjectReference{Name: name.Name},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, csl, share, ConsoleShare, shareDiff); err != nil {
+		return errors.Wrap(err, "failed to create consoleshare")
+	}
+
+	rbacName := types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%s", name.Name, "share"),
+		Namespace: name.Namespace,
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName.Name,
+			Namespace: name.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:         []string{"get", "patch", "update"},
+				APIGroups:     []string{"workloads.crd.gocardless.com"},
+				Resources:     []string{"consoleshares"},
+				ResourceNames: []string{name.Name},
+			},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, csl, role, Role, recutil.RoleDiff); err != nil {
+		return errors.Wrap(err, "failed to create role for consoleshare")
+	}
+
+	subjects := []rbacv1.Subject{{Kind: "User", Name: csl.Spec.User}}
+	drb := buildDirectoryRoleBinding(rbacName, role, subjects)
+	if err := r.createOrUpdate(ctx, logger, csl, drb, DirectoryRoleBinding, recutil.DirectoryRoleBindingDiff); err != nil {
+		return errors.Wrap(err, "failed to create directory rolebinding for consoleshare")
+	}
+
+	return nil
+}
+
+// createTransferLogObjects creates the object that records files copied into
+// and out of the console, along with the RBAC resources that allow the given
+// subjects to append to it.
+func (r *ConsoleReconciler) createTransferLogObjects(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, name types.NamespacedName, subjects []rbacv1.Subject) error {
+	transferLog := &workloadsv1alpha1.ConsoleTransferLog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    csl.Labels,
+		},
+		Spec: workloadsv1alpha1.ConsoleTransferLogSpec{
+			ConsoleRef: corev1.LocalObjectReference{Name: name.Name},
+			Transfers:  []workloadsv1alpha1.ConsoleFileTransfer{},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, csl, transferLog, ConsoleTransferLog, transferLogDiff); err != nil {
+		return errors.Wrap(err, "failed to create consoletransferlog")
+	}
+
+	rbacName := types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%s", name.Name, "transfers"),
+		Namespace: name.Namespace,
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName.Name,
+			Namespace: name.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:         []string{"get", "patch", "update"},
+				APIGroups:     []string{"workloads.crd.gocardless.com"},
+				Resources:     []string{"consoletransferlogs"},
+				ResourceNames: []string{name.Name},
+			},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, csl, role, Role, recutil.RoleDiff); err != nil {
+		return errors.Wrap(err, "failed to create role for consoletransferlog")
+	}
+
+	drb := buildDirectoryRoleBinding(rbacName, role, subjects)
+	if err := r.createOrUpdate(ctx, logger, csl, drb, DirectoryRoleBinding, recutil.DirectoryRoleBindingDiff); err != nil {
+		return errors.Wrap(err, "failed to create directory rolebinding for consoletransferlog")
+	}
+
+	return nil
+}
+
+// createActivityObjects creates the object that records activity in the console,
+// along with the RBAC resources that allow the given subjects to report it.
+func (r *ConsoleReconciler) createActivityObjects(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, name types.NamespacedName, subjects []rbacv1.Subject) error {
+	activity := &workloadsv1alpha1.ConsoleActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    csl.Labels,
+		},
+		Spec: workloadsv1alpha1.ConsoleActivitySpec{
+			ConsoleRef: corev1.LocalObjectReference{Name: name.Name},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, csl, activity, ConsoleActivity, activityDiff); err != nil {
+		return errors.Wrap(err, "failed to create consoleactivity")
+	}
+
+	rbacName := types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%s", name.Name, "activity"),
+		Namespace: name.Namespace,
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName.Name,
+			Namespace: name.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:         []string{"get", "patch", "update"},
+				APIGroups:     []string{"workloads.crd.gocardless.com"},
+				Resources:     []string{"consoleactivities"},
+				ResourceNames: []string{name.Name},
+			},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, csl, role, Role, recutil.RoleDiff); err != nil {
+		return errors.Wrap(err, "failed to create role for consoleactivity")
+	}
+
+	drb := buildDirectoryRoleBinding(rbacName, role, subjects)
+	if err := r.createOrUpdate(ctx, logger, csl, drb, DirectoryRoleBinding, recutil.DirectoryRoleBindingDiff); err != nil {
+		return errors.Wrap(err, "failed to create directory rolebinding for consoleactivity")
+	}
+
+	return nil
+}
+
+// createScriptConfigMap creates the ConfigMap holding the console's script, which
+// is mounted into the console container. The ConfigMap is immutable, so that the
+// script can't be changed after the console has been authorised.
+func (r *ConsoleReconciler) createScriptConfigMap(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, name types.NamespacedName) error {
+	// The checksum is recorded by the authenticator webhook when the console is
+	// created, and is what authorisers approve. Anyone able to update the console
+	// could change the script afterwards, so we refuse to store one that no longer
+	// matches. Once stored the script is immutable, so a console that is already
+	// running is left alone.
+	checksum := sha256.Sum256([]byte(csl.Spec.Script))
+	if hex.EncodeToString(checksum[:]) != csl.Spec.ScriptSHA256 {
+		existing := &corev1.ConfigMap{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: name.Namespace, Name: getScriptConfigMapName(name.Name)}, existing)
+		if apierrors.IsNotFound(err) {
+			return errScriptChecksumMismatch
+		}
+
+		return err
+	}
+
+	immutable := true
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getScriptConfigMapName(name.Name),
+			Namespace: name.Namespace,
+			Labels:    csl.Labels,
+			Annotations: map[string]string{
+				"workloads.crd.gocardless.com/script-sha256": csl.Spec.ScriptSHA256,
+			},
+		},
+		Data: map[string]string{
+			workloadsv1alpha1.ConsoleScriptKey: csl.Spec.Script,
+		},
+		Immutable: &immutable,
+	}
+
+	if err := r.createOrUpdate(ctx, logger, csl, configMap, ConfigMap, scriptConfigMapDiff); err != nil {
+		return errors.Wrap(err, "failed to create script configmap")
+	}
+
+	return nil
+}
+
+// errScriptChecksumMismatch is returned when a console's script doesn't match the
+// checksum that was recorded when it was created
+var errScriptChecksumMismatch = errors.New("console script does not match its checksum")
+
+// scriptConfigMapDiff is a reconcile.DiffFunc for the ConfigMaps holding console
+// scripts. These are never updated: the data is immutable, and the manager is
+// only permitted to create and delete ConfigMaps.
+func scriptConfigMapDiff(expectedObj runtime.Object, existingObj runtime.Object) recutil.Outcome {
+	return recutil.None
+}
+
+// transferLogDiff is a reconcile.DiffFunc for ConsoleTransferLogs
+func transferLogDiff(expectedObj runtime.Object, existingObj runtime.Object) recutil.Outcome {
+	expected := expectedObj.(*workloadsv1alpha1.ConsoleTransferLog)
+	existing := existingObj.(*workloadsv1alpha1.ConsoleTransferLog)
+	operation := recutil.None
+
+	if !reflect.DeepEqual(expected.ObjectMeta.Labels, existing.ObjectMeta.Labels) {
+		existing.ObjectMeta.Labels = expected.ObjectMeta.Labels
+		operation = recutil.Update
+	}
+
+	// `transfers` is appended to by users copying files, so we leave it alone
+	if !reflect.DeepEqual(expected.Spec.ConsoleRef, existing.Spec.ConsoleRef) {
+		existing.Spec.ConsoleRef = expected.Spec.ConsoleRef
+		operation = recutil.Update
+	}
+
+	return operation
+}
+
+// activityDiff is a reconcile.DiffFunc for ConsoleActivities
+func activityDiff(expectedObj runtime.Object, existingObj runtime.Object) recutil.Outcome {
+	expected := expectedObj.(*workloadsv1alpha1.ConsoleActivity)
+	existing := existingObj.(*workloadsv1alpha1.ConsoleActivity)
+	operation := recutil.None
+
+	if !reflect.DeepEqual(expected.ObjectMeta.Labels, existing.ObjectMeta.Labels) {
+		existing.ObjectMeta.Labels = expected.ObjectMeta.Labels
+		operation = recutil.Update
+	}
+
+	// The last activity is reported by users attached to the console, so we leave
+	// it alone
+	if !reflect.DeepEqual(expected.Spec.ConsoleRef, existing.Spec.ConsoleRef) {
+		existing.Spec.ConsoleRef = expected.Spec.ConsoleRef
+		operation = recutil.Update
+	}
+
+	return operation
+}
+
+// shareDiff is a reconcile.DiffFunc for ConsoleShares
+func shareDiff(expectedObj runtime.Object, existingObj runtime.Object) recutil.Outcome {
+	expected := expectedObj.(*workloadsv1alpha1.ConsoleShare)
+	existing := existingObj.(*workloadsv1alpha1.ConsoleShare)
+	operation := recutil.None
+
+	if !reflect.DeepEqual(expected.ObjectMeta.Labels, existing.ObjectMeta.Labels) {
+		existing.ObjectMeta.Labels = expected.ObjectMeta.Labels
+		operation = recutil.Update
+	}
+
+	// `participants` is managed by the console owner, so we leave it alone
+	if !reflect.DeepEqual(expected.Spec.ConsoleRef, existing.Spec.ConsoleRef) {
+		existing.Spec.ConsoleRef = expected.Spec.ConsoleRef
+		operation = recutil.Update
+	}
+
+	return operation
+}
+
 // authorisationDiff is a reconcile.DiffFunc for ConsoleAuthorisations
 func authorisationDiff(expectedObj runtime.Object, existingObj runtime.Object) recutil.Outcome {
 	expected := expectedObj.(*workloadsv1alpha1.ConsoleAuthorisation)
@@ -869,10 +1854,22 @@ func getAuditLogger(logger logr.Logger, c *workloadsv1alpha1.Console, statusCtx
 		"reason", c.Spec.Reason,
 	)
 
+	if c.HasScript() {
+		loggerCtx = loggerCtx.WithValues("script_sha256", c.Spec.ScriptSHA256)
+	}
+
 	if statusCtx.Pod != nil {
 		loggerCtx = loggerCtx.WithValues("console_pod_name", statusCtx.Pod.Name)
 	}
 
+	// Consoles from templates that are exempt from security profile rules run pods
+	// that would otherwise be forbidden, which auditors will want to know about
+	if statusCtx.Template != nil {
+		if exceptions := statusCtx.Template.Annotations[workloadsv1alpha1.SecurityProfileExceptionsAnnotation]; exceptions != "" {
+			loggerCtx = loggerCtx.WithValues("console_security_profile_exceptions", exceptions)
+		}
+	}
+
 	if statusCtx.AuthorisationRule != nil {
 		loggerCtx = loggerCtx.WithValues(
 			"console_authorisation_rule_name", statusCtx.AuthorisationRule.Name,
@@ -900,6 +1897,10 @@ func getJobName(consoleName string) string {
 	return fmt.Sprintf("%s-%s", truncateString(consoleName, 55), "console")
 }
 
+func getScriptConfigMapName(consoleName string) string {
+	return fmt.Sprintf("%s-%s", truncateString(consoleName, 56), "script")
+}
+
 // Kubernetes labels must satisfy (([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])? and not
 // exceed 63 characters in length.
 // We don't bother with the first and last character sanitisation here - just anything
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+var _ = Describe("Idle consoles", func() {
+	var (
+		now       metav1.Time
+		csl       *workloadsv1alpha1.Console
+		statusCtx consoleStatusContext
+	)
+
+	BeforeEach(func() {
+		now = metav1.NewTime(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+		idleTimeout := int32(600)
+		started := metav1.NewTime(now.Add(-time.Hour))
+
+		csl = &workloadsv1alpha1.Console{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: started},
+			Status:     workloadsv1alpha1.ConsoleStatus{Phase: workloadsv1alpha1.ConsoleRunning},
+		}
+
+		statusCtx = consoleStatusContext{
+			IsAuthorised: true,
+			Job: &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: started},
+				Status:     batchv1.JobStatus{StartTime: &started},
+			},
+			Pod: &corev1.Pod{
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			},
+			Activity: &workloadsv1alpha1.ConsoleActivity{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: started},
+			},
+			Template: &workloadsv1alpha1.ConsoleTemplate{
+				Spec: workloadsv1alpha1.ConsoleTemplateSpec{IdleTimeoutSeconds: &idleTimeout},
+			},
+		}
+	})
+
+	Describe("calculateStatus", func() {
+		It("Marks a console without any reported activity as idle", func() {
+			status := calculateStatus(csl, statusCtx, now)
+			Expect(status.IdleTime).To(Equal(&now))
+		})
+
+		Context("When activity was reported within the idle timeout", func() {
+			BeforeEach(func() {
+				reported := metav1.NewTime(now.Add(-5 * time.Minute))
+				statusCtx.Activity.Spec.LastActivityTime = &reported
+			})
+
+			It("Doesn't mark the console as idle", func() {
+				Expect(calculateStatus(csl, statusCtx, now).IdleTime).To(BeNil())
+			})
+		})
+
+		Context("When the console has only just started being tracked", func() {
+			BeforeEach(func() {
+				statusCtx.Activity.CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+			})
+
+			It("Doesn't mark the console as idle", func() {
+				Expect(calculateStatus(csl, statusCtx, now).IdleTime).To(BeNil())
+			})
+		})
+
+		Context("When the template has no idle timeout", func() {
+			BeforeEach(func() {
+				statusCtx.Template.Spec.IdleTimeoutSeconds = nil
+			})
+
+			It("Doesn't mark the console as idle", func() {
+				Expect(calculateStatus(csl, statusCtx, now).IdleTime).To(BeNil())
+			})
+		})
+
+		Context("When the console was already idle", func() {
+			var idleTime metav1.Time
+
+			BeforeEach(func() {
+				idleTime = metav1.NewTime(now.Add(-time.Minute))
+				csl.Status.IdleTime = &idleTime
+
+				reported := now
+				statusCtx.Activity.Spec.LastActivityTime = &reported
+			})
+
+			It("Keeps the time it became idle, despite later activity", func() {
+				Expect(calculateStatus(csl, statusCtx, now).IdleTime).To(Equal(&idleTime))
+			})
+		})
+	})
+
+	Describe("idleDeadlineSeconds", func() {
+		It("Is nil for consoles that aren't idle", func() {
+			Expect(idleDeadlineSeconds(csl, statusCtx.Job)).To(BeNil())
+		})
+
+		Context("When the console is idle", func() {
+			BeforeEach(func() {
+				csl.Status.IdleTime = &now
+			})
+
+			It("Is the time from the job starting to the console becoming idle", func() {
+				deadline := int64(3600)
+				Expect(idleDeadlineSeconds(csl, statusCtx.Job)).To(Equal(&deadline))
+			})
+
+			Context("And became idle as its job started", func() {
+				BeforeEach(func() {
+					statusCtx.Job.Status.StartTime = &now
+				})
+
+				It("Is the shortest deadline a job can have", func() {
+					deadline := int64(1)
+					Expect(idleDeadlineSeconds(csl, statusCtx.Job)).To(Equal(&deadline))
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("isConsoleAuthorised", func() {
+	var (
+		csl  *workloadsv1alpha1.Console
+		rule *workloadsv1alpha1.ConsoleAuthorisationRule
+		auth *workloadsv1alpha1.ConsoleAuthorisation
+	)
+
+	BeforeEach(func() {
+		csl = &workloadsv1alpha1.Console{
+			Spec: workloadsv1alpha1.ConsoleSpec{ScriptSHA256: "approved"},
+		}
+		rule = &workloadsv1alpha1.ConsoleAuthorisationRule{
+			ConsoleAuthorisers: workloadsv1alpha1.ConsoleAuthorisers{AuthorisationsRequired: 1},
+		}
+		auth = &workloadsv1alpha1.ConsoleAuthorisation{
+			Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+				Authorisations: []rbacv1.Subject{{Kind: "User", Name: "authoriser"}},
+				ScriptSHA256:   "approved",
+			},
+		}
+	})
+
+	It("Is authorised once enough authorisers have approved the script", func() {
+		Expect(isConsoleAuthorised(csl, rule, auth)).To(BeTrue())
+	})
+
+	It("Isn't authorised if the script differs from the one approved", func() {
+		csl.Spec.ScriptSHA256 = "changed"
+		Expect(isConsoleAuthorised(csl, rule, auth)).To(BeFalse())
+	})
+})
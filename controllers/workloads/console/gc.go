@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+const (
+	EventGarbageCollect = "GarbageCollect"
+
+	// GCReasonTTL is given for consoles whose TTL has elapsed
+	GCReasonTTL = "ttl"
+	// GCReasonRetention is given for finished consoles beyond the number that their
+	// template retains for each user
+	GCReasonRetention = "retention"
+
+	DefaultGCInterval           = time.Minute
+	DefaultGCDeletionsPerSecond = 5
+)
+
+var (
+	gcPending = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "theatre_console_gc_pending",
+			Help: "Number of consoles that are eligible for garbage collection but have yet to be deleted",
+		},
+	)
+	gcDeletedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_console_gc_deleted_total",
+			Help: "Count of consoles deleted by garbage collection, by reason",
+		},
+		[]string{"namespace", "reason"},
+	)
+	gcFailedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_console_gc_failed_total",
+			Help: "Count of consoles that garbage collection failed to delete",
+		},
+		[]string{"namespace"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global controller runtime prometheus registry
+	metrics.Registry.MustRegister(gcPending, gcDeletedTotal, gcFailedTotal)
+}
+
+// ConsoleGarbageCollector deletes consoles that are no longer needed, scanning all of
+// them on an interval rather than having each console requeue itself for when it
+// expires, which would flood the controller's queue when there are many consoles.
+// Deletions are rate limited, so that a backlog of expired consoles, such as after
+// the manager has been down, doesn't overwhelm the API server.
+type ConsoleGarbageCollector struct {
+	client.Client
+	Log logr.Logger
+
+	// Interval between scans of all consoles
+	Interval time.Duration
+	// DeletionsPerSecond bounds how quickly consoles are deleted, with up to this
+	// many deleted at once
+	DeletionsPerSecond float32
+}
+
+// NeedLeaderElection ensures that only the leading manager deletes consoles
+func (gc *ConsoleGarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Start scans consoles on the interval until the stop channel is closed
+func (gc *ConsoleGarbageCollector) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	burst := int(gc.DeletionsPerSecond)
+	if burst < 1 {
+		burst = 1
+	}
+
+	limiter := flowcontrol.NewTokenBucketRateLimiter(gc.DeletionsPerSecond, burst)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := gc.collect(ctx, limiter); err != nil {
+			gc.Log.Error(err, "failed to garbage collect consoles", "event", EventGarbageCollect)
+		}
+	}, gc.Interval)
+
+	return nil
+}
+
+func (gc *ConsoleGarbageCollector) collect(ctx context.Context, limiter flowcontrol.RateLimiter) error {
+	var consoles workloadsv1alpha1.ConsoleList
+	if err := gc.List(ctx, &consoles); err != nil {
+		return err
+	}
+
+	var templates workloadsv1alpha1.ConsoleTemplateList
+	if err := gc.List(ctx, &templates); err != nil {
+		return err
+	}
+
+	candidates := consolesToCollect(consoles.Items, templates.Items, time.Now())
+	gcPending.Set(float64(len(candidates)))
+
+	for idx, candidate := range candidates {
+		if err := limiter.Wait(ctx); err != nil {
+			// We've been stopped, and will leave the remaining consoles to the next
+			// manager to lead
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			// Otherwise the limiter is misconfigured, and will keep failing
+			return errors.Wrap(err, "failed to wait for deletion rate limiter")
+		}
+
+		csl := candidate.Console
+		logger := gc.Log.WithValues("console", types.NamespacedName{Namespace: csl.Namespace, Name: csl.Name})
+
+		// Consoles are otherwise only audited as they're reconciled, which happens
+		// before they expire
+		if csl.PendingAuthorisation() && candidate.Reason == GCReasonTTL {
+			statusCtx := consoleStatusContext{Command: csl.Spec.Command}
+			getAuditLogger(logger, csl, statusCtx).Info("Console expired due to lack of authorisation", "event", ConsoleEnded, "end_reason", EndReasonUnauthorised)
+		}
+
+		logger.Info("Deleting expired console", "event", EventDelete, "kind", Console, "reason", candidate.Reason)
+		err := gc.Delete(ctx, csl, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete console", "event", EventGarbageCollect)
+			gcFailedTotal.WithLabelValues(csl.Namespace).Inc()
+		} else {
+			gcDeletedTotal.WithLabelValues(csl.Namespace, candidate.Reason).Inc()
+		}
+
+		gcPending.Set(float64(len(candidates) - idx - 1))
+	}
+
+	return nil
+}
+
+type gcCandidate struct {
+	Console *workloadsv1alpha1.Console
+	Reason  string
+}
+
+// consolesToCollect returns the consoles that should be garbage collected, oldest
+// first: those whose TTL has elapsed, and finished consoles beyond the number that
+// their template retains for each user.
+func consolesToCollect(consoles []workloadsv1alpha1.Console, templates []workloadsv1alpha1.ConsoleTemplate, now time.Time) []gcCandidate {
+	retain := map[types.NamespacedName]int{}
+	for _, tpl := range templates {
+		if tpl.Spec.RetainFinishedConsolesPerUser != nil {
+			retain[types.NamespacedName{Namespace: tpl.Namespace, Name: tpl.Name}] = int(*tpl.Spec.RetainFinishedConsolesPerUser)
+		}
+	}
+
+	type owner struct {
+		template types.NamespacedName
+		user     string
+	}
+
+	var candidates []gcCandidate
+	finished := map[owner][]*workloadsv1alpha1.Console{}
+
+	for idx := range consoles {
+		csl := &consoles[idx]
+
+		// Consoles are given their TTLs when they're first reconciled, and those
+		// being deleted are left to finish
+		if !csl.DeletionTimestamp.IsZero() || csl.Spec.TTLSecondsBeforeRunning == nil || csl.Spec.TTLSecondsAfterFinished == nil {
+			continue
+		}
+
+		if gcTime := csl.GetGCTime(); gcTime != nil && gcTime.Before(now) {
+			candidates = append(candidates, gcCandidate{Console: csl, Reason: GCReasonTTL})
+			continue
+		}
+
+		if csl.PostRunning() {
+			key := owner{
+				template: types.NamespacedName{Namespace: csl.Namespace, Name: csl.Spec.ConsoleTemplateRef.Name},
+				user:     csl.Spec.User,
+			}
+			finished[key] = append(finished[key], csl)
+		}
+	}
+
+	for key, owned := range finished {
+		limit, ok := retain[key.template]
+		if !ok || len(owned) <= limit {
+			continue
+		}
+
+		sort.Slice(owned, func(i, j int) bool {
+			return createdBefore(owned[j], owned[i])
+		})
+
+		for _, csl := range owned[limit:] {
+			candidates = append(candidates, gcCandidate{Console: csl, Reason: GCReasonRetention})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return createdBefore(candidates[i].Console, candidates[j].Console)
+	})
+
+	return candidates
+}
+
+// createdBefore orders consoles by when they were created, falling back to their
+// names so that the order is stable
+func createdBefore(a, b *workloadsv1alpha1.Console) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+}
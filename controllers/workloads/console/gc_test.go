@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+var _ = Describe("consolesToCollect", func() {
+	var (
+		now       time.Time
+		consoles  []workloadsv1alpha1.Console
+		templates []workloadsv1alpha1.ConsoleTemplate
+		collected []gcCandidate
+	)
+
+	ttl := int32(3600)
+	newConsole := func(name, user string, phase workloadsv1alpha1.ConsolePhase, age time.Duration) workloadsv1alpha1.Console {
+		completion := metav1.NewTime(now.Add(-age).Add(time.Minute))
+		return workloadsv1alpha1.Console{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: workloadsv1alpha1.ConsoleSpec{
+				User:                    user,
+				ConsoleTemplateRef:      corev1.LocalObjectReference{Name: "template"},
+				TTLSecondsBeforeRunning: &ttl,
+				TTLSecondsAfterFinished: &ttl,
+			},
+			Status: workloadsv1alpha1.ConsoleStatus{
+				Phase:          phase,
+				CompletionTime: &completion,
+				ExpiryTime:     &completion,
+			},
+		}
+	}
+
+	names := func() []string {
+		result := []string{}
+		for _, candidate := range collected {
+			result = append(result, candidate.Console.Name+":"+candidate.Reason)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		now = time.Now()
+		templates = []workloadsv1alpha1.ConsoleTemplate{
+			{ObjectMeta: metav1.ObjectMeta{Name: "template", Namespace: "default"}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		collected = consolesToCollect(consoles, templates, now)
+	})
+
+	Context("With consoles past their TTL", func() {
+		BeforeEach(func() {
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("running", "alice", workloadsv1alpha1.ConsoleRunning, 3*time.Hour),
+				newConsole("expired", "alice", workloadsv1alpha1.ConsoleStopped, 3*time.Hour),
+				newConsole("unauthorised", "bob", workloadsv1alpha1.ConsolePendingAuthorisation, 2*time.Hour),
+				newConsole("recent", "alice", workloadsv1alpha1.ConsoleStopped, 10*time.Minute),
+			}
+		})
+
+		It("Collects them, oldest first", func() {
+			Expect(names()).To(Equal([]string{"expired:ttl", "unauthorised:ttl"}))
+		})
+	})
+
+	Context("With consoles that haven't been reconciled or are being deleted", func() {
+		BeforeEach(func() {
+			unreconciled := newConsole("unreconciled", "alice", "", 3*time.Hour)
+			unreconciled.Spec.TTLSecondsBeforeRunning = nil
+
+			deleted := newConsole("deleted", "alice", workloadsv1alpha1.ConsoleStopped, 3*time.Hour)
+			deletedAt := metav1.NewTime(now)
+			deleted.DeletionTimestamp = &deletedAt
+
+			consoles = []workloadsv1alpha1.Console{unreconciled, deleted}
+		})
+
+		It("Leaves them alone", func() {
+			Expect(collected).To(BeEmpty())
+		})
+	})
+
+	Context("When the template retains finished consoles", func() {
+		BeforeEach(func() {
+			retain := int32(1)
+			templates[0].Spec.RetainFinishedConsolesPerUser = &retain
+
+			consoles = []workloadsv1alpha1.Console{
+				newConsole("alice-newest", "alice", workloadsv1alpha1.ConsoleStopped, 10*time.Minute),
+				newConsole("alice-older", "alice", workloadsv1alpha1.ConsoleDestroyed, 20*time.Minute),
+				newConsole("alice-oldest", "alice", workloadsv1alpha1.ConsoleStopped, 30*time.Minute),
+				newConsole("alice-running", "alice", workloadsv1alpha1.ConsoleRunning, 40*time.Minute),
+				newConsole("bob", "bob", workloadsv1alpha1.ConsoleStopped, 50*time.Minute),
+			}
+		})
+
+		It("Collects the older finished consoles of each user", func() {
+			Expect(names()).To(Equal([]string{"alice-oldest:retention", "alice-older:retention"}))
+		})
+	})
+})
+
+// fakeLimiter fails every wait with the given error, or permits it if there is none
+type fakeLimiter struct {
+	flowcontrol.RateLimiter
+	err error
+}
+
+func (l *fakeLimiter) Wait(ctx context.Context) error {
+	return l.err
+}
+
+var _ = Describe("ConsoleGarbageCollector", func() {
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		gc      *ConsoleGarbageCollector
+		limiter *fakeLimiter
+		csl     *workloadsv1alpha1.Console
+		err     error
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		limiter = &fakeLimiter{}
+
+		ttl := int32(60)
+		completion := metav1.NewTime(time.Now().Add(-time.Hour))
+		csl = &workloadsv1alpha1.Console{
+			ObjectMeta: metav1.ObjectMeta{Name: "expired", Namespace: "default"},
+			Spec: workloadsv1alpha1.ConsoleSpec{
+				User:                    "alice",
+				ConsoleTemplateRef:      corev1.LocalObjectReference{Name: "template"},
+				TTLSecondsBeforeRunning: &ttl,
+				TTLSecondsAfterFinished: &ttl,
+			},
+			Status: workloadsv1alpha1.ConsoleStatus{
+				Phase:          workloadsv1alpha1.ConsoleStopped,
+				CompletionTime: &completion,
+				ExpiryTime:     &completion,
+			},
+		}
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(workloadsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		gc = &ConsoleGarbageCollector{
+			Client: fake.NewFakeClientWithScheme(scheme, csl),
+			Log:    zap.LoggerTo(GinkgoWriter, true),
+		}
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	JustBeforeEach(func() {
+		err = gc.collect(ctx, limiter)
+	})
+
+	consoleExists := func() bool {
+		getErr := gc.Get(context.Background(), client.ObjectKey{Namespace: csl.Namespace, Name: csl.Name}, &workloadsv1alpha1.Console{})
+		if apierrors.IsNotFound(getErr) {
+			return false
+		}
+
+		Expect(getErr).NotTo(HaveOccurred())
+		return true
+	}
+
+	It("Deletes expired consoles", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consoleExists()).To(BeFalse())
+	})
+
+	Context("When the limiter fails", func() {
+		BeforeEach(func() {
+			limiter.err = errors.New("rate: Wait(n=1) exceeds limiter's burst 0")
+		})
+
+		It("Returns the error without deleting anything", func() {
+			Expect(err).To(MatchError(ContainSubstring("failed to wait for deletion rate limiter")))
+			Expect(consoleExists()).To(BeTrue())
+		})
+	})
+
+	Context("When we've been stopped", func() {
+		BeforeEach(func() {
+			cancel()
+			limiter.err = context.Canceled
+		})
+
+		It("Leaves the remaining consoles without error", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoleExists()).To(BeTrue())
+		})
+	})
+})
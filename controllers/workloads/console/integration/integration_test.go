@@ -279,6 +279,100 @@ var _ = Describe("Console", func() {
 			})
 		})
 
+		Context("with a script", func() {
+			BeforeEach(func() {
+				csl.Spec.Noninteractive = true
+				csl.Spec.Script = "echo hello\n"
+				csl.Spec.ScriptSHA256 = "not-the-real-checksum"
+			})
+
+			It("Sets console.spec.scriptSha256 from the script", func() {
+				Expect(csl.Spec.ScriptSHA256).To(Equal("5dbad7dd0b9b122dcd9956884390f4aac4738caba8ff53498a7ab6718b176c30"))
+			})
+
+			It("Mounts the script into the job and passes it to the command", func() {
+				By("Expect job was created")
+				job := &batchv1.Job{}
+
+				Eventually(func() error {
+					identifier, _ := client.ObjectKeyFromObject(csl)
+					identifier.Name += "-console"
+					return mgr.GetClient().Get(context.TODO(), identifier, job)
+				}).ShouldNot(HaveOccurred(),
+					"failed to find associated Job for Console")
+
+				By("Expect the script configmap was created")
+				configMap := &corev1.ConfigMap{}
+				identifier, _ := client.ObjectKeyFromObject(csl)
+				identifier.Name += "-script"
+				Expect(mgr.GetClient().Get(context.TODO(), identifier, configMap)).To(Succeed())
+				Expect(configMap.Data).To(HaveKeyWithValue("script", "echo hello\n"))
+
+				By("Expect the script is passed to the command")
+				container := job.Spec.Template.Spec.Containers[0]
+				Expect(container.Command).To(Equal([]string{"bin/rails"}))
+				Expect(container.Args).To(Equal([]string{"console", "--help", "/var/run/theatre/console-script/script"}))
+
+				By("Expect the script is mounted into the container")
+				Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      "console-script",
+					MountPath: "/var/run/theatre/console-script",
+					ReadOnly:  true,
+				}))
+				Expect(job.Spec.Template.Spec.Volumes).To(HaveLen(1))
+				Expect(job.Spec.Template.Spec.Volumes[0].ConfigMap.Name).To(Equal(identifier.Name))
+			})
+
+			Context("When the script is changed after the console was created", func() {
+				BeforeEach(func() {
+					consoleTemplate.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{
+						AuthorisationsRequired: 1,
+						Subjects: []rbacv1.Subject{
+							{Kind: "User", Name: "authorising-user-1@example.com"},
+						},
+					}
+				})
+
+				It("Refuses to run the changed script", func() {
+					identifier, _ := client.ObjectKeyFromObject(csl)
+
+					By("Expect console to be pending authorisation")
+					Eventually(func() workloadsv1alpha1.ConsolePhase {
+						mgr.GetClient().Get(context.TODO(), identifier, csl)
+						return csl.Status.Phase
+					}).Should(Equal(workloadsv1alpha1.ConsolePendingAuthorisation))
+
+					By("Removing the need for authorisation from the template")
+					templateIdentifier, _ := client.ObjectKeyFromObject(consoleTemplate)
+					Expect(mgr.GetClient().Get(context.TODO(), templateIdentifier, consoleTemplate)).To(Succeed())
+					consoleTemplate.Spec.DefaultAuthorisationRule.AuthorisationsRequired = 0
+					Expect(mgr.GetClient().Update(context.TODO(), consoleTemplate)).To(Succeed())
+
+					By("Changing the script")
+					Eventually(func() error {
+						if err := mgr.GetClient().Get(context.TODO(), identifier, csl); err != nil {
+							return err
+						}
+						csl.Spec.Script = "echo goodbye\n"
+						return mgr.GetClient().Update(context.TODO(), csl)
+					}).Should(Succeed())
+
+					By("Expect the script configmap and job were not created")
+					Consistently(func() bool {
+						configMapIdentifier := identifier
+						configMapIdentifier.Name += "-script"
+						err := mgr.GetClient().Get(context.TODO(), configMapIdentifier, &corev1.ConfigMap{})
+						return apierrors.IsNotFound(err)
+					}, 2*time.Second).Should(BeTrue(), "expected no script configmap")
+
+					jobIdentifier := identifier
+					jobIdentifier.Name += "-console"
+					err := mgr.GetClient().Get(context.TODO(), jobIdentifier, &batchv1.Job{})
+					Expect(apierrors.IsNotFound(err)).To(BeTrue(), "expected no job")
+				})
+			})
+		})
+
 		It("Triggers a reconcile when updating a job", func() {
 			parallelism := int32(20)
 			defaultParallelism := int32(1)
@@ -442,6 +536,103 @@ var _ = Describe("Console", func() {
 			Expect(drb.ObjectMeta.OwnerReferences[0].Name).To(Equal(csl.ObjectMeta.Name))
 		})
 
+		It("Grants participants access to the console when it's shared", func() {
+			podName := fmt.Sprintf("%s-console-abcde", consoleName)
+			jobName := fmt.Sprintf("%s-console", consoleName)
+			identifier, _ := client.ObjectKeyFromObject(csl)
+
+			By("Create a fake running pod (to simulate a real job controller)")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: namespaceName,
+					Labels:    labels.Set{"job-name": jobName},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Image: "alpine:latest",
+							Name:  "console-container-0",
+						},
+					},
+				},
+			}
+			Expect(mgr.GetClient().Create(context.TODO(), pod)).NotTo(HaveOccurred(), "failed to create fake pod")
+
+			pod.Status.Phase = corev1.PodRunning
+			Expect(mgr.GetClient().Status().Update(context.TODO(), pod)).NotTo(HaveOccurred(), "failed to update fake pod status")
+
+			Eventually(func() workloadsv1alpha1.ConsolePhase {
+				updatedCsl := &workloadsv1alpha1.Console{}
+				mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)
+				return updatedCsl.Status.Phase
+			}).Should(Equal(workloadsv1alpha1.ConsoleRunning), "console should be running")
+
+			By("Expect only the owner can update the share object")
+			shareIdentifier := client.ObjectKey{Namespace: namespaceName, Name: consoleName + "-share"}
+			Eventually(func() []rbacv1.Subject {
+				drb := &rbacv1alpha1.DirectoryRoleBinding{}
+				mgr.GetClient().Get(context.TODO(), shareIdentifier, drb)
+				return drb.Spec.Subjects
+			}).Should(ConsistOf(rbacv1.Subject{Kind: "User", Name: "user@example.com"}))
+
+			By("Share the console")
+			Eventually(func() error {
+				share := &workloadsv1alpha1.ConsoleShare{}
+				if err := mgr.GetClient().Get(context.TODO(), identifier, share); err != nil {
+					return err
+				}
+
+				share.Spec.Participants = []workloadsv1alpha1.ConsoleParticipant{
+					{Name: "pair@example.com"},
+					{Name: "viewer@example.com", ReadOnly: true},
+				}
+				return mgr.GetClient().Update(context.TODO(), share)
+			}).ShouldNot(HaveOccurred(), "failed to share console")
+
+			By("Expect the directory role binding includes the participant with full access")
+			Eventually(func() []rbacv1.Subject {
+				drb := &rbacv1alpha1.DirectoryRoleBinding{}
+				mgr.GetClient().Get(context.TODO(), identifier, drb)
+				return drb.Spec.Subjects
+			}).Should(ContainElement(rbacv1.Subject{Kind: "User", Name: "pair@example.com"}))
+
+			By("Expect read-only participants are bound to a role that can only read logs")
+			readOnlyIdentifier := client.ObjectKey{Namespace: namespaceName, Name: consoleName + "-readonly"}
+			role := &rbacv1.Role{}
+			Eventually(func() error {
+				return mgr.GetClient().Get(context.TODO(), readOnlyIdentifier, role)
+			}).ShouldNot(HaveOccurred(), "failed to find read-only role")
+
+			Expect(role.Rules).To(Equal([]rbacv1.PolicyRule{
+				{
+					Verbs:         []string{"get"},
+					APIGroups:     []string{""},
+					Resources:     []string{"pods", "pods/log"},
+					ResourceNames: []string{podName},
+				},
+			}))
+
+			Eventually(func() []rbacv1.Subject {
+				drb := &rbacv1alpha1.DirectoryRoleBinding{}
+				mgr.GetClient().Get(context.TODO(), readOnlyIdentifier, drb)
+				return drb.Spec.Subjects
+			}).Should(ConsistOf(rbacv1.Subject{Kind: "User", Name: "viewer@example.com"}))
+
+			By("Expect the participants are recorded in the status")
+			Eventually(func() []string {
+				updatedCsl := &workloadsv1alpha1.Console{}
+				mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)
+
+				names := []string{}
+				for _, participant := range updatedCsl.Status.Participants {
+					Expect(participant.JoinedTime.IsZero()).To(BeFalse(), "participant should have a joined time")
+					names = append(names, participant.Name)
+				}
+				return names
+			}).Should(ConsistOf("pair@example.com", "viewer@example.com"))
+		})
+
 		It("Updates the status with expiry time", func() {
 			updatedCsl := &workloadsv1alpha1.Console{}
 			identifier, _ := client.ObjectKeyFromObject(csl)
@@ -530,6 +721,220 @@ var _ = Describe("Console", func() {
 			Expect(csl.ObjectMeta.OwnerReferences[0].Name).To(Equal(consoleTemplate.ObjectMeta.Name))
 		})
 
+		Describe("With a pod that fails to start", func() {
+			createFailingPod := func() {
+				By("Create a fake pod that can't pull its image")
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-console-abcde", consoleName),
+						Namespace: namespaceName,
+						Labels:    labels.Set{"job-name": fmt.Sprintf("%s-console", consoleName)},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Image: "alpine:missing", Name: "console-container-0"},
+						},
+					},
+				}
+				Expect(mgr.GetClient().Create(context.TODO(), pod)).To(Succeed(), "failed to create fake pod")
+
+				pod.Status.Phase = corev1.PodPending
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+					{
+						Name: "console-container-0",
+						State: corev1.ContainerState{
+							Waiting: &corev1.ContainerStateWaiting{
+								Reason:  "ImagePullBackOff",
+								Message: `Back-off pulling image "alpine:missing"`,
+							},
+						},
+					},
+				}
+				Expect(mgr.GetClient().Status().Update(context.TODO(), pod)).To(Succeed(), "failed to update fake pod status")
+			}
+
+			getConsole := func() *workloadsv1alpha1.Console {
+				updatedCsl := &workloadsv1alpha1.Console{}
+				identifier, _ := client.ObjectKeyFromObject(csl)
+				Expect(mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)).To(Succeed())
+				return updatedCsl
+			}
+
+			It("Records the failure in the console status", func() {
+				createFailingPod()
+
+				Eventually(func() *workloadsv1alpha1.ConsolePodFailure {
+					return getConsole().Status.PodFailure
+				}).ShouldNot(BeNil(), "the pod failure should be recorded")
+
+				updatedCsl := getConsole()
+				Expect(updatedCsl.Status.PodFailure.Reason).To(Equal("ImagePullBackOff"))
+				Expect(updatedCsl.Pending()).To(BeTrue(), "the console should still be pending")
+			})
+
+			Context("When the template has a pod failure timeout", func() {
+				BeforeEach(func() {
+					timeout := int32(0)
+					consoleTemplate.Spec.PodFailureTimeoutSeconds = &timeout
+				})
+
+				It("Fails the console and deletes its job", func() {
+					createFailingPod()
+
+					Eventually(func() bool {
+						return getConsole().Failed()
+					}).Should(BeTrue(), "the console should fail")
+
+					Eventually(func() bool {
+						job := &batchv1.Job{}
+						identifier, _ := client.ObjectKeyFromObject(csl)
+						identifier.Name += "-console"
+						return apierrors.IsNotFound(mgr.GetClient().Get(context.TODO(), identifier, job))
+					}).Should(BeTrue(), "the job should be deleted")
+
+					Expect(getConsole().Status.PodFailure.Reason).To(Equal("ImagePullBackOff"))
+				})
+			})
+		})
+
+		Describe("Deleting a running console", func() {
+			BeforeEach(func() {
+				gracePeriod := int64(5)
+				consoleTemplate.Spec.TerminationGracePeriodSeconds = &gracePeriod
+			})
+
+			It("Stops the console's pod before releasing the console", func() {
+				identifier, _ := client.ObjectKeyFromObject(csl)
+				jobIdentifier := identifier
+				jobIdentifier.Name += "-console"
+
+				By("Waiting for the console's job")
+				job := &batchv1.Job{}
+				Eventually(func() error {
+					return mgr.GetClient().Get(context.TODO(), jobIdentifier, job)
+				}).Should(Succeed(), "the job should be created")
+
+				By("Create a fake pod for the job")
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-console-abcde", consoleName),
+						Namespace: namespaceName,
+						Labels:    labels.Set{"job-name": jobIdentifier.Name},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Image: "alpine:latest", Name: "console-container-0"},
+						},
+					},
+				}
+				Expect(mgr.GetClient().Create(context.TODO(), pod)).To(Succeed(), "failed to create fake pod")
+
+				By("Deleting the console")
+				Eventually(func() []string {
+					updatedCsl := &workloadsv1alpha1.Console{}
+					Expect(mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)).To(Succeed())
+					return updatedCsl.Finalizers
+				}).Should(ContainElement(workloadsv1alpha1.ConsoleFinalizer), "the console should have a finalizer")
+
+				Expect(mgr.GetClient().Delete(context.TODO(), csl)).To(Succeed())
+
+				By("Expect the console to be terminating, recording who deleted it")
+				Eventually(func() workloadsv1alpha1.ConsolePhase {
+					updatedCsl := &workloadsv1alpha1.Console{}
+					Expect(mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)).To(Succeed())
+					return updatedCsl.Status.Phase
+				}).Should(Equal(workloadsv1alpha1.ConsoleTerminating))
+
+				updatedCsl := &workloadsv1alpha1.Console{}
+				Expect(mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)).To(Succeed())
+				Expect(updatedCsl.DeletedBy()).NotTo(BeEmpty(), "the deleting user should be recorded")
+
+				By("Expect the job to be deleted, orphaning its pod")
+				Eventually(func() bool {
+					Expect(mgr.GetClient().Get(context.TODO(), jobIdentifier, job)).To(Succeed())
+					return job.DeletionTimestamp != nil
+				}).Should(BeTrue(), "the job should be deleted")
+
+				Expect(mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)).To(Succeed())
+				Expect(updatedCsl.Finalizers).To(ContainElement(workloadsv1alpha1.ConsoleFinalizer),
+					"the console should be held until the pod has stopped")
+
+				// There is no garbage collector in the test environment, so we orphan
+				// the pod ourselves
+				job.Finalizers = nil
+				Expect(mgr.GetClient().Update(context.TODO(), job)).To(Succeed())
+
+				By("Expect the pod to be stopped and the console released")
+				Eventually(func() bool {
+					return apierrors.IsNotFound(mgr.GetClient().Get(context.TODO(), client.ObjectKey{Name: pod.Name, Namespace: namespaceName}, &corev1.Pod{}))
+				}).Should(BeTrue(), "the pod should be deleted")
+
+				Eventually(func() bool {
+					return apierrors.IsNotFound(mgr.GetClient().Get(context.TODO(), identifier, &workloadsv1alpha1.Console{}))
+				}).Should(BeTrue(), "the console should be released")
+			})
+		})
+
+		Describe("With an idle timeout", func() {
+			BeforeEach(func() {
+				idleTimeout := int32(600)
+				consoleTemplate.Spec.IdleTimeoutSeconds = &idleTimeout
+			})
+
+			It("Tracks activity in the running console", func() {
+				identifier, _ := client.ObjectKeyFromObject(csl)
+
+				By("Create a fake running pod (to simulate a real job controller)")
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-console-abcde", consoleName),
+						Namespace: namespaceName,
+						Labels:    labels.Set{"job-name": fmt.Sprintf("%s-console", consoleName)},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Image: "alpine:latest", Name: "console-container-0"},
+						},
+					},
+				}
+				Expect(mgr.GetClient().Create(context.TODO(), pod)).To(Succeed(), "failed to create fake pod")
+
+				pod.Status.Phase = corev1.PodRunning
+				Expect(mgr.GetClient().Status().Update(context.TODO(), pod)).To(Succeed(), "failed to update fake pod status")
+
+				By("Expect the activity object to be created")
+				activity := &workloadsv1alpha1.ConsoleActivity{}
+				Eventually(func() error {
+					return mgr.GetClient().Get(context.TODO(), identifier, activity)
+				}).Should(Succeed(), "the activity object should be created")
+
+				By("Expect those that can attach to the console to be able to report activity")
+				Eventually(func() []rbacv1.Subject {
+					drb := &rbacv1alpha1.DirectoryRoleBinding{}
+					mgr.GetClient().Get(context.TODO(), client.ObjectKey{Namespace: namespaceName, Name: consoleName + "-activity"}, drb)
+					return drb.Spec.Subjects
+				}).Should(ContainElement(rbacv1.Subject{Kind: "User", Name: csl.Spec.User}))
+
+				By("Report activity, which is attributed to the reporter")
+				forged := metav1.NewTime(time.Now().Add(time.Hour))
+				activity.Spec.LastActivityUser = "forged-user@example.com"
+				activity.Spec.LastActivityTime = &forged
+				Expect(mgr.GetClient().Update(context.TODO(), activity)).To(Succeed())
+
+				Eventually(func() string {
+					Expect(mgr.GetClient().Get(context.TODO(), identifier, activity)).To(Succeed())
+					return activity.Spec.LastActivityUser
+				}).ShouldNot(Or(BeEmpty(), Equal("forged-user@example.com")))
+				Expect(activity.Spec.LastActivityTime.Time.Before(time.Now())).To(BeTrue(),
+					"the activity should be recorded at the time it was reported")
+
+				By("Expect the console not to be idle")
+				updatedCsl := &workloadsv1alpha1.Console{}
+				Expect(mgr.GetClient().Get(context.TODO(), identifier, updatedCsl)).To(Succeed())
+				Expect(updatedCsl.Status.IdleTime).To(BeNil())
+			})
+		})
+
 		Describe("With an authorised console", func() {
 			BeforeEach(func() {
 				consoleTemplate.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{
@@ -733,6 +1138,55 @@ var _ = Describe("Console", func() {
 
 		})
 
+		Context("when a console security profile forbids host networking", func() {
+			var profile *workloadsv1alpha1.ConsoleSecurityProfile
+
+			BeforeEach(func() {
+				consoleTemplate.Spec.Template.Spec.HostNetwork = true
+
+				// Profiles apply to the whole cluster, so we wait for the webhook to see
+				// this one and remove it afterwards
+				profile = &workloadsv1alpha1.ConsoleSecurityProfile{
+					ObjectMeta: metav1.ObjectMeta{Name: "no-host-network"},
+					Spec: workloadsv1alpha1.ConsoleSecurityProfileSpec{
+						DisallowedHostNamespaces: []workloadsv1alpha1.HostNamespace{workloadsv1alpha1.HostNetwork},
+					},
+				}
+				Expect(mgr.GetClient().Create(context.TODO(), profile)).To(Succeed())
+
+				Eventually(func() []workloadsv1alpha1.ConsoleSecurityProfile {
+					profiles := &workloadsv1alpha1.ConsoleSecurityProfileList{}
+					Expect(mgr.GetClient().List(context.TODO(), profiles)).To(Succeed())
+					return profiles.Items
+				}).Should(HaveLen(1))
+			})
+
+			AfterEach(func() {
+				Expect(mgr.GetClient().Delete(context.TODO(), profile)).To(Succeed())
+				Eventually(func() []workloadsv1alpha1.ConsoleSecurityProfile {
+					profiles := &workloadsv1alpha1.ConsoleSecurityProfileList{}
+					Expect(mgr.GetClient().List(context.TODO(), profiles)).To(Succeed())
+					return profiles.Items
+				}).Should(BeEmpty())
+			})
+
+			It("rejects a template that uses it", func() {
+				Expect(createErr).To(MatchError(ContainSubstring("pod shares the host's Network namespace")))
+			})
+
+			Context("and the template is exempt", func() {
+				BeforeEach(func() {
+					consoleTemplate.Annotations = map[string]string{
+						workloadsv1alpha1.SecurityProfileExceptionsAnnotation: workloadsv1alpha1.SecurityRuleHostNamespaces,
+					}
+				})
+
+				It("accepts the template", func() {
+					Expect(createErr).NotTo(HaveOccurred())
+				})
+			})
+		})
+
 		Context("when invalid auth rules are set", func() {
 			BeforeEach(func() {
 				consoleTemplate.Spec.AuthorisationRules = []workloadsv1alpha1.ConsoleAuthorisationRule{
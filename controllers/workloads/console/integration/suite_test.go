@@ -21,6 +21,7 @@ import (
 	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
 	consolecontroller "github.com/gocardless/theatre/v2/controllers/workloads/console"
+	"github.com/gocardless/theatre/v2/pkg/webhook"
 )
 
 var (
@@ -74,41 +75,132 @@ var _ = BeforeSuite(func(done Done) {
 
 	// console authenticator webhook
 	mgr.GetWebhookServer().Register("/mutate-consoles", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewConsoleAuthenticatorWebhook(
-			ctrl.Log.WithName("webhooks").WithName("console-authenticator"),
+		Handler: webhook.Instrument(
+			"console-authenticator",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleAuthenticatorWebhook(
+				ctrl.Log.WithName("webhooks").WithName("console-authenticator"),
+				"system:serviceaccount:theatre-system:theatre-workloads-manager",
+			),
 		),
 	})
 
 	// console authorisation webhook
 	mgr.GetWebhookServer().Register("/validate-consoleauthorisations", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewConsoleAuthorisationWebhook(
-			mgr.GetClient(),
-			ctrl.Log.WithName("webhooks").WithName("console-authorisation"),
+		Handler: webhook.Instrument(
+			"console-authorisation",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleAuthorisationWebhook(
+				mgr.GetClient(),
+				ctrl.Log.WithName("webhooks").WithName("console-authorisation"),
+			),
+		),
+	})
+
+	// console transfer log webhook
+	mgr.GetWebhookServer().Register("/mutate-consoletransferlogs", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"console-transfer-log",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleTransferLogWebhook(
+				mgr.GetClient(),
+				ctrl.Log.WithName("webhooks").WithName("console-transfer-log"),
+			),
+		),
+	})
+
+	// console activity webhook
+	mgr.GetWebhookServer().Register("/mutate-consoleactivities", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"console-activity",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleActivityWebhook(
+				ctrl.Log.WithName("webhooks").WithName("console-activity"),
+			),
+		),
+	})
+
+	// console deletion webhook
+	mgr.GetWebhookServer().Register("/validate-consoles-delete", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"console-deletion",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleDeletionWebhook(
+				mgr.GetClient(),
+				ctrl.Log.WithName("webhooks").WithName("console-deletion"),
+			),
 		),
 	})
 
 	// console template webhook
 	mgr.GetWebhookServer().Register("/validate-consoletemplates", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewConsoleTemplateValidationWebhook(
-			ctrl.Log.WithName("webhooks").WithName("console-template"),
+		Handler: webhook.Instrument(
+			"console-template",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewConsoleTemplateValidationWebhook(
+				mgr.GetClient(),
+				ctrl.Log.WithName("webhooks").WithName("console-template"),
+			),
+		),
+	})
+
+	// scheduled console webhook
+	mgr.GetWebhookServer().Register("/mutate-scheduledconsoles", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"scheduled-console",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewScheduledConsoleWebhook(
+				ctrl.Log.WithName("webhooks").WithName("scheduled-console"),
+			),
+		),
+	})
+
+	// scheduled console authorisation webhook
+	mgr.GetWebhookServer().Register("/mutate-scheduledconsoleauthorisations", &admission.Webhook{
+		Handler: webhook.Instrument(
+			"scheduled-console-authorisation",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewScheduledConsoleAuthorisationWebhook(
+				mgr.GetClient(),
+				ctrl.Log.WithName("webhooks").WithName("scheduled-console-authorisation"),
+			),
 		),
 	})
 
 	// workloads pod PriorityClass webhook
 	mgr.GetWebhookServer().Register("/mutate-pods", &admission.Webhook{
-		Handler: workloadsv1alpha1.NewPriorityInjector(
-			mgr.GetClient(),
-			ctrl.Log.WithName("webhooks").WithName("priority-injector"),
+		Handler: webhook.Instrument(
+			"priority-injector",
+			ctrl.Log.WithName("webhooks"),
+			workloadsv1alpha1.NewPriorityInjector(
+				mgr.GetClient(),
+				mgr.GetEventRecorderFor("priority-injector"),
+				ctrl.Log.WithName("webhooks").WithName("priority-injector"),
+				nil,
+			),
 		),
 	})
 
+	exec, err := consolecontroller.NewPodExecutor(mgr.GetConfig())
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&consolecontroller.ConsoleReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("console"),
 		Scheme: mgr.GetScheme(),
+		Exec:   exec,
 	}).SetupWithManager(context.TODO(), mgr)
 	Expect(err).ToNot(HaveOccurred())
 
+	// Scan frequently, so that expired consoles are deleted within the tests
+	err = mgr.Add(&consolecontroller.ConsoleGarbageCollector{
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("console-gc"),
+		Interval:           time.Second,
+		DeletionsPerSecond: consolecontroller.DefaultGCDeletionsPerSecond,
+	})
+	Expect(err).ToNot(HaveOccurred())
+
 	go func() {
 		<-ctrl.SetupSignalHandler()
 		close(finished)
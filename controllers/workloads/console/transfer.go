@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// TransferContentRetention is how long the contents of a downloaded file are kept in
+// the transfer log, for the user that requested it to read them
+const TransferContentRetention = time.Minute
+
+// PodExecutor runs commands within the containers of console pods, which is how the
+// controller copies files into and out of them
+type PodExecutor interface {
+	Exec(pod *corev1.Pod, container string, command []string, stdin io.Reader, stdout io.Writer) error
+}
+
+// NewPodExecutor returns a PodExecutor that uses the exec subresource of pods
+func NewPodExecutor(cfg *rest.Config) (PodExecutor, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &podExecutor{clientset: clientset, restconfig: cfg}, nil
+}
+
+type podExecutor struct {
+	clientset  kubernetes.Interface
+	restconfig *rest.Config
+}
+
+func (e *podExecutor) Exec(pod *corev1.Pod, container string, command []string, stdin io.Reader, stdout io.Writer) error {
+	req := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.GetNamespace()).
+		Name(pod.GetName()).
+		SubResource("exec")
+
+	req.VersionedParams(
+		&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		},
+		scheme.ParameterCodec,
+	)
+
+	remoteExecutor, err := remotecommand.NewSPDYExecutor(e.restconfig, "POST", req.URL())
+	if err != nil {
+		return errors.Wrap(err, "failed to create SPDY executor")
+	}
+
+	var stderr bytes.Buffer
+	err = remoteExecutor.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	})
+	if err != nil && stderr.Len() > 0 {
+		return errors.Wrap(err, strings.TrimSpace(stderr.String()))
+	}
+
+	return err
+}
+
+// performTransfers carries out any transfers that have been requested in the
+// transfer log, recording the outcome of each in its status. Returns how long until
+// the contents of a downloaded file should be removed, if there are any.
+func (r *ConsoleReconciler) performTransfers(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, tpl *workloadsv1alpha1.ConsoleTemplate, transferLog *workloadsv1alpha1.ConsoleTransferLog) (time.Duration, error) {
+	now := metav1.Now()
+	transfers, results := transferLog.Spec.Transfers, transferLog.Status.Results
+	changed := expireTransferContent(results, now)
+
+	if len(results) < len(transfers) {
+		pod, container, err := r.getTransferTarget(ctx, csl)
+		if err != nil {
+			return 0, err
+		}
+
+		// Only one download's contents are held at a time, to bound the size of
+		// the transfer log
+		for idx := range results {
+			results[idx].Content = nil
+		}
+
+		for idx := len(results); idx < len(transfers); idx++ {
+			result := performTransfer(r.Exec, pod, container, tpl, transfers[idx], now)
+			if result.Phase == workloadsv1alpha1.FileTransferFailed {
+				logger.Info("File transfer failed", "transfer_path", transfers[idx].Path, "error", result.Message)
+			}
+
+			results = append(results, result)
+		}
+
+		changed = true
+	}
+
+	if changed {
+		transferLog.Status.Results = results
+		if err := r.Status().Update(ctx, transferLog); err != nil {
+			return 0, errors.Wrap(err, "failed to update console transfer log status")
+		}
+	}
+
+	// The contents of uploads are only needed until we've written them, and the
+	// webhook only allows removing them once there is a result
+	contentRemoved := false
+	for idx := range transferLog.Spec.Transfers {
+		if len(transferLog.Spec.Transfers[idx].Content) > 0 {
+			transferLog.Spec.Transfers[idx].Content = nil
+			contentRemoved = true
+		}
+	}
+
+	if contentRemoved {
+		if err := r.Update(ctx, transferLog); err != nil {
+			return 0, errors.Wrap(err, "failed to remove uploaded contents from console transfer log")
+		}
+	}
+
+	return nextContentExpiry(transferLog.Status.Results, now), nil
+}
+
+// getTransferTarget returns the pod and container that files are copied into and
+// out of, which is the one that users attach to. The pod is nil if the console isn't
+// running.
+func (r *ConsoleReconciler) getTransferTarget(ctx context.Context, csl *workloadsv1alpha1.Console) (*corev1.Pod, string, error) {
+	if !csl.Running() || csl.Status.PodName == "" {
+		return nil, "", nil
+	}
+
+	pod := &corev1.Pod{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Status.PodName}, pod)
+	if apierrors.IsNotFound(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to retrieve console pod")
+	}
+
+	for _, container := range pod.Spec.Containers {
+		if csl.Spec.Noninteractive || container.TTY {
+			return pod, container.Name, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// performTransfer copies a single file into or out of the console container. The
+// template's limits are applied to, and the checksum computed from, the bytes that
+// actually pass through the controller.
+func performTransfer(exec PodExecutor, pod *corev1.Pod, container string, tpl *workloadsv1alpha1.ConsoleTemplate, transfer workloadsv1alpha1.ConsoleFileTransfer, now metav1.Time) workloadsv1alpha1.ConsoleFileTransferResult {
+	failed := func(format string, args ...interface{}) workloadsv1alpha1.ConsoleFileTransferResult {
+		return workloadsv1alpha1.ConsoleFileTransferResult{
+			Phase:          workloadsv1alpha1.FileTransferFailed,
+			Message:        fmt.Sprintf(format, args...),
+			CompletionTime: now,
+		}
+	}
+
+	// The template may have changed since the transfer was requested
+	if !tpl.FileTransferEnabled() {
+		return failed("file transfers are disabled for consoles created from template %s", tpl.Name)
+	}
+
+	if pod == nil || exec == nil {
+		return failed("console is not running")
+	}
+
+	maxBytes := tpl.MaxFileTransferBytes()
+	var content []byte
+
+	switch transfer.Direction {
+	case workloadsv1alpha1.FileTransferUpload:
+		content = transfer.Content
+		if int64(len(content)) > maxBytes {
+			return failed("file is %d bytes, which exceeds the limit of %d bytes", len(content), maxBytes)
+		}
+
+		err := exec.Exec(pod, container, shellCommand("cat > \"$1\"", transfer.Path), bytes.NewReader(content), ioutil.Discard)
+		if err != nil {
+			return failed("failed to write %s in console: %v", transfer.Path, err)
+		}
+	case workloadsv1alpha1.FileTransferDownload:
+		// Check the size before reading the file, so that we don't pull an
+		// unbounded amount of data out of the console
+		var size bytes.Buffer
+		if err := exec.Exec(pod, container, shellCommand("wc -c < \"$1\"", transfer.Path), nil, &size); err != nil {
+			return failed("failed to read %s in console: %v", transfer.Path, err)
+		}
+
+		sizeBytes, err := strconv.ParseInt(strings.TrimSpace(size.String()), 10, 64)
+		if err != nil {
+			return failed("failed to determine size of %s in console: %v", transfer.Path, err)
+		}
+
+		if sizeBytes > maxBytes {
+			return failed("file is %d bytes, which exceeds the limit of %d bytes", sizeBytes, maxBytes)
+		}
+
+		var buf bytes.Buffer
+		err = exec.Exec(pod, container, shellCommand("cat \"$1\"", transfer.Path), nil, &limitedWriter{w: &buf, remaining: maxBytes})
+		if err != nil {
+			return failed("failed to read %s in console: %v", transfer.Path, err)
+		}
+
+		content = buf.Bytes()
+	default:
+		return failed("unknown direction %s", transfer.Direction)
+	}
+
+	checksum := sha256.Sum256(content)
+	result := workloadsv1alpha1.ConsoleFileTransferResult{
+		Phase:          workloadsv1alpha1.FileTransferCompleted,
+		SizeBytes:      int64(len(content)),
+		SHA256:         hex.EncodeToString(checksum[:]),
+		CompletionTime: now,
+	}
+
+	if transfer.Direction == workloadsv1alpha1.FileTransferDownload {
+		result.Content = content
+	}
+
+	return result
+}
+
+// shellCommand runs the script with sh, passing any further arguments as positional
+// parameters so that they are never interpreted by the shell
+func shellCommand(script string, args ...string) []string {
+	return append([]string{"sh", "-c", script, "theatre-consoles"}, args...)
+}
+
+// expireTransferContent removes the contents of downloads that have been held for
+// longer than TransferContentRetention, returning whether any were removed
+func expireTransferContent(results []workloadsv1alpha1.ConsoleFileTransferResult, now metav1.Time) bool {
+	changed := false
+	for idx := range results {
+		if len(results[idx].Content) > 0 && !now.Before(&metav1.Time{Time: results[idx].CompletionTime.Add(TransferContentRetention)}) {
+			results[idx].Content = nil
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// nextContentExpiry returns how long until the contents of a download should be
+// removed, or zero if none are held
+func nextContentExpiry(results []workloadsv1alpha1.ConsoleFileTransferResult, now metav1.Time) time.Duration {
+	var next time.Duration
+	for _, result := range results {
+		if len(result.Content) == 0 {
+			continue
+		}
+
+		until := result.CompletionTime.Add(TransferContentRetention).Sub(now.Time)
+		if until <= 0 {
+			until = time.Second
+		}
+
+		if next == 0 || until < next {
+			next = until
+		}
+	}
+
+	return next
+}
+
+// limitedWriter fails once more than the given number of bytes are written, in case a
+// file grows between checking its size and reading it
+type limitedWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		return 0, errors.New("file exceeds the size limit")
+	}
+
+	l.remaining -= int64(len(p))
+	return l.w.Write(p)
+}
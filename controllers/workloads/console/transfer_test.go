@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// fakeExecutor emulates a console container holding a single file
+type fakeExecutor struct {
+	file     []byte
+	reported string
+	err      error
+	commands [][]string
+	written  []byte
+}
+
+func (f *fakeExecutor) Exec(pod *corev1.Pod, container string, command []string, stdin io.Reader, stdout io.Writer) error {
+	f.commands = append(f.commands, command)
+	if f.err != nil {
+		return f.err
+	}
+
+	switch {
+	case strings.HasPrefix(command[2], "cat >"):
+		written, err := ioutil.ReadAll(stdin)
+		f.written = written
+		return err
+	case strings.HasPrefix(command[2], "wc -c"):
+		_, err := io.WriteString(stdout, f.reported)
+		return err
+	default:
+		_, err := stdout.Write(f.file)
+		return err
+	}
+}
+
+var _ = Describe("performTransfer", func() {
+	var (
+		exec     *fakeExecutor
+		pod      *corev1.Pod
+		tpl      *workloadsv1alpha1.ConsoleTemplate
+		transfer workloadsv1alpha1.ConsoleFileTransfer
+		now      metav1.Time
+		result   workloadsv1alpha1.ConsoleFileTransferResult
+	)
+
+	checksum := func(content []byte) string {
+		sum := sha256.Sum256(content)
+		return hex.EncodeToString(sum[:])
+	}
+
+	BeforeEach(func() {
+		exec = &fakeExecutor{file: []byte("id,amount\n1,100\n"), reported: "16\n"}
+		pod = &corev1.Pod{}
+		tpl = &workloadsv1alpha1.ConsoleTemplate{}
+		now = metav1.NewTime(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	})
+
+	JustBeforeEach(func() {
+		result = performTransfer(exec, pod, "console-container-0", tpl, transfer, now)
+	})
+
+	Context("Uploading a file", func() {
+		BeforeEach(func() {
+			transfer = workloadsv1alpha1.ConsoleFileTransfer{
+				Direction: workloadsv1alpha1.FileTransferUpload,
+				Path:      "/tmp/input.csv",
+				Content:   []byte("some input"),
+			}
+		})
+
+		It("Writes the contents to the path in the console", func() {
+			Expect(exec.written).To(Equal([]byte("some input")))
+			Expect(exec.commands[0]).To(ContainElement("/tmp/input.csv"))
+		})
+
+		It("Records the size and checksum of what was written", func() {
+			Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferCompleted))
+			Expect(result.SizeBytes).To(BeEquivalentTo(10))
+			Expect(result.SHA256).To(Equal(checksum([]byte("some input"))))
+			Expect(result.Content).To(BeEmpty())
+			Expect(result.CompletionTime).To(Equal(now))
+		})
+
+		Context("When the contents exceed the template's limit", func() {
+			BeforeEach(func() {
+				maxSizeBytes := int64(4)
+				tpl.Spec.FileTransfer = &workloadsv1alpha1.ConsoleFileTransferPolicy{MaxSizeBytes: &maxSizeBytes}
+			})
+
+			It("Fails without writing anything", func() {
+				Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferFailed))
+				Expect(result.Message).To(ContainSubstring("exceeds the limit of 4 bytes"))
+				Expect(exec.commands).To(BeEmpty())
+			})
+		})
+
+		Context("When the template has since disabled transfers", func() {
+			BeforeEach(func() {
+				tpl.Spec.FileTransfer = &workloadsv1alpha1.ConsoleFileTransferPolicy{Disabled: true}
+			})
+
+			It("Fails without writing anything", func() {
+				Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferFailed))
+				Expect(result.Message).To(ContainSubstring("file transfers are disabled"))
+				Expect(exec.commands).To(BeEmpty())
+			})
+		})
+
+		Context("When the console is no longer running", func() {
+			BeforeEach(func() {
+				pod = nil
+			})
+
+			It("Fails", func() {
+				Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferFailed))
+				Expect(result.Message).To(Equal("console is not running"))
+			})
+		})
+
+		Context("When the write fails", func() {
+			BeforeEach(func() {
+				exec.err = errors.New("permission denied")
+			})
+
+			It("Records the error", func() {
+				Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferFailed))
+				Expect(result.Message).To(ContainSubstring("permission denied"))
+			})
+		})
+	})
+
+	Context("Downloading a file", func() {
+		BeforeEach(func() {
+			transfer = workloadsv1alpha1.ConsoleFileTransfer{
+				Direction: workloadsv1alpha1.FileTransferDownload,
+				Path:      "/tmp/report.csv",
+			}
+		})
+
+		It("Returns the contents, with their size and checksum", func() {
+			Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferCompleted))
+			Expect(result.Content).To(Equal(exec.file))
+			Expect(result.SizeBytes).To(BeEquivalentTo(len(exec.file)))
+			Expect(result.SHA256).To(Equal(checksum(exec.file)))
+		})
+
+		Context("When the file exceeds the template's limit", func() {
+			BeforeEach(func() {
+				maxSizeBytes := int64(8)
+				tpl.Spec.FileTransfer = &workloadsv1alpha1.ConsoleFileTransferPolicy{MaxSizeBytes: &maxSizeBytes}
+			})
+
+			It("Fails without reading the file", func() {
+				Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferFailed))
+				Expect(result.Message).To(ContainSubstring("16 bytes, which exceeds the limit of 8 bytes"))
+				Expect(exec.commands).To(HaveLen(1))
+			})
+		})
+
+		Context("When the file grows after its size is checked", func() {
+			BeforeEach(func() {
+				maxSizeBytes := int64(8)
+				tpl.Spec.FileTransfer = &workloadsv1alpha1.ConsoleFileTransferPolicy{MaxSizeBytes: &maxSizeBytes}
+				exec.reported = "8\n"
+			})
+
+			It("Fails", func() {
+				Expect(result.Phase).To(Equal(workloadsv1alpha1.FileTransferFailed))
+				Expect(result.Message).To(ContainSubstring("exceeds the size limit"))
+				Expect(result.Content).To(BeEmpty())
+			})
+		})
+	})
+})
+
+var _ = Describe("Transfer content retention", func() {
+	var (
+		now     metav1.Time
+		results []workloadsv1alpha1.ConsoleFileTransferResult
+	)
+
+	BeforeEach(func() {
+		now = metav1.NewTime(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+		results = []workloadsv1alpha1.ConsoleFileTransferResult{
+			{Content: []byte("old"), CompletionTime: metav1.NewTime(now.Add(-2 * time.Minute))},
+			{Content: []byte("new"), CompletionTime: metav1.NewTime(now.Add(-20 * time.Second))},
+			{CompletionTime: metav1.NewTime(now.Add(-10 * time.Second))},
+		}
+	})
+
+	It("Removes contents held for longer than the retention period", func() {
+		Expect(expireTransferContent(results, now)).To(BeTrue())
+		Expect(results[0].Content).To(BeEmpty())
+		Expect(results[1].Content).To(Equal([]byte("new")))
+	})
+
+	It("Requeues for when the remaining contents should be removed", func() {
+		expireTransferContent(results, now)
+		Expect(nextContentExpiry(results, now)).To(Equal(40 * time.Second))
+	})
+
+	It("Doesn't requeue once no contents are held", func() {
+		Expect(nextContentExpiry(results[2:], now)).To(BeZero())
+	})
+})
@@ -0,0 +1,617 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rbacv1alpha1 "github.com/gocardless/theatre/v2/apis/rbac/v1alpha1"
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/cron"
+	"github.com/gocardless/theatre/v2/pkg/logging"
+	"github.com/gocardless/theatre/v2/pkg/recutil"
+)
+
+const (
+	// Resource-level events
+
+	EventDelete           = "Delete"
+	EventSuccessfulCreate = "SuccessfulCreate"
+	EventSuccessfulUpdate = "SuccessfulUpdate"
+	EventNoCreateOrUpdate = "NoCreateOrUpdate"
+
+	// Warning events
+
+	EventUnknownOutcome       = "UnknownOutcome"
+	EventInvalidSpecification = "InvalidSpecification"
+
+	// Scheduled console log keys
+
+	ScheduledConsoleCreated = "ScheduledConsoleCreated"
+	ScheduledConsoleSkipped = "ScheduledConsoleSkipped"
+
+	// Reasons given with ScheduledConsoleSkipped, for why the console wasn't created
+
+	SkipReasonSuspended       = "suspended"
+	SkipReasonAlreadyRunning  = "already-running"
+	SkipReasonMissedDeadline  = "missed-deadline"
+	SkipReasonCreateForbidden = "create-forbidden"
+	SkipReasonTooManyMissed   = "too-many-missed"
+
+	Console                       = "console"
+	ScheduledConsoleAuthorisation = "scheduledconsoleauthorisation"
+	Role                          = "role"
+	DirectoryRoleBinding          = "directoryrolebinding"
+)
+
+type ScheduledConsoleReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+func (r *ScheduledConsoleReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	logger := r.Log.WithValues("component", "ScheduledConsole")
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&workloadsv1alpha1.ScheduledConsole{}).
+		// Consoles are only owned by, rather than controlled by, the scheduled console
+		Watches(
+			&source.Kind{Type: &workloadsv1alpha1.Console{}},
+			&handler.EnqueueRequestForOwner{
+				IsController: false,
+				OwnerType:    &workloadsv1alpha1.ScheduledConsole{},
+			},
+		).
+		Complete(
+			recutil.ResolveAndReconcile(
+				ctx, logger, mgr, &workloadsv1alpha1.ScheduledConsole{},
+				func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error) {
+					return r.Reconcile(logger, ctx, request, obj.(*workloadsv1alpha1.ScheduledConsole))
+				},
+			),
+		)
+}
+
+func (r *ScheduledConsoleReconciler) Reconcile(logger logr.Logger, ctx context.Context, req ctrl.Request, sc *workloadsv1alpha1.ScheduledConsole) (ctrl.Result, error) {
+	logger = logger.WithValues("scheduled_console", req.NamespacedName)
+
+	// The webhook rejects schedules that can't be parsed, so there's nothing we can
+	// do with one until it's fixed
+	schedule, err := cron.Parse(sc.Spec.Schedule)
+	if err != nil {
+		msg := fmt.Sprintf("Invalid schedule: %v", err)
+		logger.Info(msg, "event", EventInvalidSpecification, "error", msg)
+		return ctrl.Result{}, nil
+	}
+
+	if sc.Spec.PreApproval != nil {
+		if err := r.createAuthorisationObjects(ctx, logger, sc); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var consoleList workloadsv1alpha1.ConsoleList
+	inNamespace := client.InNamespace(req.Namespace)
+	matchLabels := client.MatchingLabels(map[string]string{workloadsv1alpha1.ScheduledConsoleLabel: sc.Name})
+	if err := r.List(ctx, &consoleList, inNamespace, matchLabels); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list consoles")
+	}
+
+	now := time.Now()
+	consoles := classifyConsoles(sc, consoleList.Items, now)
+
+	// Remove the oldest finished consoles beyond the history limits
+	expired := append(
+		consolesBeyondLimit(consoles.Successful, sc.SuccessfulConsolesHistoryLimit()),
+		consolesBeyondLimit(consoles.Failed, sc.FailedConsolesHistoryLimit())...,
+	)
+	for _, csl := range expired {
+		if err := r.deleteConsole(ctx, logger, csl, "history-limit"); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := sc.Status.DeepCopy()
+	active := consoles.Active
+
+	scheduledTime, missedDeadline, err := mostRecentScheduleTime(schedule, sc, now)
+	if err != nil {
+		msg := fmt.Sprintf("Unable to determine when the latest console was due, set or decrease startingDeadlineSeconds: %v", err)
+		logger.Info(msg, "event", ScheduledConsoleSkipped, "reason", SkipReasonTooManyMissed, "error", msg)
+	} else if !scheduledTime.IsZero() {
+		active, err = r.runScheduled(ctx, logger, sc, scheduledTime, missedDeadline, active)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// Suspending a scheduled console leaves the console that is due to be
+		// created once it's resumed, subject to the starting deadline
+		if !sc.Spec.Suspend {
+			status.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+		}
+	}
+
+	status.Active = consoleReferences(active)
+
+	if !reflect.DeepEqual(status, &sc.Status) {
+		sc.Status = *status
+		if err := r.Status().Update(ctx, sc); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to update scheduled console status")
+		}
+	}
+
+	// Finished consoles are reported by the console watcher, but nothing tells us
+	// when the next console is due
+	next := schedule.Next(now.UTC())
+	if next.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	return requeueAfterInterval(logger, next.Sub(now)), nil
+}
+
+// runScheduled creates the console that was due at the scheduled time, subject to
+// the concurrency policy, returning the consoles that are active afterwards.
+func (r *ScheduledConsoleReconciler) runScheduled(ctx context.Context, logger logr.Logger, sc *workloadsv1alpha1.ScheduledConsole, scheduledTime time.Time, missedDeadline bool, active []*workloadsv1alpha1.Console) ([]*workloadsv1alpha1.Console, error) {
+	csl := sc.BuildConsole(scheduledTime)
+	logger = logger.WithValues("console_name", csl.Name, "scheduled_time", scheduledTime, "user", sc.Spec.User)
+
+	switch {
+	case sc.Spec.Suspend:
+		logging.WithNoRecord(logger).Info("Scheduled console is suspended", "event", ScheduledConsoleSkipped, "reason", SkipReasonSuspended)
+		return active, nil
+	case missedDeadline:
+		logger.Info("Console was due longer ago than the starting deadline", "event", ScheduledConsoleSkipped, "reason", SkipReasonMissedDeadline)
+		return active, nil
+	}
+
+	for _, existing := range active {
+		if existing.Name == csl.Name {
+			// We've already created this console
+			return active, nil
+		}
+	}
+
+	if len(active) > 0 {
+		switch sc.GetConcurrencyPolicy() {
+		case workloadsv1alpha1.ForbidConcurrent:
+			logger.Info("Previous console is still running", "event", ScheduledConsoleSkipped, "reason", SkipReasonAlreadyRunning)
+			return active, nil
+		case workloadsv1alpha1.ReplaceConcurrent:
+			for _, existing := range active {
+				if err := r.deleteConsole(ctx, logger, existing, "replaced"); err != nil {
+					return nil, err
+				}
+			}
+
+			active = nil
+		}
+	}
+
+	// The owner of the scheduled console must be able to create the console
+	// themselves. We create it on their behalf, and the authenticator webhook
+	// keeps them as its user.
+	allowed, reason, err := r.userCanCreateConsole(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed {
+		msg := fmt.Sprintf("User %s is not permitted to create the console: %s", sc.Spec.User, reason)
+		logger.Info(msg, "event", ScheduledConsoleSkipped, "reason", SkipReasonCreateForbidden, "error", msg)
+		return active, nil
+	}
+
+	if err := r.Create(ctx, csl); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			// We created it before failing to update our status, or it has since
+			// finished and been cleaned up by the history limits
+			return active, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to create console")
+	}
+
+	logger.Info(fmt.Sprintf("Created %s: %s", Console, csl.Name), "event", ScheduledConsoleCreated)
+	return append(active, csl), nil
+}
+
+// userCanCreateConsole asks the API server whether the owner of the scheduled
+// console, with the groups they had when they last changed it, may create consoles
+// in its namespace. It also returns the reason given for the decision, if any.
+func (r *ScheduledConsoleReconciler) userCanCreateConsole(ctx context.Context, sc *workloadsv1alpha1.ScheduledConsole) (bool, string, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   sc.Spec.User,
+			Groups: sc.Spec.UserGroups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: sc.Namespace,
+				Verb:      "create",
+				Group:     workloadsv1alpha1.GroupVersion.Group,
+				Resource:  "consoles",
+			},
+		},
+	}
+
+	if err := r.Create(ctx, review); err != nil {
+		return false, "", errors.Wrap(err, "failed to review whether user can create consoles")
+	}
+
+	return review.Status.Allowed, review.Status.Reason, nil
+}
+
+func (r *ScheduledConsoleReconciler) deleteConsole(ctx context.Context, logger logr.Logger, csl *workloadsv1alpha1.Console, reason string) error {
+	logger.Info("Deleting console", "event", EventDelete, "kind", Console, "console_name", csl.Name, "reason", reason)
+	err := r.Delete(ctx, csl, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete console")
+	}
+
+	return nil
+}
+
+// createAuthorisationObjects creates the object that authorisers update to approve
+// the scheduled console ahead of time, along with the RBAC resources that allow
+// those who could authorise its consoles to do so. Nobody can approve it if its
+// consoles don't require authorisation.
+func (r *ScheduledConsoleReconciler) createAuthorisationObjects(ctx context.Context, logger logr.Logger, sc *workloadsv1alpha1.ScheduledConsole) error {
+	tpl := &workloadsv1alpha1.ConsoleTemplate{}
+	tplName := types.NamespacedName{Namespace: sc.Namespace, Name: sc.Spec.ConsoleTemplate.ConsoleTemplateRef.Name}
+	if err := r.Get(ctx, tplName, tpl); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return errors.Wrap(err, "failed to retrieve console template")
+	}
+
+	if !tpl.HasAuthorisationRules() {
+		return nil
+	}
+
+	command, err := sc.BuildConsole(time.Time{}).GetCommand(tpl)
+	if err != nil {
+		return errors.Wrap(err, "neither the scheduled console or template have a command to evaluate")
+	}
+
+	rule, err := tpl.GetAuthorisationRuleForCommand(command)
+	if err != nil {
+		return errors.Wrap(err, "failed to determine authorisation rule for console command")
+	}
+
+	authorisation := &workloadsv1alpha1.ScheduledConsoleAuthorisation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sc.Name,
+			Namespace: sc.Namespace,
+			Labels:    sc.Labels,
+		},
+		Spec: workloadsv1alpha1.ScheduledConsoleAuthorisationSpec{
+			ScheduledConsoleRef: corev1.LocalObjectReference{Name: sc.Name},
+			Approvals:           []workloadsv1alpha1.ScheduledConsoleApproval{},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, sc, authorisation, ScheduledConsoleAuthorisation, authorisationDiff); err != nil {
+		return errors.Wrap(err, "failed to create scheduledconsoleauthorisation")
+	}
+
+	roleName, err := r.authorisationRoleName(ctx, logger, sc)
+	if err != nil {
+		return err
+	}
+
+	rbacName := types.NamespacedName{Name: roleName, Namespace: sc.Namespace}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName.Name,
+			Namespace: rbacName.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:         []string{"get", "patch", "update"},
+				APIGroups:     []string{"workloads.crd.gocardless.com"},
+				Resources:     []string{"scheduledconsoleauthorisations"},
+				ResourceNames: []string{sc.Name},
+			},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, sc, role, Role, recutil.RoleDiff); err != nil {
+		return errors.Wrap(err, "failed to create role for scheduledconsoleauthorisation")
+	}
+
+	drb := &rbacv1alpha1.DirectoryRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName.Name,
+			Namespace: rbacName.Namespace,
+		},
+		Spec: rbacv1alpha1.DirectoryRoleBindingSpec{
+			Subjects: rule.Subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     rbacName.Name,
+			},
+		},
+	}
+
+	if err := r.createOrUpdate(ctx, logger, sc, drb, DirectoryRoleBinding, recutil.DirectoryRoleBindingDiff); err != nil {
+		return errors.Wrap(err, "failed to create directory rolebinding for scheduledconsoleauthorisation")
+	}
+
+	return nil
+}
+
+// authorisationRoleName returns the name of the role and directory role binding
+// that permit authorisers to approve the scheduled console. Consoles create RBAC
+// objects named after themselves, with and without various suffixes, so any name
+// that we derived from our own could be shared by a console named to match.
+// Instead we generate a name when we first create the role, and record it in our
+// status. If the role has since been removed, or isn't ours, we generate another.
+func (r *ScheduledConsoleReconciler) authorisationRoleName(ctx context.Context, logger logr.Logger, sc *workloadsv1alpha1.ScheduledConsole) (string, error) {
+	if name := sc.Status.AuthorisationRoleName; name != "" {
+		existing := &rbacv1.Role{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: sc.Namespace}, existing)
+		switch {
+		case err == nil && metav1.IsControlledBy(existing, sc):
+			return name, nil
+		case err != nil && !apierrors.IsNotFound(err):
+			return "", errors.Wrap(err, "failed to retrieve role for scheduledconsoleauthorisation")
+		}
+	}
+
+	// The rules are added once we know the name, along with the rest of the
+	// authorisation objects
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-%s-", sc.Name, "authorisation"),
+			Namespace:    sc.Namespace,
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(sc, role, r.Scheme); err != nil {
+		return "", err
+	}
+
+	if err := r.Create(ctx, role); err != nil {
+		return "", errors.Wrap(err, "failed to create role for scheduledconsoleauthorisation")
+	}
+
+	logger.Info(fmt.Sprintf("Created %s: %s", Role, role.Name), "event", EventSuccessfulCreate)
+
+	// Record the name before doing anything else, so that we don't create another
+	// role if we fail to create the rest of the objects
+	sc.Status.AuthorisationRoleName = role.Name
+	if err := r.Status().Update(ctx, sc); err != nil {
+		return "", errors.Wrap(err, "failed to update scheduled console status")
+	}
+
+	return role.Name, nil
+}
+
+func (r *ScheduledConsoleReconciler) createOrUpdate(ctx context.Context, logger logr.Logger, sc *workloadsv1alpha1.ScheduledConsole, expected recutil.ObjWithMeta, kind string, diffFunc recutil.DiffFunc) error {
+	if err := controllerutil.SetControllerReference(sc, expected, r.Scheme); err != nil {
+		return err
+	}
+
+	outcome, err := recutil.CreateOrUpdate(ctx, r, expected, diffFunc)
+	if err != nil {
+		return errors.Wrap(err, "CreateOrUpdate failed")
+	}
+
+	objDesc := fmt.Sprintf("%s: %s", kind, expected.GetName())
+
+	switch outcome {
+	case recutil.Create:
+		logger.Info("Created "+objDesc, "event", EventSuccessfulCreate)
+	case recutil.Update:
+		logger.Info("Updated "+objDesc, "event", EventSuccessfulUpdate)
+	case recutil.None:
+		logging.WithNoRecord(logger).Info(
+			"Nothing to do for "+objDesc,
+			"event", EventNoCreateOrUpdate,
+		)
+	default:
+		msg := fmt.Sprintf("Unknown outcome %s for %s", outcome, objDesc)
+		logger.Info(
+			msg,
+			"event", EventUnknownOutcome,
+			"error", msg,
+		)
+	}
+
+	return nil
+}
+
+// authorisationDiff is a reconcile.DiffFunc for ScheduledConsoleAuthorisations
+func authorisationDiff(expectedObj runtime.Object, existingObj runtime.Object) recutil.Outcome {
+	expected := expectedObj.(*workloadsv1alpha1.ScheduledConsoleAuthorisation)
+	existing := existingObj.(*workloadsv1alpha1.ScheduledConsoleAuthorisation)
+	operation := recutil.None
+
+	if !reflect.DeepEqual(expected.ObjectMeta.Labels, existing.ObjectMeta.Labels) {
+		existing.ObjectMeta.Labels = expected.ObjectMeta.Labels
+		operation = recutil.Update
+	}
+
+	// The approvals are left to the authorising users
+	if !reflect.DeepEqual(expected.Spec.ScheduledConsoleRef, existing.Spec.ScheduledConsoleRef) {
+		existing.Spec.ScheduledConsoleRef = expected.Spec.ScheduledConsoleRef
+		operation = recutil.Update
+	}
+
+	return operation
+}
+
+// maxMissedScheduleTimes is how many times a console can have been due since we
+// last handled the scheduled console before we give up looking for the latest, as
+// CronJobs do. A frequent schedule that has been suspended or unhandled for a long
+// time could otherwise have us walk through a great many of them.
+const maxMissedScheduleTimes = 100
+
+var errTooManyMissedScheduleTimes = errors.Errorf("more than %d missed schedule times", maxMissedScheduleTimes)
+
+// mostRecentScheduleTime returns the latest time at which a console was due that
+// we're yet to handle, or the zero time if there is none. Only the latest is
+// created when several were missed. It also returns whether that time is longer ago
+// than the starting deadline allows.
+func mostRecentScheduleTime(schedule *cron.Schedule, sc *workloadsv1alpha1.ScheduledConsole, now time.Time) (time.Time, bool, error) {
+	earliest := sc.CreationTimestamp.Time
+	if sc.Status.LastScheduleTime != nil {
+		earliest = sc.Status.LastScheduleTime.Time
+	}
+
+	// Schedules are interpreted in UTC
+	last, err := lastScheduleTimeBefore(schedule, earliest.UTC(), now.UTC())
+	if err == errTooManyMissedScheduleTimes && sc.Spec.StartingDeadlineSeconds != nil {
+		// Anything due before the starting deadline would be missed anyway, so we
+		// only need to look for a console that is due within it
+		deadline := time.Duration(*sc.Spec.StartingDeadlineSeconds) * time.Second
+		last, err = lastScheduleTimeBefore(schedule, now.Add(-deadline).UTC(), now.UTC())
+	}
+
+	if err != nil || last.IsZero() {
+		return time.Time{}, false, err
+	}
+
+	missedDeadline := sc.Spec.StartingDeadlineSeconds != nil &&
+		now.Sub(last) > time.Duration(*sc.Spec.StartingDeadlineSeconds)*time.Second
+
+	return last, missedDeadline, nil
+}
+
+// lastScheduleTimeBefore returns the last time the schedule was due after the
+// earliest time, up to and including the latest, or the zero time if there was
+// none. It gives up if the schedule was due more than maxMissedScheduleTimes times.
+func lastScheduleTimeBefore(schedule *cron.Schedule, earliest, latest time.Time) (time.Time, error) {
+	var last time.Time
+	missed := 0
+	for t := schedule.Next(earliest); !t.IsZero() && !t.After(latest); t = schedule.Next(t) {
+		if missed++; missed > maxMissedScheduleTimes {
+			return time.Time{}, errTooManyMissedScheduleTimes
+		}
+
+		last = t
+	}
+
+	return last, nil
+}
+
+// scheduledConsoles groups the consoles created by a scheduled console by whether
+// they're yet to finish, or how they finished
+type scheduledConsoles struct {
+	Active     []*workloadsv1alpha1.Console
+	Successful []*workloadsv1alpha1.Console
+	Failed     []*workloadsv1alpha1.Console
+}
+
+// classifyConsoles groups the consoles that were created by the scheduled console,
+// ignoring any that are being deleted. Consoles that expired before they were
+// authorised are considered to have failed.
+func classifyConsoles(sc *workloadsv1alpha1.ScheduledConsole, consoles []workloadsv1alpha1.Console, now time.Time) scheduledConsoles {
+	var grouped scheduledConsoles
+	for idx := range consoles {
+		csl := &consoles[idx]
+
+		// Anyone can label their console as if it were ours, so we only consider
+		// those that we own
+		if !ownedBy(csl, sc) || !csl.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		switch {
+		case csl.Stopped() && csl.Status.CompletionTime != nil:
+			grouped.Successful = append(grouped.Successful, csl)
+		case csl.PostRunning():
+			grouped.Failed = append(grouped.Failed, csl)
+		case csl.PendingAuthorisation() && expiredBefore(csl, now):
+			grouped.Failed = append(grouped.Failed, csl)
+		default:
+			grouped.Active = append(grouped.Active, csl)
+		}
+	}
+
+	return grouped
+}
+
+// expiredBefore returns whether the console's TTL had elapsed by the given time.
+// Consoles are only given their TTLs once they've been reconciled.
+func expiredBefore(csl *workloadsv1alpha1.Console, now time.Time) bool {
+	if csl.Spec.TTLSecondsBeforeRunning == nil || csl.Spec.TTLSecondsAfterFinished == nil {
+		return false
+	}
+
+	gcTime := csl.GetGCTime()
+	return gcTime != nil && gcTime.Before(now)
+}
+
+func ownedBy(csl *workloadsv1alpha1.Console, sc *workloadsv1alpha1.ScheduledConsole) bool {
+	for _, ref := range csl.OwnerReferences {
+		if ref.UID == sc.UID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// consolesBeyondLimit returns the oldest consoles beyond the number we keep
+func consolesBeyondLimit(consoles []*workloadsv1alpha1.Console, limit int) []*workloadsv1alpha1.Console {
+	if len(consoles) <= limit {
+		return nil
+	}
+
+	sorted := append([]*workloadsv1alpha1.Console{}, consoles...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return createdBefore(sorted[j], sorted[i])
+	})
+
+	return sorted[limit:]
+}
+
+// createdBefore orders consoles by when they were created, falling back to their
+// names so that the order is stable
+func createdBefore(a, b *workloadsv1alpha1.Console) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.Name < b.Name
+}
+
+func consoleReferences(consoles []*workloadsv1alpha1.Console) []corev1.LocalObjectReference {
+	var refs []corev1.LocalObjectReference
+	for _, csl := range consoles {
+		refs = append(refs, corev1.LocalObjectReference{Name: csl.Name})
+	}
+
+	return refs
+}
+
+func requeueAfterInterval(logger logr.Logger, interval time.Duration) reconcile.Result {
+	logging.WithNoRecord(logger).Info(
+		"Reconciliation requeued",
+		"event", recutil.EventRequeued,
+		"reconcile_after", interval,
+	)
+	return reconcile.Result{Requeue: true, RequeueAfter: interval}
+}
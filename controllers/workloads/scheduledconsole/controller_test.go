@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/cron"
+)
+
+var _ = Describe("Scheduled consoles", func() {
+	var (
+		now time.Time
+		sc  *workloadsv1alpha1.ScheduledConsole
+	)
+
+	BeforeEach(func() {
+		// Saturday
+		now = time.Date(2020, 1, 4, 2, 30, 0, 0, time.UTC)
+
+		sc = &workloadsv1alpha1.ScheduledConsole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "maintenance",
+				Namespace:         "default",
+				UID:               types.UID("scheduled-console-uid"),
+				CreationTimestamp: metav1.NewTime(now.Add(-30 * 24 * time.Hour)),
+			},
+			Spec: workloadsv1alpha1.ScheduledConsoleSpec{
+				User:     "owner@example.com",
+				Schedule: "0 2 * * *",
+			},
+		}
+	})
+
+	Describe("mostRecentScheduleTime", func() {
+		var (
+			scheduledTime  time.Time
+			missedDeadline bool
+			err            error
+		)
+
+		JustBeforeEach(func() {
+			schedule, parseErr := cron.Parse(sc.Spec.Schedule)
+			Expect(parseErr).NotTo(HaveOccurred())
+
+			scheduledTime, missedDeadline, err = mostRecentScheduleTime(schedule, sc, now)
+		})
+
+		It("Returns the latest time that the console was due", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(scheduledTime).To(Equal(time.Date(2020, 1, 4, 2, 0, 0, 0, time.UTC)))
+			Expect(missedDeadline).To(BeFalse())
+		})
+
+		Context("When the console has already been handled", func() {
+			BeforeEach(func() {
+				last := metav1.NewTime(time.Date(2020, 1, 4, 2, 0, 0, 0, time.UTC))
+				sc.Status.LastScheduleTime = &last
+			})
+
+			It("Returns the zero time", func() {
+				Expect(scheduledTime.IsZero()).To(BeTrue())
+			})
+		})
+
+		Context("When the scheduled console was created after the console was due", func() {
+			BeforeEach(func() {
+				sc.CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+			})
+
+			It("Returns the zero time", func() {
+				Expect(scheduledTime.IsZero()).To(BeTrue())
+			})
+		})
+
+		Context("When the console was due longer ago than the starting deadline", func() {
+			BeforeEach(func() {
+				deadline := int64(600)
+				sc.Spec.StartingDeadlineSeconds = &deadline
+			})
+
+			It("Reports that it missed the deadline", func() {
+				Expect(scheduledTime).To(Equal(time.Date(2020, 1, 4, 2, 0, 0, 0, time.UTC)))
+				Expect(missedDeadline).To(BeTrue())
+			})
+		})
+
+		Context("When the console was due within the starting deadline", func() {
+			BeforeEach(func() {
+				deadline := int64(3600)
+				sc.Spec.StartingDeadlineSeconds = &deadline
+			})
+
+			It("Reports that it can still be created", func() {
+				Expect(missedDeadline).To(BeFalse())
+			})
+		})
+
+		Context("When the console has been due too many times since it was last handled", func() {
+			BeforeEach(func() {
+				sc.Spec.Schedule = "*/5 * * * *"
+			})
+
+			It("Gives up", func() {
+				Expect(err).To(Equal(errTooManyMissedScheduleTimes))
+				Expect(scheduledTime.IsZero()).To(BeTrue())
+			})
+
+			Context("With a starting deadline", func() {
+				BeforeEach(func() {
+					deadline := int64(600)
+					sc.Spec.StartingDeadlineSeconds = &deadline
+				})
+
+				It("Returns the latest time that the console was due within the deadline", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(scheduledTime).To(Equal(time.Date(2020, 1, 4, 2, 30, 0, 0, time.UTC)))
+					Expect(missedDeadline).To(BeFalse())
+				})
+			})
+
+			Context("With a starting deadline that every time was missed by", func() {
+				BeforeEach(func() {
+					sc.Spec.Schedule = "*/5 2 * * *"
+					now = now.Add(time.Hour)
+
+					deadline := int64(600)
+					sc.Spec.StartingDeadlineSeconds = &deadline
+				})
+
+				It("Returns the zero time", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(scheduledTime.IsZero()).To(BeTrue())
+				})
+			})
+		})
+	})
+
+	Describe("classifyConsoles", func() {
+		var consoles []workloadsv1alpha1.Console
+
+		build := func(name string, phase workloadsv1alpha1.ConsolePhase) workloadsv1alpha1.Console {
+			csl := sc.BuildConsole(now)
+			csl.Name = name
+			csl.Status.Phase = phase
+			return *csl
+		}
+
+		BeforeEach(func() {
+			ttl := int32(3600)
+			completed := metav1.NewTime(now.Add(-time.Minute))
+
+			running := build("running", workloadsv1alpha1.ConsoleRunning)
+
+			successful := build("successful", workloadsv1alpha1.ConsoleStopped)
+			successful.Status.CompletionTime = &completed
+
+			expired := build("expired", workloadsv1alpha1.ConsoleStopped)
+			failed := build("failed", workloadsv1alpha1.ConsoleFailed)
+
+			unauthorised := build("unauthorised", workloadsv1alpha1.ConsolePendingAuthorisation)
+			unauthorised.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+			unauthorised.Spec.TTLSecondsBeforeRunning = &ttl
+			unauthorised.Spec.TTLSecondsAfterFinished = &ttl
+
+			pending := build("pending", workloadsv1alpha1.ConsolePendingAuthorisation)
+			pending.CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+			pending.Spec.TTLSecondsBeforeRunning = &ttl
+			pending.Spec.TTLSecondsAfterFinished = &ttl
+
+			forged := build("forged", workloadsv1alpha1.ConsoleRunning)
+			forged.OwnerReferences = nil
+
+			deleting := build("deleting", workloadsv1alpha1.ConsoleRunning)
+			deletionTime := metav1.NewTime(now)
+			deleting.DeletionTimestamp = &deletionTime
+
+			consoles = []workloadsv1alpha1.Console{
+				running, successful, expired, failed, unauthorised, pending, forged, deleting,
+			}
+		})
+
+		names := func(consoles []*workloadsv1alpha1.Console) []string {
+			var names []string
+			for _, csl := range consoles {
+				names = append(names, csl.Name)
+			}
+			return names
+		}
+
+		It("Groups the consoles that we own", func() {
+			grouped := classifyConsoles(sc, consoles, now)
+
+			Expect(names(grouped.Active)).To(ConsistOf("running", "pending"))
+			Expect(names(grouped.Successful)).To(ConsistOf("successful"))
+			Expect(names(grouped.Failed)).To(ConsistOf("expired", "failed", "unauthorised"))
+		})
+	})
+
+	Describe("authorisationRoleName", func() {
+		var (
+			ctx        = context.TODO()
+			scheme     *runtime.Scheme
+			reconciler *ScheduledConsoleReconciler
+			existing   []runtime.Object
+			name       string
+			err        error
+		)
+
+		BeforeEach(func() {
+			scheme = runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(workloadsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			existing = nil
+		})
+
+		JustBeforeEach(func() {
+			reconciler = &ScheduledConsoleReconciler{
+				Client: fake.NewFakeClientWithScheme(scheme, append(existing, sc)...),
+				Log:    zap.LoggerTo(GinkgoWriter, true),
+				Scheme: scheme,
+			}
+
+			name, err = reconciler.authorisationRoleName(ctx, reconciler.Log, sc)
+		})
+
+		getRole := func(name string) *rbacv1.Role {
+			role := &rbacv1.Role{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: sc.Namespace}, role)).To(Succeed())
+			return role
+		}
+
+		It("Generates a name that can't be shared with a console's roles, and records it", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(HavePrefix("maintenance-authorisation-"))
+			Expect(name).NotTo(Equal("maintenance-authorisation-"))
+			Expect(metav1.IsControlledBy(getRole(name), sc)).To(BeTrue())
+
+			updated := &workloadsv1alpha1.ScheduledConsole{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: sc.Name, Namespace: sc.Namespace}, updated)).To(Succeed())
+			Expect(updated.Status.AuthorisationRoleName).To(Equal(name))
+		})
+
+		Context("When we've already created the role", func() {
+			BeforeEach(func() {
+				sc.Status.AuthorisationRoleName = "maintenance-authorisation-abcde"
+
+				role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "maintenance-authorisation-abcde", Namespace: sc.Namespace}}
+				Expect(controllerutil.SetControllerReference(sc, role, scheme)).To(Succeed())
+				existing = append(existing, role)
+			})
+
+			It("Returns the recorded name", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(name).To(Equal("maintenance-authorisation-abcde"))
+			})
+		})
+
+		Context("When the role with the recorded name isn't ours", func() {
+			BeforeEach(func() {
+				sc.Status.AuthorisationRoleName = "maintenance-authorisation-abcde"
+
+				existing = append(existing, &rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{Name: "maintenance-authorisation-abcde", Namespace: sc.Namespace},
+				})
+			})
+
+			It("Generates another name", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(name).To(HavePrefix("maintenance-authorisation-"))
+				Expect(name).NotTo(Equal("maintenance-authorisation-abcde"))
+				Expect(metav1.IsControlledBy(getRole(name), sc)).To(BeTrue())
+			})
+		})
+
+		Context("When the role with the recorded name has been removed", func() {
+			BeforeEach(func() {
+				sc.Status.AuthorisationRoleName = "maintenance-authorisation-abcde"
+			})
+
+			It("Generates another name", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(name).NotTo(Equal("maintenance-authorisation-abcde"))
+				Expect(sc.Status.AuthorisationRoleName).To(Equal(name))
+			})
+		})
+	})
+
+	Describe("consolesBeyondLimit", func() {
+		It("Returns the oldest consoles beyond the limit", func() {
+			ages := map[string]time.Duration{"newest": 0, "oldest": 2 * time.Hour, "middle": time.Hour}
+
+			var consoles []*workloadsv1alpha1.Console
+			for _, name := range []string{"newest", "oldest", "middle"} {
+				csl := sc.BuildConsole(now)
+				csl.Name = name
+				csl.CreationTimestamp = metav1.NewTime(now.Add(-ages[name]))
+				consoles = append(consoles, csl)
+			}
+
+			Expect(consolesBeyondLimit(consoles, 1)).To(Equal([]*workloadsv1alpha1.Console{consoles[2], consoles[1]}))
+			Expect(consolesBeyondLimit(consoles, 3)).To(BeEmpty())
+		})
+	})
+})
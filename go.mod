@@ -17,6 +17,7 @@ require (
 	github.com/sykesm/zap-logfmt v0.0.3
 	go.uber.org/zap v1.12.0
 	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
+	gomodules.xyz/jsonpatch/v2 v2.0.1
 	gomodules.xyz/jsonpatch/v3 v3.0.1
 	google.golang.org/api v0.4.0
 	gopkg.in/h2non/gock.v1 v1.0.15
@@ -0,0 +1,226 @@
+// Package cron parses the standard five field cron schedules used by scheduled
+// consoles, in the same format as Kubernetes CronJobs.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron schedule, with a bit set for each value that matches
+// each field
+type Schedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+
+	// Whether the day of the month or week was given as *, as a day matches when
+	// either of them matches unless one of them is *
+	dayOfMonthStar, dayOfWeekStar bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField     = field{name: "minute", min: 0, max: 59}
+	hourField       = field{name: "hour", min: 0, max: 23}
+	dayOfMonthField = field{name: "day of month", min: 1, max: 31}
+	monthField      = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Sunday may be given as either 0 or 7
+	dayOfWeekField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule of five space separated fields: minute, hour, day of
+// month, month and day of week. Each field is a comma separated list of values,
+// ranges (1-5) and steps (*/15 or 1-30/5), and months and days of the week may be
+// given by their three letter names. The @hourly, @daily, @weekly, @monthly and
+// @yearly macros are also accepted.
+func Parse(spec string) (*Schedule, error) {
+	if expanded, ok := macros[strings.ToLower(strings.TrimSpace(spec))]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, found %d: %q", len(fields), spec)
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dayOfMonth, err = dayOfMonthField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dayOfWeek, err = dayOfWeekField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+
+	// Fold Sunday as 7 into Sunday as 0
+	if s.dayOfWeek&(1<<7) != 0 {
+		s.dayOfWeek |= 1
+	}
+
+	s.dayOfMonthStar = strings.HasPrefix(fields[2], "*")
+	s.dayOfWeekStar = strings.HasPrefix(fields[4], "*")
+
+	return &s, nil
+}
+
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		partBits, err := f.parsePart(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: %w", f.name, expr, err)
+		}
+
+		bits |= partBits
+	}
+
+	return bits, nil
+}
+
+// parsePart parses a single value, range or step
+func (f field) parsePart(part string) (uint64, error) {
+	rangeExpr, step := part, 1
+	if idx := strings.Index(part, "/"); idx >= 0 {
+		var err error
+		rangeExpr = part[:idx]
+		if step, err = strconv.Atoi(part[idx+1:]); err != nil || step < 1 {
+			return 0, fmt.Errorf("step must be a positive number")
+		}
+	}
+
+	var start, end int
+	switch {
+	case rangeExpr == "*":
+		start, end = f.min, f.max
+	case strings.Contains(rangeExpr, "-"):
+		bounds := strings.SplitN(rangeExpr, "-", 2)
+		var err error
+		if start, err = f.value(bounds[0]); err != nil {
+			return 0, err
+		}
+		if end, err = f.value(bounds[1]); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("range %s is backwards", rangeExpr)
+		}
+	default:
+		var err error
+		if start, err = f.value(rangeExpr); err != nil {
+			return 0, err
+		}
+
+		// A single value with a step, such as 5/15, runs from that value to the end
+		end = start
+		if strings.Contains(part, "/") {
+			end = f.max
+		}
+	}
+
+	var bits uint64
+	for value := start; value <= end; value += step {
+		bits |= 1 << uint(value)
+	}
+
+	return bits, nil
+}
+
+func (f field) value(expr string) (int, error) {
+	if value, ok := f.names[strings.ToLower(expr)]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", expr)
+	}
+
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%d is outside of %d-%d", value, f.min, f.max)
+	}
+
+	return value, nil
+}
+
+// searchLimit bounds how far ahead we look for the next time that matches, as some
+// schedules, such as the 30th of February, never do
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after t that matches the schedule, in t's location, or
+// the zero time if nothing matches within the next five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchesDay follows cron in matching a day when either its day of the month or of
+// the week matches, if both are restricted
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if s.dayOfMonthStar || s.dayOfWeekStar {
+		return dayOfMonth && dayOfWeek
+	}
+
+	return dayOfMonth || dayOfWeek
+}
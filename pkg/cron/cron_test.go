@@ -0,0 +1,50 @@
+package cron
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule", func() {
+	// Wednesday
+	from := time.Date(2020, 1, 1, 10, 30, 15, 0, time.UTC)
+
+	DescribeTable("Next",
+		func(spec string, expected time.Time) {
+			schedule, err := Parse(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.Next(from)).To(Equal(expected))
+		},
+		Entry("every minute", "* * * * *", time.Date(2020, 1, 1, 10, 31, 0, 0, time.UTC)),
+		Entry("every 15 minutes", "*/15 * * * *", time.Date(2020, 1, 1, 10, 45, 0, 0, time.UTC)),
+		Entry("a single time later today", "0 22 * * *", time.Date(2020, 1, 1, 22, 0, 0, 0, time.UTC)),
+		Entry("a single time earlier in the day", "0 2 * * *", time.Date(2020, 1, 2, 2, 0, 0, 0, time.UTC)),
+		Entry("a list of hours", "0 9,17 * * *", time.Date(2020, 1, 1, 17, 0, 0, 0, time.UTC)),
+		Entry("a range of weekdays", "0 9 * * mon-fri", time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC)),
+		Entry("Sunday as 7", "0 9 * * 7", time.Date(2020, 1, 5, 9, 0, 0, 0, time.UTC)),
+		Entry("a day of the month", "0 0 15 * *", time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)),
+		Entry("a named month", "0 0 1 MAR *", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("a leap day", "0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)),
+		Entry("either a day of the month or week", "0 0 10 * fri", time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)),
+		Entry("a step from a value", "40/10 * * * *", time.Date(2020, 1, 1, 10, 40, 0, 0, time.UTC)),
+		Entry("a stepped range", "0 1-12/5 * * *", time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC)),
+		Entry("the @daily macro", "@daily", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)),
+		Entry("the @weekly macro", "@weekly", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)),
+		Entry("a date that never happens", "0 0 30 2 *", time.Time{}),
+	)
+
+	DescribeTable("Parse errors",
+		func(spec, message string) {
+			_, err := Parse(spec)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("too few fields", "* * * *", "expected 5 fields"),
+		Entry("a value out of range", "60 * * * *", "outside of 0-59"),
+		Entry("a backwards range", "* 5-1 * * *", "is backwards"),
+		Entry("an invalid step", "*/0 * * * *", "step must be a positive number"),
+		Entry("an unknown name", "* * * * funday", "is not a number"),
+	)
+})
@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -28,6 +29,8 @@ const (
 	EventRequeued     = "ReconcileRequeued"
 	EventError        = "ReconcileError"
 	EventComplete     = "ReconcileComplete"
+	EventFinalize     = "ReconcileFinalize"
+	EventFinalized    = "ReconcileFinalized"
 )
 
 var (
@@ -49,10 +52,26 @@ func init() {
 // at the start of traditional reconciliation loops.
 type ObjectReconcileFunc func(logger logr.Logger, request reconcile.Request, obj runtime.Object) (reconcile.Result, error)
 
+// Finalizer describes the clean up that must happen before an object can be deleted.
+// Finalize is called for objects that are being deleted, and the finalizer is only
+// removed once it returns without error or requesting a requeue, which leaves it free to
+// wait on the objects that it is cleaning up.
+type Finalizer struct {
+	Name     string
+	Finalize ObjectReconcileFunc
+}
+
 // ResolveAndReconcile helps avoid boilerplate where you would normally attempt to fetch
 // your modified object at the start of a reconciliation loop, and instead calls an inner
 // reconciliation function with the already resolved object.
 func ResolveAndReconcile(ctx context.Context, logger logr.Logger, mgr manager.Manager, objType runtime.Object, inner ObjectReconcileFunc) reconcile.Reconciler {
+	return ResolveAndReconcileWithFinalizer(ctx, logger, mgr, objType, nil, inner)
+}
+
+// ResolveAndReconcileWithFinalizer behaves like ResolveAndReconcile, but also ensures
+// that every object carries the given finalizer, and runs it when the object is deleted.
+// A nil finalizer leaves deleted objects alone.
+func ResolveAndReconcileWithFinalizer(ctx context.Context, logger logr.Logger, mgr manager.Manager, objType runtime.Object, finalizer *Finalizer, inner ObjectReconcileFunc) reconcile.Reconciler {
 	return reconcile.Func(func(request reconcile.Request) (res reconcile.Result, err error) {
 		logger := logger.WithValues("request", request)
 		logger.Info("Reconcile request start", "event", EventRequestStart)
@@ -103,14 +122,37 @@ func ResolveAndReconcile(ctx context.Context, logger logr.Logger, mgr manager.Ma
 		// we'd expect to be eventually deleted via propagation) and getting stuck
 		// in an infinite loop, due to these resources now blocking the deletion of
 		// the parent.
-		// If we need to support finalizers in the future then this will need to be
-		// extended to call a function that performs the finalizer actions.
+		// Objects with our finalizer are instead handed to it, so that it can clean up
+		// before the object is released.
 		if !obj.GetDeletionTimestamp().IsZero() {
-			logger.Info("Skipping reconciliation due to deletion", "event", EventSkipped)
-			res = reconcile.Result{Requeue: false}
+			if finalizer == nil || !controllerutil.ContainsFinalizer(obj, finalizer.Name) {
+				logger.Info("Skipping reconciliation due to deletion", "event", EventSkipped)
+				res = reconcile.Result{Requeue: false}
+				return res, nil
+			}
+
+			logger.Info("Finalizing object", "event", EventFinalize, "finalizer", finalizer.Name)
+			res, err = finalizer.Finalize(logger, request, obj)
+			if err != nil || res.Requeue || res.RequeueAfter > 0 {
+				return res, err
+			}
+
+			controllerutil.RemoveFinalizer(obj, finalizer.Name)
+			if err := mgr.GetClient().Update(ctx, obj); err != nil {
+				return res, errors.Wrap(err, "failed to remove finalizer")
+			}
+
+			logger.Info("Removed finalizer", "event", EventFinalized, "finalizer", finalizer.Name)
 			return res, nil
 		}
 
+		if finalizer != nil && !controllerutil.ContainsFinalizer(obj, finalizer.Name) {
+			controllerutil.AddFinalizer(obj, finalizer.Name)
+			if err := mgr.GetClient().Update(ctx, obj); err != nil {
+				return res, errors.Wrap(err, "failed to add finalizer")
+			}
+		}
+
 		return inner(logger, request, obj)
 	})
 }
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Outcome classifies the response that a webhook gave to a request
+type Outcome string
+
+const (
+	OutcomeAllowed Outcome = "allowed"
+	OutcomeDenied  Outcome = "denied"
+	OutcomePatched Outcome = "patched"
+	OutcomeErrored Outcome = "errored"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_webhook_requests_total",
+			Help: "Count of requests handled by each webhook, by outcome",
+		},
+		[]string{"webhook", "namespace", "outcome"},
+	)
+	requestDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "theatre_webhook_request_duration_seconds",
+			Help:    "Time taken by each webhook to respond to requests",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"webhook"},
+	)
+	panicsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "theatre_webhook_panics_total",
+			Help: "Count of requests that caused a webhook to panic, which are also counted as errored",
+		},
+		[]string{"webhook"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global controller runtime prometheus registry
+	metrics.Registry.MustRegister(requestsTotal, requestDurationSeconds, panicsTotal)
+}
+
+// Instrument wraps an admission handler so that every webhook logs, measures and
+// recovers from panics in the same way. Each request is logged when it starts and
+// ends, and counted by its outcome, so handlers need only log the decisions they
+// make.
+//
+// The name identifies the webhook in logs and metrics, and should match the name
+// given to the handler's own logger.
+func Instrument(name string, logger logr.Logger, handler admission.Handler) admission.Handler {
+	return &instrumentedHandler{
+		name:    name,
+		logger:  logger.WithName(name),
+		handler: handler,
+	}
+}
+
+type instrumentedHandler struct {
+	name    string
+	logger  logr.Logger
+	handler admission.Handler
+}
+
+// InjectDecoder passes the decoder on to the wrapped handler, which is what needs it
+func (h *instrumentedHandler) InjectDecoder(d *admission.Decoder) error {
+	_, err := admission.InjectDecoderInto(d, h.handler)
+	return err
+}
+
+// InjectFunc passes dependencies, such as the client, on to the wrapped handler
+func (h *instrumentedHandler) InjectFunc(f inject.Func) error {
+	return f(h.handler)
+}
+
+func (h *instrumentedHandler) Handle(ctx context.Context, req admission.Request) (resp admission.Response) {
+	logger := h.logger.WithValues("uuid", string(req.UID))
+	logger.Info("starting request", "event", "request.start")
+
+	defer func(start time.Time) {
+		if r := recover(); r != nil {
+			logger.Error(fmt.Errorf("%v", r), "webhook panicked", "event", "request.panic", "stack", string(debug.Stack()))
+			panicsTotal.WithLabelValues(h.name).Inc()
+			resp = admission.Errored(http.StatusInternalServerError, fmt.Errorf("webhook %s failed to handle request", h.name))
+		}
+
+		duration := time.Since(start)
+		outcome := OutcomeFor(resp)
+
+		logger.Info("completed request", "event", "request.end", "outcome", outcome, "duration", duration.Seconds())
+		requestDurationSeconds.WithLabelValues(h.name).Observe(duration.Seconds())
+		requestsTotal.WithLabelValues(h.name, req.Namespace, string(outcome)).Inc()
+	}(time.Now())
+
+	return h.handler.Handle(ctx, req)
+}
+
+// OutcomeFor classifies a response. Handlers deny requests with a forbidden status,
+// through admission.Denied or admission.ValidationResponse, whereas any other status
+// means that the handler failed to make a decision.
+func OutcomeFor(resp admission.Response) Outcome {
+	switch {
+	case resp.Allowed && (len(resp.Patches) > 0 || len(resp.Patch) > 0):
+		return OutcomePatched
+	case resp.Allowed:
+		return OutcomeAllowed
+	case resp.Result == nil || resp.Result.Code == http.StatusForbidden:
+		return OutcomeDenied
+	default:
+		return OutcomeErrored
+	}
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+type decodingHandler struct {
+	admission.HandlerFunc
+	decoder *admission.Decoder
+}
+
+func (h *decodingHandler) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+var _ = Describe("Instrument", func() {
+	Describe("Handle", func() {
+		var (
+			name    string
+			handler admission.Handler
+			resp    admission.Response
+		)
+
+		request := admission.Request{
+			AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				UID:       "a-uid",
+				Namespace: "a-namespace",
+			},
+		}
+
+		requests := func(outcome Outcome) float64 {
+			return testutil.ToFloat64(requestsTotal.WithLabelValues(name, "a-namespace", string(outcome)))
+		}
+
+		JustBeforeEach(func() {
+			resp = Instrument(name, zap.LoggerTo(GinkgoWriter, true), handler).Handle(context.TODO(), request)
+		})
+
+		Context("When the handler patches the request", func() {
+			BeforeEach(func() {
+				name = "patching"
+				handler = admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+					patch := Patch{}
+					patch.Add("/spec/priorityClassName", "best-effort")
+					return patch.Response("")
+				})
+			})
+
+			It("Counts the request as patched", func() {
+				Expect(resp.Allowed).To(BeTrue())
+				Expect(requests(OutcomePatched)).To(Equal(1.0))
+				Expect(requests(OutcomeAllowed)).To(Equal(0.0))
+			})
+		})
+
+		Context("When the handler denies the request", func() {
+			BeforeEach(func() {
+				name = "denying"
+				handler = admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+					return admission.Denied("not today")
+				})
+			})
+
+			It("Counts the request as denied", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(requests(OutcomeDenied)).To(Equal(1.0))
+			})
+		})
+
+		Context("When the handler fails", func() {
+			BeforeEach(func() {
+				name = "erroring"
+				handler = admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+					return admission.Errored(http.StatusInternalServerError, errors.New("oops"))
+				})
+			})
+
+			It("Counts the request as errored", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(requests(OutcomeErrored)).To(Equal(1.0))
+			})
+		})
+
+		Context("When the handler panics", func() {
+			BeforeEach(func() {
+				name = "panicking"
+				handler = admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+					panic("oops")
+				})
+			})
+
+			It("Responds with an error", func() {
+				Expect(resp.Allowed).To(BeFalse())
+				Expect(resp.Result.Code).To(BeEquivalentTo(http.StatusInternalServerError))
+				Expect(requests(OutcomeErrored)).To(Equal(1.0))
+				Expect(testutil.ToFloat64(panicsTotal.WithLabelValues(name))).To(Equal(1.0))
+			})
+		})
+	})
+
+	It("Passes the decoder to the wrapped handler", func() {
+		inner := &decodingHandler{}
+		decoder, err := admission.NewDecoder(runtime.NewScheme())
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = admission.InjectDecoderInto(decoder, Instrument("decoding", zap.LoggerTo(GinkgoWriter, true), inner))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inner.decoder).To(Equal(decoder))
+	})
+})
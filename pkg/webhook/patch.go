@@ -0,0 +1,171 @@
+// Package webhook contains helpers shared by theatre's admission webhooks.
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gomodules.xyz/jsonpatch/v2"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Patch is the list of JSON patch operations that a mutating webhook responds with.
+//
+// Webhooks should describe their changes with explicit operations, rather than
+// marshalling a mutated copy of the object and diffing it against the request. The
+// copy only contains the fields that our scheme knows about, so any field added to
+// the API server since we were built would be removed, and diffing the raw request
+// produces operations on unrelated fields that were only normalised by decoding.
+type Patch []jsonpatch.JsonPatchOperation
+
+// Add sets the value at the path. For object members this replaces any existing
+// value, while for arrays it inserts the value at the index.
+func (p *Patch) Add(path string, value interface{}) {
+	*p = append(*p, jsonpatch.NewPatch("add", path, value))
+}
+
+// Replace sets the value at the path, which must already exist.
+func (p *Patch) Replace(path string, value interface{}) {
+	*p = append(*p, jsonpatch.NewPatch("replace", path, value))
+}
+
+// Remove deletes the value at the path, which must already exist.
+func (p *Patch) Remove(path string) {
+	*p = append(*p, jsonpatch.NewPatch("remove", path, nil))
+}
+
+// Response allows the request, applying the patch.
+func (p Patch) Response(reason string) admission.Response {
+	return admission.Patched(reason, p...)
+}
+
+// Path builds a JSON pointer from its segments, escaping any that contain special
+// characters, such as annotation keys that contain a slash.
+func Path(segments ...string) string {
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+
+	var path strings.Builder
+	for _, segment := range segments {
+		path.WriteString("/")
+		path.WriteString(escape.Replace(segment))
+	}
+
+	return path.String()
+}
+
+// Index formats an array index as a path segment.
+func Index(idx int) string {
+	return strconv.Itoa(idx)
+}
+
+// Diff returns the operations that turn the original object into the mutated one,
+// for webhooks whose changes are too extensive to describe by hand.
+//
+// Both objects are encoded with our own types before being compared, unlike the raw
+// request, so fields that our scheme doesn't know about are never touched, and
+// fields are visited in order so that the same change always produces the same
+// patch.
+func Diff(original, mutated interface{}) (Patch, error) {
+	var a, b interface{}
+	if err := roundTrip(original, &a); err != nil {
+		return nil, err
+	}
+	if err := roundTrip(mutated, &b); err != nil {
+		return nil, err
+	}
+
+	return diffValues("", a, b, Patch{}, (*Patch).Replace), nil
+}
+
+func roundTrip(obj interface{}, into *interface{}) error {
+	encoded, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(encoded, into)
+}
+
+// diffValues compares two values, recursing into them where they are both arrays or
+// both non-empty objects, and otherwise calling set if they differ.
+func diffValues(path string, a, b interface{}, patch Patch, set func(*Patch, string, interface{})) Patch {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		// Our types encode some empty structs, such as a container's resources, that
+		// the request may have left out, so we set them whole rather than adding
+		// members to an object that might not exist.
+		if bv, ok := b.(map[string]interface{}); ok && len(av) > 0 {
+			return diffObjects(path, av, bv, patch)
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			return diffArrays(path, av, bv, patch)
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		set(&patch, path, b)
+	}
+
+	return patch
+}
+
+func diffObjects(path string, a, b map[string]interface{}, patch Patch) Patch {
+	keys := []string{}
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		av, inA := a[key]
+		bv, inB := b[key]
+		member := path + Path(key)
+
+		switch {
+		case !inB:
+			patch.Remove(member)
+		case !inA:
+			patch.Add(member, bv)
+		default:
+			// Adding an object member replaces it if it's already there, and unlike
+			// replace, doesn't fail if the request left it out
+			patch = diffValues(member, av, bv, patch, (*Patch).Add)
+		}
+	}
+
+	return patch
+}
+
+// diffArrays compares elements at the same index, and then removes or appends the
+// elements beyond the end of the shorter array. Removals start from the end so that
+// the indices of the remaining elements don't shift.
+func diffArrays(path string, a, b []interface{}, patch Patch) Patch {
+	common := len(a)
+	if len(b) < common {
+		common = len(b)
+	}
+
+	for idx := 0; idx < common; idx++ {
+		patch = diffValues(path+Path(Index(idx)), a[idx], b[idx], patch, (*Patch).Replace)
+	}
+
+	for idx := len(a) - 1; idx >= common; idx-- {
+		patch.Remove(path + Path(Index(idx)))
+	}
+
+	for idx := common; idx < len(b); idx++ {
+		patch.Add(path+Path(Index(idx)), b[idx])
+	}
+
+	return patch
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gomodules.xyz/jsonpatch/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Patch", func() {
+	Describe("Path", func() {
+		It("Escapes segments", func() {
+			Expect(Path("metadata", "annotations", "vault.crd.gocardless.com/role~1")).
+				To(Equal("/metadata/annotations/vault.crd.gocardless.com~1role~01"))
+		})
+	})
+
+	Describe("Diff", func() {
+		var (
+			original *corev1.Pod
+			mutated  *corev1.Pod
+			patch    Patch
+			err      error
+		)
+
+		BeforeEach(func() {
+			original = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "app",
+					Annotations: map[string]string{"keep": "me", "drop": "me"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Command: []string{"app"}},
+						{Name: "worker", Command: []string{"worker"}},
+					},
+				},
+			}
+
+			mutated = original.DeepCopy()
+		})
+
+		JustBeforeEach(func() {
+			patch, err = Diff(original, mutated)
+		})
+
+		Context("When nothing changed", func() {
+			It("Returns no operations", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(patch).To(BeEmpty())
+			})
+		})
+
+		Context("When fields change", func() {
+			BeforeEach(func() {
+				delete(mutated.Annotations, "drop")
+				mutated.Annotations["added/by-webhook"] = "yes"
+				mutated.Spec.Containers[1].Command = []string{"wrapper", "worker"}
+				mutated.Spec.Containers = append(mutated.Spec.Containers, corev1.Container{Name: "sidecar"})
+				mutated.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("1"),
+				}
+			})
+
+			It("Returns operations on only those fields, in order", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(patch).To(Equal(Patch{
+					jsonpatch.NewPatch("add", "/metadata/annotations/added~1by-webhook", "yes"),
+					jsonpatch.NewPatch("remove", "/metadata/annotations/drop", nil),
+					jsonpatch.NewPatch("add", "/spec/containers/0/resources", map[string]interface{}{
+						"limits": map[string]interface{}{"cpu": "1"},
+					}),
+					jsonpatch.NewPatch("replace", "/spec/containers/1/command/0", "wrapper"),
+					jsonpatch.NewPatch("add", "/spec/containers/1/command/1", "worker"),
+					jsonpatch.NewPatch("add", "/spec/containers/2", map[string]interface{}{
+						"name":      "sidecar",
+						"resources": map[string]interface{}{},
+					}),
+				}))
+			})
+		})
+
+		Context("When elements are removed from an array", func() {
+			BeforeEach(func() {
+				original.Spec.Containers = append(original.Spec.Containers, corev1.Container{Name: "sidecar"})
+				mutated.Spec.Containers = mutated.Spec.Containers[:1]
+			})
+
+			It("Removes them from the end", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(patch).To(Equal(Patch{
+					jsonpatch.NewPatch("remove", "/spec/containers/2", nil),
+					jsonpatch.NewPatch("remove", "/spec/containers/1", nil),
+				}))
+			})
+		})
+	})
+})
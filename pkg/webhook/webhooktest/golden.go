@@ -0,0 +1,34 @@
+// Package webhooktest helps test the patches generated by mutating webhooks, by
+// comparing them against golden files.
+//
+// When a change to a webhook deliberately changes its patches, regenerate the golden
+// files by running the tests with the update flag, and review the difference:
+//
+//   go test ./apis/... -args -update
+package webhooktest
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+
+	. "github.com/onsi/gomega"
+	"gomodules.xyz/jsonpatch/v2"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the patches that webhooks generate")
+
+// ExpectGoldenPatch asserts that the patch matches the golden file at the path
+func ExpectGoldenPatch(path string, patch []jsonpatch.JsonPatchOperation) {
+	actual, err := json.MarshalIndent(patch, "", "  ")
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	actual = append(actual, '\n')
+
+	if *update {
+		ExpectWithOffset(1, ioutil.WriteFile(path, actual, 0644)).To(Succeed())
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred(), "golden file is missing, run the tests with -update to create it")
+	ExpectWithOffset(1, string(actual)).To(Equal(string(expected)))
+}
@@ -0,0 +1,289 @@
+// Package client provides a programmatic interface to theatre consoles, for tools
+// that embed them. Unlike the runner, it never prints or prompts, and failures that
+// callers may want to handle are returned as typed errors, which can be inspected
+// with errors.As.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
+)
+
+// IOStreams are the streams that an attached console reads from and writes to
+type IOStreams = runner.IOStreams
+
+// Event is a console that changed phase, as observed by Watch
+type Event struct {
+	Console *workloadsv1alpha1.Console
+
+	// Whether the console was deleted, in which case it has its final state
+	Deleted bool
+
+	// Set if we couldn't tell whether the console matches the options given to Watch
+	Err error
+}
+
+// Interface is the set of operations on consoles, implemented by Client and by Fake
+// for use in tests
+type Interface interface {
+	// Create creates a console from the single template in the namespace matching the
+	// selector, and returns without waiting for it to start
+	Create(ctx context.Context, namespace, selector string, opts ...CreateOption) (*workloadsv1alpha1.Console, error)
+
+	// Get returns the console, searching all namespaces if none is given
+	Get(ctx context.Context, namespace, name string) (*workloadsv1alpha1.Console, error)
+
+	// Wait blocks until the console is running and can be attached to, or has
+	// stopped, including waiting for it to be authorised. Consoles whose pod failed
+	// to start return a ConsoleFailedError.
+	Wait(ctx context.Context, namespace, name string, opts ...WaitOption) (*workloadsv1alpha1.Console, error)
+
+	// Attach connects the streams to a running console until it exits
+	Attach(ctx context.Context, namespace, name string, streams IOStreams, opts ...AttachOption) error
+
+	// Authorise adds the user's authorisation to a console. The user must match who
+	// the API server authenticates the client as.
+	Authorise(ctx context.Context, namespace, name, username string) error
+
+	// List returns the consoles in the namespace, or all namespaces if none is given
+	List(ctx context.Context, namespace string, opts ...ListOption) ([]workloadsv1alpha1.Console, error)
+
+	// Watch sends each matching console as it changes phase, starting with their
+	// current state, until the context is cancelled, when the channel is closed
+	Watch(ctx context.Context, namespace string, opts ...ListOption) (<-chan Event, error)
+}
+
+var _ Interface = &Client{}
+
+// Client operates on consoles in a Kubernetes cluster
+type Client struct {
+	runner consoleRunner
+	config *rest.Config
+}
+
+// consoleRunner is the part of the runner that the client is built on, which tests
+// replace to exercise how its errors are handled
+type consoleRunner interface {
+	ListTemplatesBySelector(ctx context.Context, namespace, labelSelector string) (runner.TemplateSlice, error)
+	CreateResource(namespace string, template workloadsv1alpha1.ConsoleTemplate, opts runner.Options) (*workloadsv1alpha1.Console, error)
+	Get(ctx context.Context, opts runner.GetOptions) (*workloadsv1alpha1.Console, error)
+	WaitUntilReady(ctx context.Context, createdCsl workloadsv1alpha1.Console, waitForAuthorisation bool) (*workloadsv1alpha1.Console, error)
+	Attach(ctx context.Context, opts runner.AttachOptions) error
+	Authorise(ctx context.Context, opts runner.AuthoriseOptions) error
+	ListConsolesByLabelsAndUser(ctx context.Context, namespace, username, labelSelector string) (runner.ConsoleSlice, error)
+	Matches(ctx context.Context, filter runner.ConsoleFilter, csl *workloadsv1alpha1.Console) (bool, error)
+	WatchEvents(ctx context.Context, opts runner.WatchOptions, handler runner.WatchHandler) error
+}
+
+var _ consoleRunner = &runner.Runner{}
+
+// New builds a client from the given Kubernetes configuration
+func New(cfg *rest.Config) (*Client, error) {
+	r, err := runner.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{runner: r, config: cfg}, nil
+}
+
+func (c *Client) Create(ctx context.Context, namespace, selector string, opts ...CreateOption) (*workloadsv1alpha1.Console, error) {
+	o := applyCreateOptions(opts)
+
+	templates, err := c.runner.ListTemplatesBySelector(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(templates) {
+	case 0:
+		return nil, &TemplateNotFoundError{Namespace: namespace, Selector: selector}
+	case 1:
+	default:
+		return nil, &AmbiguousTemplateError{Selector: selector, Templates: templates}
+	}
+
+	return c.runner.CreateResource(templates[0].Namespace, templates[0], runner.Options{
+		Cmd:            o.command,
+		Timeout:        int(o.timeout.Seconds()),
+		Reason:         o.reason,
+		Noninteractive: o.noninteractive || o.script != "",
+		Script:         o.script,
+	})
+}
+
+func (c *Client) Get(ctx context.Context, namespace, name string) (*workloadsv1alpha1.Console, error) {
+	csl, err := c.runner.Get(ctx, runner.GetOptions{Namespace: namespace, ConsoleName: name})
+	if err != nil {
+		return nil, consoleError(namespace, name, err)
+	}
+
+	return csl, nil
+}
+
+func (c *Client) Wait(ctx context.Context, namespace, name string, opts ...WaitOption) (*workloadsv1alpha1.Console, error) {
+	o := applyWaitOptions(opts)
+
+	csl, err := c.Get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ready, err := c.runner.WaitUntilReady(ctx, *csl, o.waitForAuthorisation)
+	if errors.Is(err, runner.ErrConsolePendingAuthorisation) {
+		// Fetch the console again, as it will have changed while we were waiting
+		if csl, err = c.Get(ctx, csl.Namespace, csl.Name); err != nil {
+			return nil, err
+		}
+
+		return nil, &PendingAuthorisationError{Console: csl}
+	}
+	var failedErr runner.ConsoleFailedError
+	if errors.As(err, &failedErr) {
+		if csl, err = c.Get(ctx, csl.Namespace, csl.Name); err != nil {
+			return nil, err
+		}
+
+		return nil, &ConsoleFailedError{Console: csl, Reason: failedErr.Reason}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ready, nil
+}
+
+func (c *Client) Attach(ctx context.Context, namespace, name string, streams IOStreams, opts ...AttachOption) error {
+	o := applyAttachOptions(opts)
+
+	csl, err := c.Get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if !csl.Running() {
+		return &ConsoleNotRunningError{Console: csl}
+	}
+
+	return c.runner.Attach(ctx, runner.AttachOptions{
+		Namespace:         csl.Namespace,
+		KubeConfig:        c.config,
+		Name:              csl.Name,
+		IO:                withDefaultStreams(streams),
+		Scrollback:        o.scrollback,
+		ReconnectAttempts: o.reconnectAttempts,
+		ReadOnly:          o.readOnly,
+		Hook:              runner.DefaultLifecycleHook{ReconnectingToConsoleFunc: reconnectHook(o.onReconnect)},
+	})
+}
+
+func (c *Client) Authorise(ctx context.Context, namespace, name, username string) error {
+	csl, err := c.Get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	err = c.runner.Authorise(ctx, runner.AuthoriseOptions{Namespace: csl.Namespace, ConsoleName: csl.Name, Username: username})
+	switch {
+	case apierrors.IsNotFound(err):
+		// Consoles only have an authorisation object if their command requires one
+		return &AuthorisationDeniedError{Namespace: csl.Namespace, Name: csl.Name, Username: username, Reason: "console does not require authorisation"}
+	case apierrors.IsForbidden(err), apierrors.IsInvalid(err):
+		return &AuthorisationDeniedError{Namespace: csl.Namespace, Name: csl.Name, Username: username, Reason: statusMessage(err)}
+	}
+
+	return err
+}
+
+func (c *Client) List(ctx context.Context, namespace string, opts ...ListOption) ([]workloadsv1alpha1.Console, error) {
+	o := applyListOptions(opts)
+
+	listed, err := c.runner.ListConsolesByLabelsAndUser(ctx, namespace, o.user, o.selector)
+	if err != nil {
+		return nil, err
+	}
+
+	consoles := []workloadsv1alpha1.Console{}
+	for idx := range listed {
+		match, err := c.runner.Matches(ctx, o.filter(), &listed[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			consoles = append(consoles, listed[idx])
+		}
+	}
+
+	return consoles, nil
+}
+
+func (c *Client) Watch(ctx context.Context, namespace string, opts ...ListOption) (<-chan Event, error) {
+	o := applyListOptions(opts)
+
+	// Check the selector now, as we can't report errors once we're watching
+	if _, err := labels.Parse(o.selector); err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	watchOpts := runner.WatchOptions{
+		Namespace: namespace,
+		Username:  o.user,
+		Selector:  o.selector,
+		Filter:    o.filter(),
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		c.runner.WatchEvents(ctx, watchOpts, func(csl *workloadsv1alpha1.Console, deleted bool, err error) {
+			select {
+			case events <- Event{Console: csl, Deleted: deleted, Err: err}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	return events, nil
+}
+
+func (o listOptions) filter() runner.ConsoleFilter {
+	return runner.ConsoleFilter{
+		Phases:           o.phases,
+		Authoriser:       o.authoriser,
+		AuthoriserGroups: o.authoriserGroups,
+	}
+}
+
+// withDefaultStreams discards output that the caller didn't ask for, as the attachers
+// expect every stream to be set
+func withDefaultStreams(streams IOStreams) IOStreams {
+	if streams.Out == nil {
+		streams.Out = ioutil.Discard
+	}
+	if streams.ErrOut == nil {
+		streams.ErrOut = ioutil.Discard
+	}
+
+	return streams
+}
+
+func reconnectHook(fn func(*workloadsv1alpha1.Console, int, error)) func(*workloadsv1alpha1.Console, int, error) error {
+	return func(csl *workloadsv1alpha1.Console, attempt int, err error) error {
+		if fn != nil {
+			fn(csl, attempt, err)
+		}
+
+		return nil
+	}
+}
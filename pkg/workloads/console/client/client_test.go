@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
+)
+
+// stubRunner answers the runner calls made by Client from canned functions. Calls
+// without one panic, via the nil interface.
+type stubRunner struct {
+	consoleRunner
+
+	get            func(runner.GetOptions) (*workloadsv1alpha1.Console, error)
+	waitUntilReady func(workloadsv1alpha1.Console) (*workloadsv1alpha1.Console, error)
+	authorise      func(runner.AuthoriseOptions) error
+}
+
+func (s *stubRunner) Get(ctx context.Context, opts runner.GetOptions) (*workloadsv1alpha1.Console, error) {
+	return s.get(opts)
+}
+
+func (s *stubRunner) WaitUntilReady(ctx context.Context, csl workloadsv1alpha1.Console, waitForAuthorisation bool) (*workloadsv1alpha1.Console, error) {
+	return s.waitUntilReady(csl)
+}
+
+func (s *stubRunner) Authorise(ctx context.Context, opts runner.AuthoriseOptions) error {
+	return s.authorise(opts)
+}
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    context.Context
+		stub   *stubRunner
+		client *Client
+		gets   int
+	)
+
+	consoleResource := schema.GroupResource{Group: "workloads.crd.gocardless.com", Resource: "consoles"}
+	authorisationResource := schema.GroupResource{Group: "workloads.crd.gocardless.com", Resource: "consoleauthorisations"}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		gets = 0
+
+		stub = &stubRunner{
+			get: func(opts runner.GetOptions) (*workloadsv1alpha1.Console, error) {
+				gets++
+				return &workloadsv1alpha1.Console{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: opts.ConsoleName, Generation: int64(gets)},
+					Spec:       workloadsv1alpha1.ConsoleSpec{User: "alice@example.com"},
+					Status:     workloadsv1alpha1.ConsoleStatus{Phase: workloadsv1alpha1.ConsolePendingAuthorisation},
+				}, nil
+			},
+		}
+		client = &Client{runner: stub}
+	})
+
+	Describe("Wait", func() {
+		var (
+			csl *workloadsv1alpha1.Console
+			err error
+		)
+
+		JustBeforeEach(func() {
+			csl, err = client.Wait(ctx, "default", "my-console")
+		})
+
+		Context("When the console is ready", func() {
+			BeforeEach(func() {
+				stub.waitUntilReady = func(csl workloadsv1alpha1.Console) (*workloadsv1alpha1.Console, error) {
+					csl.Status.Phase = workloadsv1alpha1.ConsoleRunning
+					return &csl, nil
+				}
+			})
+
+			It("Returns the console", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(csl.Status.Phase).To(Equal(workloadsv1alpha1.ConsoleRunning))
+			})
+		})
+
+		Context("When the console doesn't exist", func() {
+			BeforeEach(func() {
+				stub.get = func(runner.GetOptions) (*workloadsv1alpha1.Console, error) {
+					return nil, apierrors.NewNotFound(consoleResource, "my-console")
+				}
+			})
+
+			It("Returns a ConsoleNotFoundError", func() {
+				var notFoundErr *ConsoleNotFoundError
+				Expect(errors.As(err, &notFoundErr)).To(BeTrue())
+				Expect(notFoundErr.Namespace).To(Equal("default"))
+				Expect(notFoundErr.Name).To(Equal("my-console"))
+			})
+		})
+
+		Context("When the console isn't found in any namespace", func() {
+			BeforeEach(func() {
+				stub.get = func(runner.GetOptions) (*workloadsv1alpha1.Console, error) {
+					return nil, fmt.Errorf("%w with name: my-console", runner.ErrConsoleNotFound)
+				}
+			})
+
+			It("Returns a ConsoleNotFoundError", func() {
+				var notFoundErr *ConsoleNotFoundError
+				Expect(errors.As(err, &notFoundErr)).To(BeTrue())
+			})
+		})
+
+		Context("When the console is pending authorisation", func() {
+			BeforeEach(func() {
+				stub.waitUntilReady = func(csl workloadsv1alpha1.Console) (*workloadsv1alpha1.Console, error) {
+					return &csl, runner.ErrConsolePendingAuthorisation
+				}
+			})
+
+			It("Returns a PendingAuthorisationError with the latest console", func() {
+				var pendingErr *PendingAuthorisationError
+				Expect(errors.As(err, &pendingErr)).To(BeTrue())
+				Expect(pendingErr.Console.Generation).To(BeEquivalentTo(2))
+				Expect(csl).To(BeNil())
+			})
+		})
+
+		Context("When the console failed to start", func() {
+			BeforeEach(func() {
+				stub.waitUntilReady = func(workloadsv1alpha1.Console) (*workloadsv1alpha1.Console, error) {
+					return nil, fmt.Errorf("waiting for console: %w", runner.ConsoleFailedError{Reason: "image not found"})
+				}
+			})
+
+			It("Returns a ConsoleFailedError with the reason and latest console", func() {
+				var failedErr *ConsoleFailedError
+				Expect(errors.As(err, &failedErr)).To(BeTrue())
+				Expect(failedErr.Reason).To(Equal("image not found"))
+				Expect(failedErr.Console.Generation).To(BeEquivalentTo(2))
+			})
+		})
+
+		Context("When waiting fails for any other reason", func() {
+			BeforeEach(func() {
+				stub.waitUntilReady = func(workloadsv1alpha1.Console) (*workloadsv1alpha1.Console, error) {
+					return nil, context.DeadlineExceeded
+				}
+			})
+
+			It("Returns the error unchanged", func() {
+				Expect(err).To(Equal(context.DeadlineExceeded))
+			})
+		})
+	})
+
+	Describe("Authorise", func() {
+		var (
+			authoriseErr error
+			err          error
+		)
+
+		BeforeEach(func() {
+			authoriseErr = nil
+			stub.authorise = func(opts runner.AuthoriseOptions) error {
+				Expect(opts).To(Equal(runner.AuthoriseOptions{Namespace: "default", ConsoleName: "my-console", Username: "bob@example.com"}))
+				return authoriseErr
+			}
+		})
+
+		JustBeforeEach(func() {
+			err = client.Authorise(ctx, "default", "my-console", "bob@example.com")
+		})
+
+		It("Succeeds when the runner does", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("When the console has no authorisation object", func() {
+			BeforeEach(func() {
+				authoriseErr = apierrors.NewNotFound(authorisationResource, "my-console")
+			})
+
+			It("Returns an AuthorisationDeniedError", func() {
+				var deniedErr *AuthorisationDeniedError
+				Expect(errors.As(err, &deniedErr)).To(BeTrue())
+				Expect(deniedErr.Reason).To(Equal("console does not require authorisation"))
+				Expect(deniedErr.Username).To(Equal("bob@example.com"))
+			})
+		})
+
+		Context("When the webhook rejects the authorisation", func() {
+			BeforeEach(func() {
+				authoriseErr = apierrors.NewForbidden(authorisationResource, "my-console", errors.New("an authoriser cannot authorise their own console"))
+			})
+
+			It("Returns an AuthorisationDeniedError with the webhook's message", func() {
+				var deniedErr *AuthorisationDeniedError
+				Expect(errors.As(err, &deniedErr)).To(BeTrue())
+				Expect(deniedErr.Reason).To(ContainSubstring("an authoriser cannot authorise their own console"))
+			})
+		})
+
+		Context("When the authorisation is invalid", func() {
+			BeforeEach(func() {
+				authoriseErr = apierrors.NewInvalid(
+					schema.GroupKind{Group: "workloads.crd.gocardless.com", Kind: "ConsoleAuthorisation"}, "my-console",
+					field.ErrorList{field.Forbidden(field.NewPath("spec", "authorisations"), "the user has already authorised this console")},
+				)
+			})
+
+			It("Returns an AuthorisationDeniedError with the reason", func() {
+				var deniedErr *AuthorisationDeniedError
+				Expect(errors.As(err, &deniedErr)).To(BeTrue())
+				Expect(deniedErr.Reason).To(ContainSubstring("the user has already authorised this console"))
+			})
+		})
+
+		Context("When the console doesn't exist", func() {
+			BeforeEach(func() {
+				stub.get = func(runner.GetOptions) (*workloadsv1alpha1.Console, error) {
+					return nil, apierrors.NewNotFound(consoleResource, "my-console")
+				}
+			})
+
+			It("Returns a ConsoleNotFoundError rather than a denial", func() {
+				var notFoundErr *ConsoleNotFoundError
+				Expect(errors.As(err, &notFoundErr)).To(BeTrue())
+			})
+		})
+
+		Context("When the API server fails", func() {
+			BeforeEach(func() {
+				authoriseErr = apierrors.NewInternalError(errors.New("etcd unavailable"))
+			})
+
+			It("Returns the error unchanged", func() {
+				Expect(err).To(Equal(authoriseErr))
+			})
+		})
+	})
+})
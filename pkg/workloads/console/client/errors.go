@@ -0,0 +1,128 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
+)
+
+// TemplateNotFoundError is returned by Create when no console template matches the
+// selector
+type TemplateNotFoundError struct {
+	Namespace string
+	Selector  string
+}
+
+func (e *TemplateNotFoundError) Error() string {
+	if e.Namespace == "" {
+		return fmt.Sprintf("no console template matches selector %q", e.Selector)
+	}
+
+	return fmt.Sprintf("no console template in namespace %s matches selector %q", e.Namespace, e.Selector)
+}
+
+// AmbiguousTemplateError is returned by Create when more than one console template
+// matches the selector, in which case it needs narrowing down
+type AmbiguousTemplateError struct {
+	Selector  string
+	Templates []workloadsv1alpha1.ConsoleTemplate
+}
+
+func (e *AmbiguousTemplateError) Error() string {
+	identifiers := make([]string, 0, len(e.Templates))
+	for _, tpl := range e.Templates {
+		identifiers = append(identifiers, tpl.Namespace+"/"+tpl.Name)
+	}
+
+	return fmt.Sprintf("selector %q matches more than one console template: %s", e.Selector, strings.Join(identifiers, ", "))
+}
+
+// ConsoleNotFoundError is returned when operating on a console that doesn't exist
+type ConsoleNotFoundError struct {
+	Namespace string
+	Name      string
+}
+
+func (e *ConsoleNotFoundError) Error() string {
+	return fmt.Sprintf("console %s not found", qualifiedName(e.Namespace, e.Name))
+}
+
+// ConsoleNotRunningError is returned when attaching to a console that isn't running
+type ConsoleNotRunningError struct {
+	Console *workloadsv1alpha1.Console
+}
+
+func (e *ConsoleNotRunningError) Error() string {
+	return fmt.Sprintf("console %s is not running, its phase is %s", e.Console.Name, e.Console.Status.Phase)
+}
+
+// PendingAuthorisationError is returned by Wait when the console is awaiting
+// authorisation, unless it was asked to wait for authorisation
+type PendingAuthorisationError struct {
+	Console *workloadsv1alpha1.Console
+}
+
+func (e *PendingAuthorisationError) Error() string {
+	return fmt.Sprintf("console %s is pending authorisation", e.Console.Name)
+}
+
+// ConsoleFailedError is returned by Wait when the console's pod failed to start, for
+// longer than its template allows. The reason is taken from the pod's most recent
+// warning event where possible.
+type ConsoleFailedError struct {
+	Console *workloadsv1alpha1.Console
+	Reason  string
+}
+
+func (e *ConsoleFailedError) Error() string {
+	return fmt.Sprintf("console %s failed to start: %s", e.Console.Name, e.Reason)
+}
+
+// AuthorisationDeniedError is returned by Authorise when the authorisation is
+// rejected, for example because the user owns the console or has already authorised
+// it
+type AuthorisationDeniedError struct {
+	Namespace string
+	Name      string
+	Username  string
+	Reason    string
+}
+
+func (e *AuthorisationDeniedError) Error() string {
+	return fmt.Sprintf("%s cannot authorise console %s: %s", e.Username, qualifiedName(e.Namespace, e.Name), e.Reason)
+}
+
+// consoleError converts the not found errors returned by the API server, or the
+// runner when searching all namespaces, into a ConsoleNotFoundError, leaving all
+// others untouched
+func consoleError(namespace, name string, err error) error {
+	if apierrors.IsNotFound(err) || errors.Is(err, runner.ErrConsoleNotFound) {
+		return &ConsoleNotFoundError{Namespace: namespace, Name: name}
+	}
+
+	return err
+}
+
+// statusMessage returns the message from an API server error, which is where
+// admission webhooks explain why they rejected a request
+func statusMessage(err error) string {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Message != "" {
+		return status.Status().Message
+	}
+
+	return err.Error()
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "/" + name
+}
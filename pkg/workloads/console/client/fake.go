@@ -0,0 +1,495 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+	"github.com/gocardless/theatre/v2/pkg/workloads/console/runner"
+)
+
+// fakeWatchBuffer is how many events a watch on the fake can hold before they're read.
+// Tests that leave more than this unread are almost certainly broken, so the fake
+// panics rather than blocking.
+const fakeWatchBuffer = 100
+
+// Fake is an in-memory implementation of Interface, for unit testing code that uses
+// consoles. It follows the rules of the real controller and webhooks closely enough
+// for most tests, without ever blocking:
+//
+//   - Consoles whose command requires authorisation start in the PendingAuthorisation
+//     phase, until enough users have authorised them, and all others start Running.
+//   - Wait returns a PendingAuthorisationError for consoles awaiting authorisation,
+//     rather than waiting for an authorisation that will never come.
+//   - Wait returns a ConsoleFailedError for consoles in the Failed phase, which
+//     tests can set up with SetPhase or AddConsole.
+//   - Attach succeeds immediately for running consoles, without touching the streams.
+//
+// Any of the Func fields can be set to override the behaviour of that method, such as
+// to return an error.
+type Fake struct {
+	// The user that created consoles are attributed to, as the API server would
+	// authenticate them
+	User string
+
+	CreateFunc    func(ctx context.Context, namespace, selector string, opts ...CreateOption) (*workloadsv1alpha1.Console, error)
+	GetFunc       func(ctx context.Context, namespace, name string) (*workloadsv1alpha1.Console, error)
+	WaitFunc      func(ctx context.Context, namespace, name string, opts ...WaitOption) (*workloadsv1alpha1.Console, error)
+	AttachFunc    func(ctx context.Context, namespace, name string, streams IOStreams, opts ...AttachOption) error
+	AuthoriseFunc func(ctx context.Context, namespace, name, username string) error
+	ListFunc      func(ctx context.Context, namespace string, opts ...ListOption) ([]workloadsv1alpha1.Console, error)
+	WatchFunc     func(ctx context.Context, namespace string, opts ...ListOption) (<-chan Event, error)
+
+	mu             sync.Mutex
+	templates      []workloadsv1alpha1.ConsoleTemplate
+	consoles       []*workloadsv1alpha1.Console
+	authorisations map[string][]string
+	watchers       map[*fakeWatcher]struct{}
+	created        int
+}
+
+var _ Interface = &Fake{}
+
+type fakeWatcher struct {
+	namespace string
+	opts      listOptions
+	events    chan Event
+}
+
+// NewFake returns a fake that creates consoles from the given templates
+func NewFake(templates ...workloadsv1alpha1.ConsoleTemplate) *Fake {
+	return &Fake{
+		templates:      templates,
+		authorisations: map[string][]string{},
+		watchers:       map[*fakeWatcher]struct{}{},
+	}
+}
+
+// AddConsole adds an existing console to the fake, such as one in a particular phase
+func (f *Fake) AddConsole(csl workloadsv1alpha1.Console) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.consoles = append(f.consoles, csl.DeepCopy())
+	f.notify(&csl, false)
+}
+
+// SetPhase moves a console into a new phase, as the controller would when its pod
+// starts or exits
+func (f *Fake) SetPhase(namespace, name string, phase workloadsv1alpha1.ConsolePhase) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	csl, err := f.find(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	csl.Status.Phase = phase
+	f.notify(csl, false)
+
+	return nil
+}
+
+// Delete removes a console from the fake
+func (f *Fake) Delete(namespace, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	csl, err := f.find(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	for idx, existing := range f.consoles {
+		if existing == csl {
+			f.consoles = append(f.consoles[:idx], f.consoles[idx+1:]...)
+			break
+		}
+	}
+
+	f.notify(csl, true)
+
+	return nil
+}
+
+// Authorisations returns the users that have authorised the console
+func (f *Fake) Authorisations(namespace, name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string{}, f.authorisations[namespace+"/"+name]...)
+}
+
+func (f *Fake) Create(ctx context.Context, namespace, selector string, opts ...CreateOption) (*workloadsv1alpha1.Console, error) {
+	if f.CreateFunc != nil {
+		return f.CreateFunc(ctx, namespace, selector, opts...)
+	}
+
+	o := applyCreateOptions(opts)
+
+	selectorSet, err := labels.ConvertSelectorToLabelsMap(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var templates []workloadsv1alpha1.ConsoleTemplate
+	for _, tpl := range f.templates {
+		if (namespace == "" || tpl.Namespace == namespace) && labels.SelectorFromSet(selectorSet).Matches(labels.Set(tpl.Labels)) {
+			templates = append(templates, tpl)
+		}
+	}
+
+	switch len(templates) {
+	case 0:
+		return nil, &TemplateNotFoundError{Namespace: namespace, Selector: selector}
+	case 1:
+	default:
+		return nil, &AmbiguousTemplateError{Selector: selector, Templates: templates}
+	}
+
+	tpl := templates[0]
+	f.created++
+
+	csl := &workloadsv1alpha1.Console{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%d", tpl.Name, f.created),
+			Namespace:         tpl.Namespace,
+			Labels:            labels.Merge(labels.Set{}, tpl.Labels),
+			CreationTimestamp: metav1.Now(),
+		},
+		Spec: workloadsv1alpha1.ConsoleSpec{
+			User:               f.User,
+			Reason:             o.reason,
+			TimeoutSeconds:     int(o.timeout.Seconds()),
+			ConsoleTemplateRef: corev1.LocalObjectReference{Name: tpl.Name},
+			Command:            o.command,
+			Noninteractive:     o.noninteractive || o.script != "",
+			Script:             o.script,
+		},
+	}
+
+	if csl.HasScript() {
+		checksum := sha256.Sum256([]byte(csl.Spec.Script))
+		csl.Spec.ScriptSHA256 = hex.EncodeToString(checksum[:])
+	}
+
+	rule, err := authorisationRule(csl, &tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	csl.Status.Phase = workloadsv1alpha1.ConsoleRunning
+	if rule != nil && rule.AuthorisationsRequired > 0 {
+		csl.Status.Phase = workloadsv1alpha1.ConsolePendingAuthorisation
+	}
+
+	f.consoles = append(f.consoles, csl)
+	f.notify(csl, false)
+
+	return csl.DeepCopy(), nil
+}
+
+func (f *Fake) Get(ctx context.Context, namespace, name string) (*workloadsv1alpha1.Console, error) {
+	if f.GetFunc != nil {
+		return f.GetFunc(ctx, namespace, name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	csl, err := f.find(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return csl.DeepCopy(), nil
+}
+
+func (f *Fake) Wait(ctx context.Context, namespace, name string, opts ...WaitOption) (*workloadsv1alpha1.Console, error) {
+	if f.WaitFunc != nil {
+		return f.WaitFunc(ctx, namespace, name, opts...)
+	}
+
+	csl, err := f.Get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if csl.PendingAuthorisation() {
+		return nil, &PendingAuthorisationError{Console: csl}
+	}
+
+	if csl.Failed() {
+		reason := "unknown reason"
+		if failure := csl.Status.PodFailure; failure != nil {
+			reason = failure.Reason
+		}
+
+		return nil, &ConsoleFailedError{Console: csl, Reason: reason}
+	}
+
+	return csl, nil
+}
+
+func (f *Fake) Attach(ctx context.Context, namespace, name string, streams IOStreams, opts ...AttachOption) error {
+	if f.AttachFunc != nil {
+		return f.AttachFunc(ctx, namespace, name, streams, opts...)
+	}
+
+	csl, err := f.Get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if !csl.Running() {
+		return &ConsoleNotRunningError{Console: csl}
+	}
+
+	return nil
+}
+
+func (f *Fake) Authorise(ctx context.Context, namespace, name, username string) error {
+	if f.AuthoriseFunc != nil {
+		return f.AuthoriseFunc(ctx, namespace, name, username)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	csl, err := f.find(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	denied := func(reason string) error {
+		return &AuthorisationDeniedError{Namespace: csl.Namespace, Name: csl.Name, Username: username, Reason: reason}
+	}
+
+	tpl := f.template(csl)
+	if tpl == nil {
+		return denied("console does not require authorisation")
+	}
+
+	rule, err := authorisationRule(csl, tpl)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return denied("console does not require authorisation")
+	}
+
+	// These mirror the rules enforced by the authorisation webhook
+	if username == csl.Spec.User {
+		return denied("an authoriser cannot authorise their own console")
+	}
+
+	key := csl.Namespace + "/" + csl.Name
+	for _, existing := range f.authorisations[key] {
+		if existing == username {
+			return denied("the user has already authorised this console")
+		}
+	}
+
+	f.authorisations[key] = append(f.authorisations[key], username)
+
+	if csl.PendingAuthorisation() && len(f.authorisations[key]) >= rule.AuthorisationsRequired {
+		csl.Status.Phase = workloadsv1alpha1.ConsoleRunning
+		f.notify(csl, false)
+	}
+
+	return nil
+}
+
+func (f *Fake) List(ctx context.Context, namespace string, opts ...ListOption) ([]workloadsv1alpha1.Console, error) {
+	if f.ListFunc != nil {
+		return f.ListFunc(ctx, namespace, opts...)
+	}
+
+	o := applyListOptions(opts)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	consoles := []workloadsv1alpha1.Console{}
+	for _, csl := range f.consoles {
+		match, err := f.matches(namespace, o, csl)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			consoles = append(consoles, *csl.DeepCopy())
+		}
+	}
+
+	return consoles, nil
+}
+
+// Watch sends the current state of every matching console, followed by changes made
+// through the fake, until the context is cancelled
+func (f *Fake) Watch(ctx context.Context, namespace string, opts ...ListOption) (<-chan Event, error) {
+	if f.WatchFunc != nil {
+		return f.WatchFunc(ctx, namespace, opts...)
+	}
+
+	o := applyListOptions(opts)
+	if _, err := labels.Parse(o.selector); err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	watcher := &fakeWatcher{namespace: namespace, opts: o, events: make(chan Event, fakeWatchBuffer)}
+	f.watchers[watcher] = struct{}{}
+
+	for _, csl := range f.consoles {
+		f.send(watcher, csl, false)
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		delete(f.watchers, watcher)
+		close(watcher.events)
+	}()
+
+	return watcher.events, nil
+}
+
+// find returns the stored console, searching all namespaces if none is given. Callers
+// must hold the lock.
+func (f *Fake) find(namespace, name string) (*workloadsv1alpha1.Console, error) {
+	var found *workloadsv1alpha1.Console
+	for _, csl := range f.consoles {
+		if csl.Name != name || (namespace != "" && csl.Namespace != namespace) {
+			continue
+		}
+
+		if found != nil {
+			return nil, fmt.Errorf("too many consoles found with name: %s, please specify namespace", name)
+		}
+
+		found = csl
+	}
+
+	if found == nil {
+		return nil, &ConsoleNotFoundError{Namespace: namespace, Name: name}
+	}
+
+	return found, nil
+}
+
+func (f *Fake) template(csl *workloadsv1alpha1.Console) *workloadsv1alpha1.ConsoleTemplate {
+	for idx, tpl := range f.templates {
+		if tpl.Namespace == csl.Namespace && tpl.Name == csl.Spec.ConsoleTemplateRef.Name {
+			return &f.templates[idx]
+		}
+	}
+
+	return nil
+}
+
+// matches applies the list options in the same way as the real client. Callers must
+// hold the lock.
+func (f *Fake) matches(namespace string, o listOptions, csl *workloadsv1alpha1.Console) (bool, error) {
+	if namespace != "" && csl.Namespace != namespace {
+		return false, nil
+	}
+
+	if o.user != "" && csl.Spec.User != o.user {
+		return false, nil
+	}
+
+	selector, err := labels.Parse(o.selector)
+	if err != nil {
+		return false, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	if !selector.Matches(labels.Set(csl.Labels)) {
+		return false, nil
+	}
+
+	if len(o.phases) > 0 {
+		match := false
+		for _, phase := range o.phases {
+			match = match || csl.Status.Phase == phase
+		}
+
+		if !match {
+			return false, nil
+		}
+	}
+
+	if o.authoriser == "" && len(o.authoriserGroups) == 0 {
+		return true, nil
+	}
+
+	return f.canAuthorise(csl, o.authoriser, o.authoriserGroups)
+}
+
+// canAuthorise applies the same rules as runner.CanAuthorise, to the fake's
+// authorisations. Callers must hold the lock.
+func (f *Fake) canAuthorise(csl *workloadsv1alpha1.Console, username string, groups []string) (bool, error) {
+	authz := &workloadsv1alpha1.ConsoleAuthorisation{}
+	for _, existing := range f.authorisations[csl.Namespace+"/"+csl.Name] {
+		authz.Spec.Authorisations = append(authz.Spec.Authorisations, rbacv1.Subject{Kind: rbacv1.UserKind, Name: existing})
+	}
+
+	return runner.CanAuthoriseConsole(csl, f.template(csl), authz, username, groups)
+}
+
+// notify sends the console to every watcher it matches. Callers must hold the lock.
+func (f *Fake) notify(csl *workloadsv1alpha1.Console, deleted bool) {
+	for watcher := range f.watchers {
+		f.send(watcher, csl, deleted)
+	}
+}
+
+func (f *Fake) send(watcher *fakeWatcher, csl *workloadsv1alpha1.Console, deleted bool) {
+	match, err := f.matches(watcher.namespace, watcher.opts, csl)
+	if err == nil && !match {
+		return
+	}
+
+	select {
+	case watcher.events <- Event{Console: csl.DeepCopy(), Deleted: deleted, Err: err}:
+	default:
+		panic(fmt.Sprintf("more than %d console events left unread, did you forget to read from Watch?", fakeWatchBuffer))
+	}
+}
+
+// authorisationRule returns the template's rule for the console's command, or nil if
+// the template has no rules
+func authorisationRule(csl *workloadsv1alpha1.Console, tpl *workloadsv1alpha1.ConsoleTemplate) (*workloadsv1alpha1.ConsoleAuthorisationRule, error) {
+	if !tpl.HasAuthorisationRules() {
+		return nil, nil
+	}
+
+	command, err := csl.GetCommand(tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := tpl.GetAuthorisationRuleForCommand(command)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+func newTemplate(name string, labels map[string]string) workloadsv1alpha1.ConsoleTemplate {
+	return workloadsv1alpha1.ConsoleTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec: workloadsv1alpha1.ConsoleTemplateSpec{
+			Template: workloadsv1alpha1.PodTemplatePreserveMetadataSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "console", Command: []string{"bin/rails", "console"}}},
+				},
+			},
+		},
+	}
+}
+
+var _ = Describe("Fake", func() {
+	var (
+		ctx  context.Context
+		fake *Fake
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		restricted := newTemplate("restricted", map[string]string{"app": "myapp", "access": "restricted"})
+		restricted.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{
+			AuthorisationsRequired: 1,
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.UserKind, Name: "alice"},
+				{Kind: rbacv1.GroupKind, Name: "sre"},
+			},
+		}
+
+		fake = NewFake(restricted, newTemplate("open", map[string]string{"app": "myapp", "access": "open"}))
+		fake.User = "bob"
+	})
+
+	Describe("Create", func() {
+		It("Creates a running console from the matching template", func() {
+			csl, err := fake.Create(ctx, "default", "access=open", WithReason("debugging"), WithCommand("bash"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(csl.Name).To(Equal("open-1"))
+			Expect(csl.Spec.User).To(Equal("bob"))
+			Expect(csl.Spec.Reason).To(Equal("debugging"))
+			Expect(csl.Spec.Command).To(Equal([]string{"bash"}))
+			Expect(csl.Status.Phase).To(Equal(workloadsv1alpha1.ConsoleRunning))
+		})
+
+		It("Requires authorisation when the template does", func() {
+			csl, err := fake.Create(ctx, "default", "access=restricted")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(csl.Status.Phase).To(Equal(workloadsv1alpha1.ConsolePendingAuthorisation))
+		})
+
+		It("Records the checksum of scripts", func() {
+			csl, err := fake.Create(ctx, "default", "access=open", WithScript("echo hello\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(csl.Spec.Noninteractive).To(BeTrue())
+			Expect(csl.Spec.ScriptSHA256).To(Equal("5dbad7dd0b9b122dcd9956884390f4aac4738caba8ff53498a7ab6718b176c30"))
+		})
+
+		It("Fails when no template matches", func() {
+			_, err := fake.Create(ctx, "default", "app=other")
+
+			var notFound *TemplateNotFoundError
+			Expect(errors.As(err, &notFound)).To(BeTrue())
+			Expect(notFound.Selector).To(Equal("app=other"))
+		})
+
+		It("Fails when several templates match", func() {
+			_, err := fake.Create(ctx, "default", "app=myapp")
+
+			var ambiguous *AmbiguousTemplateError
+			Expect(errors.As(err, &ambiguous)).To(BeTrue())
+			Expect(ambiguous.Templates).To(HaveLen(2))
+		})
+	})
+
+	Describe("Wait", func() {
+		It("Returns running consoles", func() {
+			csl, err := fake.Create(ctx, "default", "access=open")
+			Expect(err).NotTo(HaveOccurred())
+
+			ready, err := fake.Wait(ctx, csl.Namespace, csl.Name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready.Name).To(Equal(csl.Name))
+		})
+
+		It("Reports consoles awaiting authorisation", func() {
+			csl, err := fake.Create(ctx, "default", "access=restricted")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = fake.Wait(ctx, csl.Namespace, csl.Name, WithoutAuthorisation())
+
+			var pending *PendingAuthorisationError
+			Expect(errors.As(err, &pending)).To(BeTrue())
+			Expect(pending.Console.Name).To(Equal(csl.Name))
+		})
+
+		It("Reports consoles that failed to start", func() {
+			csl, err := fake.Create(ctx, "default", "access=open")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fake.SetPhase(csl.Namespace, csl.Name, workloadsv1alpha1.ConsoleFailed)).To(Succeed())
+
+			_, err = fake.Wait(ctx, csl.Namespace, csl.Name)
+
+			var failed *ConsoleFailedError
+			Expect(errors.As(err, &failed)).To(BeTrue())
+			Expect(failed.Console.Name).To(Equal(csl.Name))
+		})
+
+		It("Reports missing consoles", func() {
+			_, err := fake.Wait(ctx, "default", "missing")
+
+			var notFound *ConsoleNotFoundError
+			Expect(errors.As(err, &notFound)).To(BeTrue())
+		})
+	})
+
+	Describe("Attach", func() {
+		It("Refuses to attach to consoles that aren't running", func() {
+			csl, err := fake.Create(ctx, "default", "access=restricted")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = fake.Attach(ctx, csl.Namespace, csl.Name, IOStreams{})
+
+			var notRunning *ConsoleNotRunningError
+			Expect(errors.As(err, &notRunning)).To(BeTrue())
+		})
+
+		It("Can be overridden", func() {
+			fake.AttachFunc = func(context.Context, string, string, IOStreams, ...AttachOption) error {
+				return errors.New("connection refused")
+			}
+
+			Expect(fake.Attach(ctx, "default", "anything", IOStreams{})).To(MatchError("connection refused"))
+		})
+	})
+
+	Describe("Authorise", func() {
+		var csl *workloadsv1alpha1.Console
+
+		BeforeEach(func() {
+			var err error
+			csl, err = fake.Create(ctx, "default", "access=restricted")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Starts the console once authorised", func() {
+			Expect(fake.Authorise(ctx, csl.Namespace, csl.Name, "alice")).To(Succeed())
+			Expect(fake.Authorisations(csl.Namespace, csl.Name)).To(Equal([]string{"alice"}))
+
+			ready, err := fake.Wait(ctx, csl.Namespace, csl.Name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready.Status.Phase).To(Equal(workloadsv1alpha1.ConsoleRunning))
+		})
+
+		It("Rejects the owner of the console", func() {
+			err := fake.Authorise(ctx, csl.Namespace, csl.Name, "bob")
+
+			var denied *AuthorisationDeniedError
+			Expect(errors.As(err, &denied)).To(BeTrue())
+			Expect(denied.Reason).To(ContainSubstring("their own console"))
+		})
+
+		It("Rejects users that have already authorised the console", func() {
+			Expect(fake.Authorise(ctx, csl.Namespace, csl.Name, "alice")).To(Succeed())
+
+			var denied *AuthorisationDeniedError
+			Expect(errors.As(fake.Authorise(ctx, csl.Namespace, csl.Name, "alice"), &denied)).To(BeTrue())
+		})
+	})
+
+	Describe("List", func() {
+		BeforeEach(func() {
+			_, err := fake.Create(ctx, "default", "access=open")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fake.Create(ctx, "default", "access=restricted")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Lists every console", func() {
+			consoles, err := fake.List(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoles).To(HaveLen(2))
+		})
+
+		It("Filters by phase", func() {
+			consoles, err := fake.List(ctx, "default", InPhases(workloadsv1alpha1.ConsolePendingAuthorisation))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoles).To(HaveLen(1))
+			Expect(consoles[0].Name).To(Equal("restricted-2"))
+		})
+
+		It("Filters by labels", func() {
+			consoles, err := fake.List(ctx, "default", MatchingLabels("access=open"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoles).To(HaveLen(1))
+			Expect(consoles[0].Name).To(Equal("open-1"))
+		})
+
+		It("Filters by set-based selectors", func() {
+			consoles, err := fake.List(ctx, "default", MatchingLabels("access in (open,public)"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoles).To(HaveLen(1))
+			Expect(consoles[0].Name).To(Equal("open-1"))
+
+			consoles, err = fake.List(ctx, "default", MatchingLabels("app=myapp,access!=open"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoles).To(HaveLen(1))
+			Expect(consoles[0].Name).To(Equal("restricted-2"))
+		})
+
+		It("Rejects invalid selectors", func() {
+			_, err := fake.List(ctx, "default", MatchingLabels("access in open"))
+			Expect(err).To(MatchError(ContainSubstring("invalid selector")))
+		})
+
+		It("Filters by who can authorise", func() {
+			consoles, err := fake.List(ctx, "default", AuthorisableBy("carol", "sre"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoles).To(HaveLen(1))
+
+			consoles, err = fake.List(ctx, "default", AuthorisableBy("carol"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(consoles).To(BeEmpty())
+		})
+	})
+
+	Describe("Watch", func() {
+		It("Sends existing consoles followed by changes", func() {
+			csl, err := fake.Create(ctx, "default", "access=restricted")
+			Expect(err).NotTo(HaveOccurred())
+
+			watchCtx, cancel := context.WithCancel(ctx)
+			events, err := fake.Watch(watchCtx, "default")
+			Expect(err).NotTo(HaveOccurred())
+
+			var event Event
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Console.Status.Phase).To(Equal(workloadsv1alpha1.ConsolePendingAuthorisation))
+
+			Expect(fake.Authorise(ctx, csl.Namespace, csl.Name, "alice")).To(Succeed())
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Console.Status.Phase).To(Equal(workloadsv1alpha1.ConsoleRunning))
+
+			Expect(fake.Delete(csl.Namespace, csl.Name)).To(Succeed())
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Deleted).To(BeTrue())
+
+			cancel()
+			Eventually(events, time.Second).Should(BeClosed())
+		})
+	})
+})
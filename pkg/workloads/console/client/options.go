@@ -0,0 +1,162 @@
+package client
+
+import (
+	"time"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// CreateOption configures a console as it's created
+type CreateOption func(*createOptions)
+
+type createOptions struct {
+	command        []string
+	timeout        time.Duration
+	reason         string
+	script         string
+	noninteractive bool
+}
+
+// WithCommand runs the command in the console instead of the template's default
+func WithCommand(command ...string) CreateOption {
+	return func(o *createOptions) { o.command = command }
+}
+
+// WithTimeout sets how long the console can run for, which is clamped to the
+// template's maximum. The template's default is used if this isn't set.
+func WithTimeout(timeout time.Duration) CreateOption {
+	return func(o *createOptions) { o.timeout = timeout }
+}
+
+// WithReason records why the console is needed, which is shown to authorisers and
+// audited
+func WithReason(reason string) CreateOption {
+	return func(o *createOptions) { o.reason = reason }
+}
+
+// WithScript runs the script in the console, passing it to the command if one is
+// given. Consoles that run scripts are always noninteractive.
+func WithScript(script string) CreateOption {
+	return func(o *createOptions) { o.script = script }
+}
+
+// Noninteractive disables the TTY and stdin of the console container
+func Noninteractive() CreateOption {
+	return func(o *createOptions) { o.noninteractive = true }
+}
+
+// WaitOption configures how long Wait waits for
+type WaitOption func(*waitOptions)
+
+type waitOptions struct {
+	waitForAuthorisation bool
+}
+
+// WithoutAuthorisation returns a PendingAuthorisationError from Wait as soon as the
+// console is awaiting authorisation, rather than waiting until it's authorised
+func WithoutAuthorisation() WaitOption {
+	return func(o *waitOptions) { o.waitForAuthorisation = false }
+}
+
+// AttachOption configures how we attach to a console
+type AttachOption func(*attachOptions)
+
+type attachOptions struct {
+	scrollback        int64
+	reconnectAttempts int
+	readOnly          bool
+	onReconnect       func(csl *workloadsv1alpha1.Console, attempt int, err error)
+}
+
+// WithScrollback replays the given number of lines of recent output before attaching
+func WithScrollback(lines int64) AttachOption {
+	return func(o *attachOptions) { o.scrollback = lines }
+}
+
+// WithReconnectAttempts reconnects up to the given number of times if the connection
+// to the console drops while it's still running. The callback, if given, is notified
+// before each attempt.
+func WithReconnectAttempts(attempts int, onReconnect func(csl *workloadsv1alpha1.Console, attempt int, err error)) AttachOption {
+	return func(o *attachOptions) {
+		o.reconnectAttempts = attempts
+		o.onReconnect = onReconnect
+	}
+}
+
+// ReadOnly follows the console's output without sending any input, for consoles
+// shared in read-only mode
+func ReadOnly() AttachOption {
+	return func(o *attachOptions) { o.readOnly = true }
+}
+
+// ListOption narrows down the consoles returned by List and Watch
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	user             string
+	selector         string
+	phases           []workloadsv1alpha1.ConsolePhase
+	authoriser       string
+	authoriserGroups []string
+}
+
+// ForUser only includes consoles owned by the user
+func ForUser(user string) ListOption {
+	return func(o *listOptions) { o.user = user }
+}
+
+// MatchingLabels only includes consoles matching the selector, such as app=myapp or
+// "access in (open,restricted)"
+func MatchingLabels(selector string) ListOption {
+	return func(o *listOptions) { o.selector = selector }
+}
+
+// InPhases only includes consoles in one of the phases
+func InPhases(phases ...workloadsv1alpha1.ConsolePhase) ListOption {
+	return func(o *listOptions) { o.phases = phases }
+}
+
+// AuthorisableBy only includes consoles awaiting authorisation that the user, or a
+// member of one of the groups, can authorise
+func AuthorisableBy(user string, groups ...string) ListOption {
+	return func(o *listOptions) {
+		o.authoriser = user
+		o.authoriserGroups = groups
+	}
+}
+
+func applyCreateOptions(opts []CreateOption) createOptions {
+	o := createOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func applyWaitOptions(opts []WaitOption) waitOptions {
+	o := waitOptions{waitForAuthorisation: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func applyAttachOptions(opts []AttachOption) attachOptions {
+	o := attachOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func applyListOptions(opts []ListOption) listOptions {
+	o := listOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// activityReportInterval bounds how often we report activity while attached to a
+// console, as otherwise every keystroke would be a request to the API server. We
+// also report on this interval for as long as we're attached. It should be well
+// below the shortest idle timeout that a template can set.
+const activityReportInterval = 30 * time.Second
+
+// activityReporter records activity in the console's ConsoleActivity, which the
+// controller uses to stop consoles that have been idle for longer than their
+// template permits. Reporting is best effort: the activity object only exists when
+// the template has an idle timeout, and we'd rather carry on than interrupt the
+// console if we can't report to it.
+type activityReporter struct {
+	report   func()
+	interval time.Duration
+
+	mu           sync.Mutex
+	lastReported time.Time
+}
+
+func newActivityReporter(ctx context.Context, runner *Runner, csl *workloadsv1alpha1.Console) *activityReporter {
+	return &activityReporter{
+		report:   func() { runner.reportActivity(ctx, csl) },
+		interval: activityReportInterval,
+	}
+}
+
+// Report records activity in the console in the background, unless we've already
+// done so within the report interval
+func (a *activityReporter) Report() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if time.Since(a.lastReported) < a.interval {
+		return
+	}
+
+	a.lastReported = time.Now()
+	go a.report()
+}
+
+// Attach attaches to the console, reporting activity as it does so and then on the
+// report interval until it detaches. Being attached counts as activity even without
+// any input, such as when following the output of a long-running command.
+func (a *activityReporter) Attach(ctx context.Context, attacher Attacher, pod *corev1.Pod, containerName string, streams IOStreams) error {
+	a.Report()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				a.Report()
+			}
+		}
+	}()
+
+	return attacher.Attach(ctx, pod, containerName, streams)
+}
+
+// reportActivity updates the console's activity object, which an admission webhook
+// attributes to us at the time that it receives the update
+func (c *Runner) reportActivity(ctx context.Context, csl *workloadsv1alpha1.Console) error {
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"lastActivityTime": metav1.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// The activity object has the same name as the console
+	activity := &workloadsv1alpha1.ConsoleActivity{
+		ObjectMeta: metav1.ObjectMeta{Namespace: csl.Namespace, Name: csl.Name},
+	}
+
+	return c.kubeClient.Patch(ctx, activity, client.RawPatch(types.MergePatchType, patchBytes))
+}
+
+// activityReader reports activity whenever input is read from the underlying reader
+type activityReader struct {
+	io.Reader
+	reporter *activityReporter
+}
+
+func (r *activityReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.reporter.Report()
+	}
+
+	return n, err
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// idleAttacher stays attached for the given duration without sending any input
+type idleAttacher struct {
+	duration time.Duration
+}
+
+func (a idleAttacher) Attach(ctx context.Context, pod *corev1.Pod, containerName string, streams IOStreams) error {
+	time.Sleep(a.duration)
+	return nil
+}
+
+var _ = Describe("activityReporter", func() {
+	var (
+		reports  int32
+		reporter *activityReporter
+	)
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&reports, 0)
+		reporter = &activityReporter{
+			report:   func() { atomic.AddInt32(&reports, 1) },
+			interval: 20 * time.Millisecond,
+		}
+	})
+
+	It("Reports at most once per interval", func() {
+		for i := 0; i < 10; i++ {
+			reporter.Report()
+		}
+
+		Eventually(func() int32 { return atomic.LoadInt32(&reports) }).Should(Equal(int32(1)))
+		Consistently(func() int32 { return atomic.LoadInt32(&reports) }, 50*time.Millisecond).Should(Equal(int32(1)))
+	})
+
+	It("Keeps reporting activity while attached without any input", func() {
+		err := reporter.Attach(context.TODO(), idleAttacher{duration: 200 * time.Millisecond}, &corev1.Pod{}, "console", IOStreams{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() int32 { return atomic.LoadInt32(&reports) }).Should(BeNumerically(">=", 5),
+			"an attached session should report activity on every interval, so that it isn't stopped as idle")
+	})
+
+	It("Stops reporting once detached", func() {
+		err := reporter.Attach(context.TODO(), idleAttacher{duration: 50 * time.Millisecond}, &corev1.Pod{}, "console", IOStreams{})
+		Expect(err).NotTo(HaveOccurred())
+
+		time.Sleep(30 * time.Millisecond)
+		detached := atomic.LoadInt32(&reports)
+		Consistently(func() int32 { return atomic.LoadInt32(&reports) }, 100*time.Millisecond).Should(Equal(detached))
+	})
+})
@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gomodules.xyz/jsonpatch/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// CopyOptions encapsulates the arguments to copy a file into or out of a console
+type CopyOptions struct {
+	Namespace string
+
+	// Exactly one of the source and destination must refer to a path within a console,
+	// in the form <console-name>:<path>, while the other is a local path.
+	Source      string
+	Destination string
+}
+
+// copyTimeout is how long we wait for the controller to carry out a transfer
+const copyTimeout = 2 * time.Minute
+
+// Copy transfers a single file into or out of a running console, subject to the limits
+// set by its template. The transfer is requested through the console's transfer log,
+// and carried out by the controller, which enforces those limits and records the
+// size and checksum of what it copied.
+func (c *Runner) Copy(ctx context.Context, opts CopyOptions) (*workloadsv1alpha1.ConsoleFileTransfer, *workloadsv1alpha1.ConsoleFileTransferResult, error) {
+	srcConsole, srcPath, srcRemote := parseConsolePath(opts.Source)
+	dstConsole, dstPath, dstRemote := parseConsolePath(opts.Destination)
+
+	if srcRemote == dstRemote {
+		return nil, nil, fmt.Errorf("exactly one of the source or destination must be a console path, such as <console-name>:/tmp/file")
+	}
+
+	direction, consoleName := workloadsv1alpha1.FileTransferUpload, dstConsole
+	if srcRemote {
+		direction, consoleName = workloadsv1alpha1.FileTransferDownload, srcConsole
+	}
+
+	csl, err := c.FindConsoleByName(opts.Namespace, consoleName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !csl.Running() {
+		return nil, nil, fmt.Errorf("console %s is not running", csl.Name)
+	}
+
+	// The controller applies the template's limits, but checking them here lets us
+	// fail before reading or sending anything
+	tpl := &workloadsv1alpha1.ConsoleTemplate{}
+	err = c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Spec.ConsoleTemplateRef.Name}, tpl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get console template: %w", err)
+	}
+
+	if !tpl.FileTransferEnabled() {
+		return nil, nil, fmt.Errorf("file transfers are disabled for consoles created from template %s", tpl.Name)
+	}
+
+	transfer := &workloadsv1alpha1.ConsoleFileTransfer{Direction: direction, Path: srcPath}
+	if direction == workloadsv1alpha1.FileTransferUpload {
+		// Copying into a directory keeps the name of the file, as cp would
+		if strings.HasSuffix(dstPath, "/") {
+			dstPath = path.Join(dstPath, filepath.Base(srcPath))
+		}
+
+		info, err := os.Stat(srcPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if maxBytes := tpl.MaxFileTransferBytes(); info.Size() > maxBytes {
+			return nil, nil, fmt.Errorf("%s is %d bytes, which exceeds the limit of %d bytes", srcPath, info.Size(), maxBytes)
+		}
+
+		transfer.Path = dstPath
+		transfer.Content, err = ioutil.ReadFile(srcPath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, copyTimeout)
+	defer cancel()
+
+	transfer, result, err := c.requestTransfer(ctx, csl, transfer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if result.Phase != workloadsv1alpha1.FileTransferCompleted {
+		return transfer, result, fmt.Errorf("failed to copy file: %s", result.Message)
+	}
+
+	if direction == workloadsv1alpha1.FileTransferDownload {
+		if info, err := os.Stat(dstPath); err == nil && info.IsDir() {
+			dstPath = filepath.Join(dstPath, path.Base(srcPath))
+		}
+
+		if int64(len(result.Content)) != result.SizeBytes {
+			return transfer, result, fmt.Errorf("contents of %s are no longer available", srcPath)
+		}
+
+		if err := ioutil.WriteFile(dstPath, result.Content, 0600); err != nil {
+			return transfer, result, err
+		}
+	}
+
+	return transfer, result, nil
+}
+
+// requestTransfer appends the transfer to the console's transfer log, then waits for
+// the controller to carry it out. An admission webhook attributes the transfer to
+// us, and rejects it if it breaks the template's limits.
+func (c *Runner) requestTransfer(ctx context.Context, csl *workloadsv1alpha1.Console, transfer *workloadsv1alpha1.ConsoleFileTransfer) (*workloadsv1alpha1.ConsoleFileTransfer, *workloadsv1alpha1.ConsoleFileTransferResult, error) {
+	patch := []jsonpatch.Operation{
+		jsonpatch.NewOperation("add", "/spec/transfers/-", transfer),
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The transfer log has the same name as the console
+	transferLog := &workloadsv1alpha1.ConsoleTransferLog{
+		ObjectMeta: metav1.ObjectMeta{Namespace: csl.Namespace, Name: csl.Name},
+	}
+
+	err = c.kubeClient.Patch(ctx, transferLog, client.ConstantPatch(types.JSONPatchType, patchBytes))
+	if apierrors.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("console %s does not permit file transfers", csl.Name)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to request file transfer: %w", err)
+	}
+
+	// Transfers are only ever appended, so ours is the last in the patched log
+	idx := len(transferLog.Spec.Transfers) - 1
+	recorded := transferLog.Spec.Transfers[idx]
+
+	var result *workloadsv1alpha1.ConsoleFileTransferResult
+	err = wait.PollImmediateUntil(time.Second, func() (bool, error) {
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Name}, transferLog); err != nil {
+			return false, err
+		}
+
+		if len(transferLog.Status.Results) <= idx {
+			return false, nil
+		}
+
+		result = &transferLog.Status.Results[idx]
+		return true, nil
+	}, ctx.Done())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed waiting for file transfer to complete: %w", err)
+	}
+
+	return &recorded, result, nil
+}
+
+// parseConsolePath splits a path of the form <console-name>:<path>, returning false if
+// the path is local
+func parseConsolePath(p string) (string, string, bool) {
+	idx := strings.Index(p, ":")
+	if idx <= 0 || idx == len(p)-1 || strings.Contains(p[:idx], "/") {
+		return "", p, false
+	}
+
+	return p[:idx], p[idx+1:], true
+}
@@ -0,0 +1,329 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// DescribeOptions encapsulates the arguments to describe a console
+type DescribeOptions struct {
+	Namespace   string
+	ConsoleName string
+}
+
+// ConsoleDescription gathers everything needed to explain the state of a console
+type ConsoleDescription struct {
+	Console  *workloadsv1alpha1.Console
+	Template *workloadsv1alpha1.ConsoleTemplate
+
+	// The command the console runs, falling back to the template default, and
+	// including the path to its script if it has one
+	Command []string
+
+	// The authorisation rule that matched the command, or nil if the console doesn't
+	// require authorisation
+	AuthorisationRule *workloadsv1alpha1.ConsoleAuthorisationRule
+
+	// Subjects that have authorised the console, and those from the matched rule that
+	// could still do so
+	Authorisations     []rbacv1.Subject
+	PendingAuthorisers []rbacv1.Subject
+
+	// The console pod, if it exists
+	Pod *corev1.Pod
+
+	// What has happened to the console so far, oldest first
+	Timeline []TimelineEntry
+}
+
+// TimelineEntry is a single thing that happened to a console, or will happen, in the
+// case of it expiring
+type TimelineEntry struct {
+	Time    time.Time
+	Source  string
+	Reason  string
+	Message string
+}
+
+// Describe gathers the console alongside its template, authorisation and pod, and
+// reconstructs a timeline of what has happened to it from events and pod status.
+// Anything that no longer exists, or that we lack permission to see, is omitted.
+func (c *Runner) Describe(ctx context.Context, opts DescribeOptions) (*ConsoleDescription, error) {
+	csl, err := c.Get(ctx, GetOptions{Namespace: opts.Namespace, ConsoleName: opts.ConsoleName})
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &ConsoleDescription{Console: csl, Command: csl.Spec.Command}
+
+	tpl := &workloadsv1alpha1.ConsoleTemplate{}
+	err = c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Spec.ConsoleTemplateRef.Name}, tpl)
+	if ignoreMissing(err) != nil {
+		return nil, fmt.Errorf("failed to get console template: %w", err)
+	}
+
+	if err == nil {
+		desc.Template = tpl
+		if err := desc.resolveAuthorisation(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+
+	if csl.Status.PodName != "" {
+		pod := &corev1.Pod{}
+		err := c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Status.PodName}, pod)
+		if ignoreMissing(err) != nil {
+			return nil, fmt.Errorf("failed to get console pod: %w", err)
+		}
+
+		if err == nil {
+			desc.Pod = pod
+		}
+	}
+
+	if err := desc.buildTimeline(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return desc, nil
+}
+
+// resolveAuthorisation works out which authorisation rule applies to the console, in
+// the same way as the controller, and how far along authorisation is
+func (d *ConsoleDescription) resolveAuthorisation(ctx context.Context, c *Runner) error {
+	command, err := d.Console.GetCommand(d.Template)
+	if err != nil {
+		return err
+	}
+
+	d.Command = command
+
+	if !d.Template.HasAuthorisationRules() {
+		return nil
+	}
+
+	rule, err := d.Template.GetAuthorisationRuleForCommand(d.Command)
+	if err != nil {
+		return err
+	}
+
+	d.AuthorisationRule = &rule
+
+	// The authorisation object has the same name as the console
+	authz := &workloadsv1alpha1.ConsoleAuthorisation{}
+	err = c.kubeClient.Get(ctx, client.ObjectKey{Namespace: d.Console.Namespace, Name: d.Console.Name}, authz)
+	if ignoreMissing(err) != nil {
+		return fmt.Errorf("failed to get console authorisation: %w", err)
+	}
+
+	d.Authorisations = authz.Spec.Authorisations
+	if len(d.Authorisations) >= rule.AuthorisationsRequired {
+		return nil
+	}
+
+	// Owners can't authorise their own console, and nobody can authorise it twice.
+	// Groups remain eligible, as other members could still authorise.
+	excluded := map[string]bool{d.Console.Spec.User: true}
+	for _, subject := range d.Authorisations {
+		excluded[subject.Name] = true
+	}
+
+	for _, subject := range rule.Subjects {
+		if subject.Kind == rbacv1.UserKind && excluded[subject.Name] {
+			continue
+		}
+
+		d.PendingAuthorisers = append(d.PendingAuthorisers, subject)
+	}
+
+	return nil
+}
+
+// buildTimeline merges events for the console and its pod with what we know from their
+// status, as events expire long before consoles do
+func (d *ConsoleDescription) buildTimeline(ctx context.Context, c *Runner) error {
+	csl := d.Console
+
+	d.Timeline = append(d.Timeline, TimelineEntry{
+		Time: csl.CreationTimestamp.Time, Source: "Console", Reason: "Created",
+		Message: fmt.Sprintf("Created by %s", csl.Spec.User),
+	})
+
+	sources := []corev1.ObjectReference{{Kind: "Console", Name: csl.Name}}
+	if d.Pod != nil {
+		sources = append(sources, corev1.ObjectReference{Kind: "Pod", Name: d.Pod.Name})
+	}
+
+	for _, source := range sources {
+		var events corev1.EventList
+		err := c.kubeClient.List(
+			ctx, &events,
+			client.InNamespace(csl.Namespace),
+			client.MatchingFields{"involvedObject.kind": source.Kind, "involvedObject.name": source.Name},
+		)
+		// Users aren't always permitted to view events, in which case we make do with
+		// the rest of the timeline
+		if apierrors.IsForbidden(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for _, event := range events.Items {
+			eventTime := event.FirstTimestamp.Time
+			if eventTime.IsZero() {
+				eventTime = event.EventTime.Time
+			}
+
+			d.Timeline = append(d.Timeline, TimelineEntry{
+				Time: eventTime, Source: source.Kind, Reason: event.Reason, Message: event.Message,
+			})
+		}
+	}
+
+	if d.Pod != nil {
+		if d.Pod.Status.StartTime != nil {
+			d.Timeline = append(d.Timeline, TimelineEntry{
+				Time: d.Pod.Status.StartTime.Time, Source: "Pod", Reason: "Scheduled",
+				Message: fmt.Sprintf("Scheduled to %s", d.Pod.Spec.NodeName),
+			})
+		}
+
+		for _, status := range d.Pod.Status.ContainerStatuses {
+			if running := status.State.Running; running != nil {
+				d.Timeline = append(d.Timeline, TimelineEntry{
+					Time: running.StartedAt.Time, Source: "Pod", Reason: "ContainerStarted",
+					Message: fmt.Sprintf("Container %s started", status.Name),
+				})
+			}
+
+			if terminated := status.State.Terminated; terminated != nil {
+				d.Timeline = append(d.Timeline, TimelineEntry{
+					Time: terminated.FinishedAt.Time, Source: "Pod", Reason: "ContainerTerminated",
+					Message: fmt.Sprintf("Container %s exited with code %d (%s)", status.Name, terminated.ExitCode, terminated.Reason),
+				})
+			}
+		}
+	}
+
+	if csl.Status.CompletionTime != nil {
+		d.Timeline = append(d.Timeline, TimelineEntry{
+			Time: csl.Status.CompletionTime.Time, Source: "Console", Reason: "Completed",
+			Message: "Console completed successfully",
+		})
+	}
+
+	// Only running consoles will expire, which we show as a future entry
+	if csl.Running() && csl.Status.ExpiryTime != nil {
+		d.Timeline = append(d.Timeline, TimelineEntry{
+			Time: csl.Status.ExpiryTime.Time, Source: "Console", Reason: "Expires",
+			Message: fmt.Sprintf("Console will be terminated in %s", duration.HumanDuration(time.Until(csl.Status.ExpiryTime.Time))),
+		})
+	}
+
+	sort.SliceStable(d.Timeline, func(i, j int) bool {
+		return d.Timeline[i].Time.Before(d.Timeline[j].Time)
+	})
+
+	return nil
+}
+
+// Print writes the description in a similar style to kubectl describe
+func (d *ConsoleDescription) Print(output io.Writer) error {
+	w := tabwriter.NewWriter(output, 0, 8, 2, ' ', 0)
+	csl := d.Console
+
+	command := "<unknown>"
+	if len(d.Command) > 0 {
+		command = strings.Join(d.Command, " ")
+	}
+
+	template := csl.Spec.ConsoleTemplateRef.Name
+	if d.Template == nil {
+		template += " (not found)"
+	}
+
+	fmt.Fprintf(w, "Name:\t%s\n", csl.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", csl.Namespace)
+	fmt.Fprintf(w, "User:\t%s\n", csl.Spec.User)
+	fmt.Fprintf(w, "Reason:\t%s\n", csl.Spec.Reason)
+	fmt.Fprintf(w, "Template:\t%s\n", template)
+	fmt.Fprintf(w, "Command:\t%s\n", command)
+	if csl.HasScript() {
+		fmt.Fprintf(w, "Script SHA256:\t%s\n", csl.Spec.ScriptSHA256)
+	}
+	fmt.Fprintf(w, "Phase:\t%s\n", csl.Status.Phase)
+	if failure := csl.Status.PodFailure; failure != nil {
+		fmt.Fprintf(w, "Pod failure:\t%s since %s: %s\n", failure.Reason, failure.Since.Format(time.RFC3339), failure.Message)
+	}
+
+	if d.Pod != nil {
+		fmt.Fprintf(w, "Pod:\t%s (%s)\n", d.Pod.Name, d.Pod.Status.Phase)
+	} else {
+		fmt.Fprintf(w, "Pod:\t<none>\n")
+	}
+
+	if csl.Status.ExpiryTime != nil {
+		fmt.Fprintf(w, "Expiry:\t%s\n", csl.Status.ExpiryTime.String())
+	} else {
+		fmt.Fprintf(w, "Expiry:\t<none>\n")
+	}
+
+	fmt.Fprintf(w, "Authorisation:\t")
+	if rule := d.AuthorisationRule; rule != nil {
+		fmt.Fprintf(w, "\n  Rule:\t%s\n", rule.Name)
+		fmt.Fprintf(w, "  Given:\t%d/%d %s\n", len(d.Authorisations), rule.AuthorisationsRequired, formatSubjects(d.Authorisations))
+		fmt.Fprintf(w, "  Can still authorise:\t%s\n", formatSubjects(d.PendingAuthorisers))
+	} else if d.Template != nil {
+		fmt.Fprintf(w, "Not required\n")
+	} else {
+		fmt.Fprintf(w, "<unknown>\n")
+	}
+
+	fmt.Fprintf(w, "Timeline:\n")
+	fmt.Fprintf(w, "  TIME\tSOURCE\tREASON\tMESSAGE\n")
+	for _, entry := range d.Timeline {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", entry.Time.Format(time.RFC3339), entry.Source, entry.Reason, entry.Message)
+	}
+
+	// Flush the printed buffer to output
+	return w.Flush()
+}
+
+// formatSubjects prints subjects as Kind:Name, like the lifecycle printer
+func formatSubjects(subjects []rbacv1.Subject) string {
+	if len(subjects) == 0 {
+		return "<none>"
+	}
+
+	names := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		names = append(names, subject.Kind+":"+subject.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// ignoreMissing is client.IgnoreNotFound, but also ignores errors caused by not being
+// permitted to view a resource
+func ignoreMissing(err error) error {
+	if apierrors.IsForbidden(err) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(err)
+}
@@ -1,15 +1,19 @@
 package integration
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
@@ -492,4 +496,489 @@ var _ = Describe("Runner", func() {
 			})
 		})
 	})
+
+	Describe("List", func() {
+		var (
+			namespace corev1.Namespace
+			output    bytes.Buffer
+			format    string
+			err       error
+		)
+
+		BeforeEach(func() {
+			namespace = newNamespace("")
+			mustCreateNamespace(namespace)
+
+			output.Reset()
+			mustCreateConsole(newConsole(namespace.Name, "listed", "test", "test-user", map[string]string{}))
+		})
+
+		JustBeforeEach(func() {
+			_, err = consoleRunner.List(context.TODO(), runner.ListOptions{
+				Namespace:    namespace.Name,
+				Output:       &output,
+				OutputFormat: format,
+			})
+		})
+
+		Context("With json output", func() {
+			BeforeEach(func() { format = "json" })
+
+			It("Prints a console list", func() {
+				Expect(err).NotTo(HaveOccurred())
+
+				var list workloadsv1alpha1.ConsoleList
+				Expect(json.Unmarshal(output.Bytes(), &list)).To(Succeed())
+				Expect(list.Kind).To(Equal("ConsoleList"))
+				Expect(list.Items).To(HaveLen(1))
+				Expect(list.Items[0].Name).To(Equal("listed"))
+			})
+		})
+
+		Context("With jsonpath output", func() {
+			BeforeEach(func() { format = "jsonpath={.items[*].spec.user}" })
+
+			It("Prints the requested fields", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output.String()).To(Equal("test-user"))
+			})
+		})
+
+		Context("With wide output", func() {
+			BeforeEach(func() { format = "wide" })
+
+			It("Prints the extra columns", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output.String()).To(ContainSubstring("AUTHORISATIONS"))
+				Expect(output.String()).To(ContainSubstring("<template default>"))
+			})
+		})
+
+		Context("With an unknown output format", func() {
+			BeforeEach(func() { format = "xml" })
+
+			It("Fails without printing", func() {
+				Expect(err).To(MatchError(ContainSubstring("unsupported output format")))
+				Expect(output.String()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("ListTemplates", func() {
+		var (
+			namespace corev1.Namespace
+			output    bytes.Buffer
+			format    string
+			templates runner.TemplateSlice
+			err       error
+		)
+
+		BeforeEach(func() {
+			namespace = newNamespace("")
+			mustCreateNamespace(namespace)
+
+			output.Reset()
+			format = ""
+
+			restricted := newConsoleTemplate(namespace.Name, "restricted", map[string]string{"app": "myapp", "access": "restricted"})
+			restricted.Spec.DefaultTimeoutSeconds = 600
+			restricted.Spec.MaxTimeoutSeconds = 3600
+			restricted.Spec.Template.Spec.Containers[0].Command = []string{"bin/rails", "console"}
+			restricted.Spec.AuthorisationRules = []workloadsv1alpha1.ConsoleAuthorisationRule{
+				{
+					Name:                 "bash",
+					MatchCommandElements: []string{"bash"},
+					ConsoleAuthorisers:   workloadsv1alpha1.ConsoleAuthorisers{AuthorisationsRequired: 2},
+				},
+			}
+			restricted.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{AuthorisationsRequired: 1}
+
+			mustCreateConsoleTemplate(restricted)
+			mustCreateConsoleTemplate(newConsoleTemplate(namespace.Name, "open", map[string]string{"app": "myapp", "access": "open"}))
+		})
+
+		JustBeforeEach(func() {
+			templates, err = consoleRunner.ListTemplates(context.TODO(), runner.ListTemplatesOptions{
+				Namespace:    namespace.Name,
+				Output:       &output,
+				OutputFormat: format,
+			})
+		})
+
+		It("Lists templates sorted by name", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(templates).To(HaveLen(2))
+			Expect(templates[0].Name).To(Equal("open"))
+			Expect(templates[1].Name).To(Equal("restricted"))
+		})
+
+		It("Summarises each template", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.String()).To(ContainSubstring("access=restricted,app=myapp"))
+			Expect(output.String()).To(ContainSubstring("bin/rails console"))
+			Expect(output.String()).To(ContainSubstring("10m0s/1h0m0s"))
+			Expect(output.String()).To(ContainSubstring("bash=2,default=1"))
+		})
+
+		Context("With json output", func() {
+			BeforeEach(func() { format = "json" })
+
+			It("Prints a console template list", func() {
+				Expect(err).NotTo(HaveOccurred())
+
+				var list workloadsv1alpha1.ConsoleTemplateList
+				Expect(json.Unmarshal(output.Bytes(), &list)).To(Succeed())
+				Expect(list.Kind).To(Equal("ConsoleTemplateList"))
+				Expect(list.Items).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("PickTemplate", func() {
+		var (
+			templates runner.TemplateSlice
+			input     string
+			errOut    bytes.Buffer
+			picked    *workloadsv1alpha1.ConsoleTemplate
+			err       error
+		)
+
+		BeforeEach(func() {
+			errOut.Reset()
+			templates = runner.TemplateSlice{
+				newConsoleTemplate("default", "first", map[string]string{"app": "first"}),
+				newConsoleTemplate("default", "second", map[string]string{"app": "second"}),
+			}
+		})
+
+		JustBeforeEach(func() {
+			picked, err = runner.PickTemplate(
+				runner.IOStreams{In: bytes.NewBufferString(input), Out: &bytes.Buffer{}, ErrOut: &errOut},
+				templates,
+			)
+		})
+
+		Context("When a valid template is chosen", func() {
+			BeforeEach(func() { input = "2\n" })
+
+			It("Returns that template", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(picked.Name).To(Equal("second"))
+				Expect(errOut.String()).To(ContainSubstring("[1]"))
+				Expect(errOut.String()).To(ContainSubstring("app=first"))
+			})
+		})
+
+		Context("When an invalid choice is followed by a valid one", func() {
+			BeforeEach(func() { input = "3\n1\n" })
+
+			It("Asks again", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(picked.Name).To(Equal("first"))
+				Expect(errOut.String()).To(ContainSubstring("Please enter a number between 1 and 2"))
+			})
+		})
+
+		Context("When no choice is made", func() {
+			BeforeEach(func() { input = "" })
+
+			It("Fails", func() {
+				Expect(err).To(MatchError("no console template chosen"))
+			})
+		})
+	})
+
+	Describe("AuthorisationProgress", func() {
+		var (
+			namespace       corev1.Namespace
+			consoleTemplate workloadsv1alpha1.ConsoleTemplate
+			consoles        runner.ConsoleSlice
+		)
+
+		BeforeEach(func() {
+			namespace = newNamespace("")
+			mustCreateNamespace(namespace)
+
+			consoleTemplate = newConsoleTemplate(namespace.Name, "test", map[string]string{})
+			consoleTemplate.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{
+				AuthorisationsRequired: 2,
+				Subjects:               []rbacv1.Subject{{Kind: "User", Name: "authoriser"}},
+			}
+			consoleTemplate.Spec.AuthorisationRules = []workloadsv1alpha1.ConsoleAuthorisationRule{
+				{
+					Name:                 "read-only",
+					MatchCommandElements: []string{"ls", "**"},
+					ConsoleAuthorisers:   workloadsv1alpha1.ConsoleAuthorisers{AuthorisationsRequired: 0},
+				},
+			}
+			mustCreateConsoleTemplate(consoleTemplate)
+
+			needsAuth := newConsole(namespace.Name, "needs-auth", consoleTemplate.Name, "test-user", map[string]string{})
+			needsAuth.Spec.Command = []string{"rails", "console"}
+			noAuth := newConsole(namespace.Name, "no-auth", consoleTemplate.Name, "test-user", map[string]string{})
+			noAuth.Spec.Command = []string{"ls", "-la"}
+			consoles = runner.ConsoleSlice{needsAuth, noAuth}
+
+			authz := workloadsv1alpha1.ConsoleAuthorisation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name, Name: needsAuth.Name},
+				Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+					ConsoleRef:     corev1.LocalObjectReference{Name: needsAuth.Name},
+					Authorisations: []rbacv1.Subject{{Kind: "User", Name: "authoriser"}},
+				},
+			}
+			Expect(kubeClient.Create(context.TODO(), &authz)).To(Succeed())
+		})
+
+		It("Reports given against required authorisations", func() {
+			progress, err := consoleRunner.AuthorisationProgress(context.TODO(), namespace.Name, consoles)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress).To(Equal(map[types.NamespacedName]string{
+				{Namespace: namespace.Name, Name: "needs-auth"}: "1/2",
+			}))
+		})
+	})
+
+	Describe("Watch", func() {
+		var (
+			namespace corev1.Namespace
+			output    *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			namespace = newNamespace("")
+			mustCreateNamespace(namespace)
+
+			output = gbytes.NewBuffer()
+
+			running := newConsole(namespace.Name, "running", "test", "test-user", map[string]string{})
+			running.Status.Phase = workloadsv1alpha1.ConsoleRunning
+			mustCreateConsole(running)
+
+			stopped := newConsole(namespace.Name, "stopped", "test", "test-user", map[string]string{})
+			stopped.Status.Phase = workloadsv1alpha1.ConsoleStopped
+			mustCreateConsole(stopped)
+		})
+
+		It("Streams phase transitions of matching consoles", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error)
+			go func() {
+				done <- consoleRunner.Watch(ctx, runner.WatchOptions{
+					Namespace: namespace.Name,
+					Filter: runner.ConsoleFilter{
+						Phases: []workloadsv1alpha1.ConsolePhase{
+							workloadsv1alpha1.ConsoleRunning, workloadsv1alpha1.ConsolePending,
+						},
+					},
+					Output: output,
+				})
+			}()
+
+			By("Printing the current state of matching consoles")
+			Eventually(output).Should(gbytes.Say("running"))
+			Consistently(output.Contents).ShouldNot(ContainSubstring("stopped"))
+
+			By("Printing consoles as they transition")
+			pending := newConsole(namespace.Name, "pending", "test", "test-user", map[string]string{})
+			mustCreateConsole(pending)
+			mustUpdateConsolePhase(pending, workloadsv1alpha1.ConsolePending)
+			Eventually(output).Should(gbytes.Say("pending"))
+
+			cancel()
+			Eventually(done).Should(Receive(MatchError(context.Canceled)))
+		})
+	})
+
+	Describe("CanAuthorise", func() {
+		var (
+			namespace       corev1.Namespace
+			consoleTemplate workloadsv1alpha1.ConsoleTemplate
+			console         workloadsv1alpha1.Console
+			authorisations  []rbacv1.Subject
+		)
+
+		BeforeEach(func() {
+			namespace = newNamespace("")
+			mustCreateNamespace(namespace)
+
+			consoleTemplate = newConsoleTemplate(namespace.Name, "test", map[string]string{})
+			consoleTemplate.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{
+				AuthorisationsRequired: 2,
+				Subjects: []rbacv1.Subject{
+					{Kind: "User", Name: "alice"},
+					{Kind: "User", Name: "bob"},
+					{Kind: "Group", Name: "sre"},
+				},
+			}
+			mustCreateConsoleTemplate(consoleTemplate)
+
+			console = newConsole(namespace.Name, "pending-auth", consoleTemplate.Name, "bob", map[string]string{})
+			console.Spec.Command = []string{"rails", "console"}
+			console.Status.Phase = workloadsv1alpha1.ConsolePendingAuthorisation
+			authorisations = []rbacv1.Subject{}
+		})
+
+		JustBeforeEach(func() {
+			authz := workloadsv1alpha1.ConsoleAuthorisation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name, Name: console.Name},
+				Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+					ConsoleRef:     corev1.LocalObjectReference{Name: console.Name},
+					Authorisations: authorisations,
+				},
+			}
+			Expect(kubeClient.Create(context.TODO(), &authz)).To(Succeed())
+		})
+
+		canAuthorise := func(username string, groups ...string) bool {
+			ok, err := consoleRunner.CanAuthorise(context.TODO(), &console, username, groups)
+			Expect(err).NotTo(HaveOccurred())
+			return ok
+		}
+
+		It("Allows subjects of the rule", func() {
+			Expect(canAuthorise("alice")).To(BeTrue())
+			Expect(canAuthorise("carol", "sre")).To(BeTrue())
+		})
+
+		It("Rejects users who aren't subjects of the rule", func() {
+			Expect(canAuthorise("carol")).To(BeFalse())
+			Expect(canAuthorise("carol", "developers")).To(BeFalse())
+		})
+
+		It("Rejects the owner of the console", func() {
+			Expect(canAuthorise("bob")).To(BeFalse())
+		})
+
+		Context("When the user has already authorised the console", func() {
+			BeforeEach(func() {
+				authorisations = []rbacv1.Subject{{Kind: "User", Name: "alice"}}
+			})
+
+			It("Rejects them", func() {
+				Expect(canAuthorise("alice")).To(BeFalse())
+			})
+		})
+
+		Context("When the console isn't awaiting authorisation", func() {
+			BeforeEach(func() {
+				console.Status.Phase = workloadsv1alpha1.ConsoleRunning
+			})
+
+			It("Rejects everyone", func() {
+				Expect(canAuthorise("alice")).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Describe", func() {
+		var (
+			namespace       corev1.Namespace
+			consoleTemplate workloadsv1alpha1.ConsoleTemplate
+			console         workloadsv1alpha1.Console
+		)
+
+		BeforeEach(func() {
+			namespace = newNamespace("")
+			mustCreateNamespace(namespace)
+
+			consoleTemplate = newConsoleTemplate(namespace.Name, "test", map[string]string{})
+			consoleTemplate.Spec.AuthorisationRules = []workloadsv1alpha1.ConsoleAuthorisationRule{
+				{
+					Name:                 "rails-console",
+					MatchCommandElements: []string{"rails", "console"},
+					ConsoleAuthorisers: workloadsv1alpha1.ConsoleAuthorisers{
+						AuthorisationsRequired: 2,
+						Subjects: []rbacv1.Subject{
+							{Kind: "User", Name: "alice"},
+							{Kind: "User", Name: "bob"},
+							{Kind: "User", Name: "carol"},
+							{Kind: "Group", Name: "sre"},
+						},
+					},
+				},
+			}
+			consoleTemplate.Spec.DefaultAuthorisationRule = &workloadsv1alpha1.ConsoleAuthorisers{}
+			mustCreateConsoleTemplate(consoleTemplate)
+
+			console = newConsole(namespace.Name, "pending-auth", consoleTemplate.Name, "bob", map[string]string{})
+			console.Spec.Command = []string{"rails", "console"}
+			console.Status.Phase = workloadsv1alpha1.ConsolePendingAuthorisation
+			mustCreateConsole(console)
+
+			authz := workloadsv1alpha1.ConsoleAuthorisation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name, Name: console.Name},
+				Spec: workloadsv1alpha1.ConsoleAuthorisationSpec{
+					ConsoleRef:     corev1.LocalObjectReference{Name: console.Name},
+					Authorisations: []rbacv1.Subject{{Kind: "User", Name: "alice"}},
+				},
+			}
+			Expect(kubeClient.Create(context.TODO(), &authz)).To(Succeed())
+		})
+
+		It("Explains the console's authorisation", func() {
+			desc, err := consoleRunner.Describe(context.TODO(), runner.DescribeOptions{Namespace: namespace.Name, ConsoleName: console.Name})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(desc.Template.Name).To(Equal(consoleTemplate.Name))
+			Expect(desc.AuthorisationRule.Name).To(Equal("rails-console"))
+			Expect(desc.Authorisations).To(ConsistOf(rbacv1.Subject{Kind: "User", Name: "alice"}))
+
+			By("Excluding the owner and existing authorisers from those that can still authorise")
+			Expect(desc.PendingAuthorisers).To(ConsistOf(
+				rbacv1.Subject{Kind: "User", Name: "carol"},
+				rbacv1.Subject{Kind: "Group", Name: "sre"},
+			))
+
+			var out bytes.Buffer
+			Expect(desc.Print(&out)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("Rule:"))
+			Expect(out.String()).To(ContainSubstring("1/2 User:alice"))
+		})
+
+		It("Starts the timeline with the console's creation", func() {
+			desc, err := consoleRunner.Describe(context.TODO(), runner.DescribeOptions{Namespace: namespace.Name, ConsoleName: console.Name})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(desc.Timeline).NotTo(BeEmpty())
+			Expect(desc.Timeline[0].Reason).To(Equal("Created"))
+		})
+	})
+
+	Describe("FindLatestAttachableConsole", func() {
+		var namespace corev1.Namespace
+
+		mustCreateRunningConsole := func(name, username string) {
+			csl := newConsole(namespace.Name, name, "test", username, map[string]string{})
+			csl.Status.Phase = workloadsv1alpha1.ConsoleRunning
+			csl.Status.PodName = name + "-pod"
+			mustCreateConsole(csl)
+		}
+
+		BeforeEach(func() {
+			namespace = newNamespace("")
+			mustCreateNamespace(namespace)
+
+			mustCreateRunningConsole("alice-older", "alice")
+			// Creation timestamps have second precision
+			time.Sleep(1100 * time.Millisecond)
+			mustCreateRunningConsole("alice-newer", "alice")
+
+			stopped := newConsole(namespace.Name, "alice-stopped", "test", "alice", map[string]string{})
+			stopped.Status.Phase = workloadsv1alpha1.ConsoleStopped
+			mustCreateConsole(stopped)
+		})
+
+		It("Finds the most recently created running console", func() {
+			csl, err := consoleRunner.FindLatestAttachableConsole(context.TODO(), namespace.Name, "alice")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(csl.Name).To(Equal("alice-newer"))
+		})
+
+		It("Fails when the user has no running consoles", func() {
+			_, err := consoleRunner.FindLatestAttachableConsole(context.TODO(), namespace.Name, "bob")
+			Expect(err).To(MatchError(ContainSubstring("no running consoles found")))
+		})
+	})
 })
@@ -0,0 +1,205 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/kubectl/pkg/cmd/get"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// Output formats supported when printing consoles, mirroring those of kubectl get
+const (
+	OutputFormatTable      = ""
+	OutputFormatWide       = "wide"
+	OutputFormatJSON       = "json"
+	OutputFormatYAML       = "yaml"
+	OutputFormatJSONPath   = "jsonpath="
+	OutputFormatGoTemplate = "go-template="
+)
+
+// consoleColumns are the columns printed for the default table output
+const consoleColumns = "NAME:.metadata.name,NAMESPACE:.metadata.namespace,PHASE:.status.phase,CREATED:.metadata.creationTimestamp,USER:.spec.user,REASON:.spec.reason"
+
+// PrintOptions configure how consoles are printed
+type PrintOptions struct {
+	// One of "", "wide", "json", "yaml", "jsonpath=<template>" or "go-template=<template>"
+	Format string
+
+	// Progress of each console's authorisation, formatted as given/required, which is
+	// shown in the wide output. Consoles that don't require authorisation can be omitted.
+	Authorisations map[types.NamespacedName]string
+}
+
+// ValidateOutputFormat returns an error if we don't know how to print the given format
+func ValidateOutputFormat(format string) error {
+	switch {
+	case format == OutputFormatTable, format == OutputFormatWide:
+		return nil
+	default:
+		_, err := newObjectPrinter(format)
+		return err
+	}
+}
+
+// newObjectPrinter returns a printer for the structured output formats, which print the
+// console objects themselves rather than a table.
+func newObjectPrinter(format string) (printers.ResourcePrinter, error) {
+	switch {
+	case format == OutputFormatJSON:
+		return &printers.JSONPrinter{}, nil
+	case format == OutputFormatYAML:
+		return &printers.YAMLPrinter{}, nil
+	case strings.HasPrefix(format, OutputFormatJSONPath):
+		printer, err := printers.NewJSONPathPrinter(strings.TrimPrefix(format, OutputFormatJSONPath))
+		if err != nil {
+			return nil, fmt.Errorf("invalid jsonpath template: %w", err)
+		}
+
+		printer.AllowMissingKeys(true)
+		return printer, nil
+	case strings.HasPrefix(format, OutputFormatGoTemplate):
+		printer, err := printers.NewGoTemplatePrinter([]byte(strings.TrimPrefix(format, OutputFormatGoTemplate)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid go-template: %w", err)
+		}
+
+		printer.AllowMissingKeys(true)
+		return printer, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, must be one of: json, yaml, wide, jsonpath=..., go-template=...", format)
+	}
+}
+
+// PrintConsole prints a single console. Structured formats print the console object
+// itself, while tables print a single row.
+func PrintConsole(output io.Writer, csl *workloadsv1alpha1.Console, opts PrintOptions) error {
+	switch opts.Format {
+	case OutputFormatTable, OutputFormatWide:
+		return ConsoleSlice{*csl}.PrintWithOptions(output, opts)
+	}
+
+	printer, err := newObjectPrinter(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	return printer.PrintObj(withConsoleGVK(*csl), output)
+}
+
+// Print writes the consoles to output as a table
+func (cs ConsoleSlice) Print(output io.Writer) error {
+	return cs.PrintWithOptions(output, PrintOptions{})
+}
+
+// PrintWithOptions writes the consoles to output in the requested format. Structured
+// formats print a ConsoleList, as kubectl would.
+func (cs ConsoleSlice) PrintWithOptions(output io.Writer, opts PrintOptions) error {
+	switch opts.Format {
+	case OutputFormatTable:
+		return cs.printTable(output)
+	case OutputFormatWide:
+		return cs.printWideTable(output, opts.Authorisations)
+	}
+
+	printer, err := newObjectPrinter(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	list := &workloadsv1alpha1.ConsoleList{}
+	list.SetGroupVersionKind(workloadsv1alpha1.GroupVersion.WithKind("ConsoleList"))
+	for _, csl := range cs {
+		list.Items = append(list.Items, *withConsoleGVK(csl))
+	}
+
+	return printer.PrintObj(list, output)
+}
+
+func (cs ConsoleSlice) printTable(output io.Writer) error {
+	w := tabwriter.NewWriter(output, 0, 8, 2, ' ', 0)
+
+	if len(cs) == 0 {
+		return nil
+	}
+
+	decoder := scheme.Codecs.UniversalDecoder(scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	printer, err := get.NewCustomColumnsPrinterFromSpec(
+		consoleColumns,
+		decoder,
+		false, // false => print headers
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, cnsl := range cs {
+		printer.PrintObj(&cnsl, w)
+	}
+
+	// Flush the printed buffer to output
+	w.Flush()
+
+	return nil
+}
+
+// printWideTable extends the default columns with details that need more than a simple
+// field lookup, such as the authorisation progress.
+func (cs ConsoleSlice) printWideTable(output io.Writer, authorisations map[types.NamespacedName]string) error {
+	w := tabwriter.NewWriter(output, 0, 8, 2, ' ', 0)
+
+	if len(cs) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "NAME\tNAMESPACE\tPHASE\tCREATED\tUSER\tREASON\tEXPIRY\tTEMPLATE\tCOMMAND\tAUTHORISATIONS")
+	for _, csl := range cs {
+		// Match the custom columns printer, which marks missing fields as <none>
+		expiry := "<none>"
+		if csl.Status.ExpiryTime != nil {
+			expiry = csl.Status.ExpiryTime.String()
+		}
+
+		command := "<template default>"
+		if len(csl.Spec.Command) > 0 {
+			command = strings.Join(csl.Spec.Command, " ")
+		}
+
+		authorisation, ok := authorisations[types.NamespacedName{Namespace: csl.Namespace, Name: csl.Name}]
+		if !ok {
+			authorisation = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			csl.Name,
+			csl.Namespace,
+			csl.Status.Phase,
+			csl.CreationTimestamp.String(),
+			csl.Spec.User,
+			csl.Spec.Reason,
+			expiry,
+			csl.Spec.ConsoleTemplateRef.Name,
+			command,
+			authorisation,
+		)
+	}
+
+	// Flush the printed buffer to output
+	return w.Flush()
+}
+
+// withConsoleGVK returns a copy of the console with its type information set. Objects
+// decoded by the client lose this, but the structured printers require it.
+func withConsoleGVK(csl workloadsv1alpha1.Console) *workloadsv1alpha1.Console {
+	obj := csl.DeepCopy()
+	obj.SetGroupVersionKind(workloadsv1alpha1.GroupVersion.WithKind("Console"))
+
+	return obj
+}
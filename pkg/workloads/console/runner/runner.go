@@ -7,11 +7,12 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"gomodules.xyz/jsonpatch/v3"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -20,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -27,7 +29,6 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
-	"k8s.io/kubectl/pkg/cmd/get"
 	"k8s.io/kubectl/pkg/util/term"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -54,6 +55,8 @@ type Options struct {
 	// should be set to false but some execution environments, eg
 	// Tekton, do not like attaching to TTY-enabled pods.
 	Noninteractive bool
+	// A script to deliver into the console, which is passed to Cmd
+	Script string
 }
 
 // New builds a runner
@@ -95,6 +98,7 @@ type LifecycleHook interface {
 	ConsoleRequiresAuthorisation(*workloadsv1alpha1.Console, *workloadsv1alpha1.ConsoleAuthorisationRule) error
 	ConsoleReady(*workloadsv1alpha1.Console) error
 	TemplateFound(*workloadsv1alpha1.ConsoleTemplate) error
+	ReconnectingToConsole(*workloadsv1alpha1.Console, int, error) error
 }
 
 var _ LifecycleHook = DefaultLifecycleHook{}
@@ -105,6 +109,7 @@ type DefaultLifecycleHook struct {
 	ConsoleRequiresAuthorisationFunc func(*workloadsv1alpha1.Console, *workloadsv1alpha1.ConsoleAuthorisationRule) error
 	ConsoleReadyFunc                 func(*workloadsv1alpha1.Console) error
 	TemplateFoundFunc                func(*workloadsv1alpha1.ConsoleTemplate) error
+	ReconnectingToConsoleFunc        func(*workloadsv1alpha1.Console, int, error) error
 }
 
 func (d DefaultLifecycleHook) AttachingToConsole(c *workloadsv1alpha1.Console) error {
@@ -142,6 +147,13 @@ func (d DefaultLifecycleHook) TemplateFound(c *workloadsv1alpha1.ConsoleTemplate
 	return nil
 }
 
+func (d DefaultLifecycleHook) ReconnectingToConsole(c *workloadsv1alpha1.Console, attempt int, err error) error {
+	if d.ReconnectingToConsoleFunc != nil {
+		return d.ReconnectingToConsoleFunc(c, attempt, err)
+	}
+	return nil
+}
+
 // CreateOptions encapsulates the arguments to create a console
 type CreateOptions struct {
 	Namespace      string
@@ -152,9 +164,18 @@ type CreateOptions struct {
 	Attach         bool
 	Noninteractive bool
 
+	// A template to create the console from, such as one chosen with PickTemplate,
+	// in which case Selector is ignored
+	Template *workloadsv1alpha1.ConsoleTemplate
+
+	// A script to run in the console, using Command as the interpreter. Consoles
+	// that run scripts are always noninteractive.
+	Script string
+
 	// Options only used when Attach is true
-	KubeConfig *rest.Config
-	IO         IOStreams
+	KubeConfig        *rest.Config
+	IO                IOStreams
+	ReconnectAttempts int
 
 	// Lifecycle hook to notify when the state of the console changes
 	Hook LifecycleHook
@@ -175,9 +196,12 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 	opts = opts.WithDefaults()
 
 	// Create and attach to the console
-	tpl, err := c.FindTemplateBySelector(opts.Namespace, opts.Selector)
-	if err != nil {
-		return nil, err
+	var err error
+	tpl := opts.Template
+	if tpl == nil {
+		if tpl, err = c.FindTemplateBySelector(opts.Namespace, opts.Selector); err != nil {
+			return nil, err
+		}
 	}
 
 	err = opts.Hook.TemplateFound(tpl)
@@ -185,7 +209,13 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 		return nil, err
 	}
 
-	opt := Options{Cmd: opts.Command, Timeout: int(opts.Timeout.Seconds()), Reason: opts.Reason, Noninteractive: opts.Noninteractive}
+	opt := Options{
+		Cmd:            opts.Command,
+		Timeout:        int(opts.Timeout.Seconds()),
+		Reason:         opts.Reason,
+		Noninteractive: opts.Noninteractive || opts.Script != "",
+		Script:         opts.Script,
+	}
 	csl, err := c.CreateResource(tpl.Namespace, *tpl, opt)
 	if err != nil {
 		return nil, err
@@ -198,8 +228,13 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 
 	// Wait for authorisation step or until ready
 	_, err = c.WaitUntilReady(ctx, *csl, false)
-	if err == consolePendingAuthorisationError {
-		rule, err := tpl.GetAuthorisationRuleForCommand(opts.Command)
+	if errors.Is(err, ErrConsolePendingAuthorisation) {
+		command, err := csl.GetCommand(tpl)
+		if err != nil {
+			return csl, err
+		}
+
+		rule, err := tpl.GetAuthorisationRuleForCommand(command)
 		if err != nil {
 			return csl, fmt.Errorf("failed to get authorisation rule %w", err)
 		}
@@ -223,11 +258,12 @@ func (c *Runner) Create(ctx context.Context, opts CreateOptions) (*workloadsv1al
 		return csl, c.Attach(
 			ctx,
 			AttachOptions{
-				Namespace:  csl.GetNamespace(),
-				KubeConfig: opts.KubeConfig,
-				Name:       csl.GetName(),
-				IO:         opts.IO,
-				Hook:       opts.Hook,
+				Namespace:         csl.GetNamespace(),
+				KubeConfig:        opts.KubeConfig,
+				Name:              csl.GetName(),
+				IO:                opts.IO,
+				Hook:              opts.Hook,
+				ReconnectAttempts: opts.ReconnectAttempts,
 			},
 		)
 	}
@@ -294,7 +330,7 @@ func (c *Runner) waitForSuccess(ctx context.Context, csl *workloadsv1alpha1.Cons
 			// We can receive *metav1.Status events in the situation where there's an error, in
 			// which case we should exit early.
 			if status, ok := event.Object.(*metav1.Status); ok {
-				return fmt.Errorf("received failure from Kubernetes: %w", status.Reason)
+				return fmt.Errorf("received failure from Kubernetes: %s", status.Reason)
 			}
 
 			// We should be safe now, as a watcher should return either Status or the type we
@@ -323,8 +359,13 @@ type GetOptions struct {
 	ConsoleName string
 }
 
-// Get provides a standardised method to get a console
+// Get provides a standardised method to get a console. If no namespace is given, we
+// search all namespaces for a console with this name.
 func (c *Runner) Get(ctx context.Context, opts GetOptions) (*workloadsv1alpha1.Console, error) {
+	if opts.Namespace == "" {
+		return c.FindConsoleByName(opts.Namespace, opts.ConsoleName)
+	}
+
 	var csl workloadsv1alpha1.Console
 	err := c.kubeClient.Get(
 		ctx,
@@ -349,6 +390,18 @@ type AttachOptions struct {
 
 	IO IOStreams
 
+	// Number of lines of recent output to replay before attaching, giving context on
+	// what's already happened in the console. Zero disables this.
+	Scrollback int64
+
+	// Number of times to reconnect if the connection to the console drops while it is
+	// still running, such as when a laptop sleeps. Zero disables reconnection.
+	ReconnectAttempts int
+
+	// Follow the output of the console without attaching to it, for participants that
+	// the console has been shared with in read-only mode. No input is sent.
+	ReadOnly bool
+
 	// Lifecycle hook to notify when the state of the console changes
 	Hook LifecycleHook
 }
@@ -362,6 +415,16 @@ func (opts AttachOptions) WithDefaults() AttachOptions {
 	return opts
 }
 
+const (
+	// Bounds on the backoff between attempts to reconnect to a console
+	reconnectInitialBackoff = time.Second
+	reconnectMaxBackoff     = 30 * time.Second
+
+	// The most output we'll replay after reconnecting, in case we were disconnected for
+	// long enough to miss a lot of it.
+	reconnectMaxReplayLines int64 = 1000
+)
+
 // Attach provides the ability to attach to a running console, given the console name
 func (c *Runner) Attach(ctx context.Context, opts AttachOptions) error {
 	// Get options with any unset values defaulted
@@ -382,15 +445,51 @@ func (c *Runner) Attach(ctx context.Context, opts AttachOptions) error {
 		return err
 	}
 
+	if opts.ReadOnly {
+		logOpts := &corev1.PodLogOptions{Follow: true}
+		if opts.Scrollback > 0 {
+			logOpts.TailLines = &opts.Scrollback
+		}
+
+		if err := c.replayLogs(ctx, pod, containerName, opts.IO, logOpts); err != nil {
+			return fmt.Errorf("failed to follow console output: %w", err)
+		}
+
+		return nil
+	}
+
+	// Being attached to the console counts as activity in it, as does any input that
+	// we send, so that the console isn't stopped for being idle while we're using it
+	activity := newActivityReporter(ctx, c, csl)
+
 	var attacher Attacher
 	if !csl.Spec.Noninteractive {
-		attacher = newInteractiveAttacher(c.clientset, opts.KubeConfig)
+		attacher = newInteractiveAttacher(c.clientset, opts.KubeConfig, activity)
 	} else {
 		attacher = newNoninteractiveAttacher(c.clientset, opts.KubeConfig)
 	}
 
-	err = attacher.Attach(ctx, pod, containerName, opts.IO)
-	if err != nil {
+	if opts.Scrollback > 0 {
+		tailLines := opts.Scrollback
+		if err := c.replayLogs(ctx, pod, containerName, opts.IO, &corev1.PodLogOptions{TailLines: &tailLines}); err != nil {
+			return fmt.Errorf("failed to replay console output: %w", err)
+		}
+	}
+
+	backoff := wait.Backoff{
+		Duration: reconnectInitialBackoff,
+		Factor:   2.0,
+		Jitter:   0.1,
+		Steps:    opts.ReconnectAttempts,
+		Cap:      reconnectMaxBackoff,
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = activity.Attach(ctx, attacher, pod, containerName, opts.IO)
+		if err == nil {
+			break
+		}
+
 		// If this is true, it is likely that the pod has already terminated for whatever
 		// reason - very often because a command has run so quickly that by the time waitForConsole
 		// is done the script has run to completion. We don't necessarily want to error out
@@ -399,16 +498,68 @@ func (c *Runner) Attach(ctx context.Context, opts AttachOptions) error {
 			return c.extractLogs(ctx, csl, pod, containerName, opts.IO)
 		}
 
-		return fmt.Errorf("failed to attach to console: %w", err)
+		if attempt > opts.ReconnectAttempts {
+			return fmt.Errorf("failed to attach to console: %w", err)
+		}
+
+		disconnectedAt := metav1.Now()
+
+		// We only reconnect while the console is running. If it finished while we were
+		// disconnected, show what we missed and report how it exited.
+		current, getErr := c.Get(ctx, GetOptions{Namespace: csl.Namespace, ConsoleName: csl.Name})
+		if getErr != nil {
+			return fmt.Errorf("failed to attach to console: %w", err)
+		}
+
+		if !current.Running() {
+			if err := c.replayLogs(ctx, pod, containerName, opts.IO, &corev1.PodLogOptions{SinceTime: &disconnectedAt}); err != nil {
+				return fmt.Errorf("failed to replay console output: %w", err)
+			}
+
+			return c.waitForSuccess(ctx, current)
+		}
+
+		if hookErr := opts.Hook.ReconnectingToConsole(current, attempt, err); hookErr != nil {
+			return hookErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to attach to console: %w", ctx.Err())
+		case <-time.After(backoff.Step()):
+		}
+
+		pod, containerName, err = c.GetAttachablePod(ctx, current)
+		if err != nil {
+			return fmt.Errorf("could not find pod to attach to: %w", err)
+		}
+
+		// Replay whatever the console printed while we were away, so nothing is lost
+		tailLines := reconnectMaxReplayLines
+		err = c.replayLogs(ctx, pod, containerName, opts.IO, &corev1.PodLogOptions{SinceTime: &disconnectedAt, TailLines: &tailLines})
+		if err != nil {
+			return fmt.Errorf("failed to replay console output: %w", err)
+		}
 	}
 
 	return c.waitForSuccess(ctx, csl)
 }
 
 func (c *Runner) extractLogs(ctx context.Context, csl *workloadsv1alpha1.Console, pod *corev1.Pod, containerName string, streams IOStreams) error {
+	if err := c.replayLogs(ctx, pod, containerName, streams, &corev1.PodLogOptions{}); err != nil {
+		return err
+	}
+
+	// Propagate the exit status of the pod as though we had actually attached.
+	return c.waitForSuccess(ctx, csl)
+}
+
+// replayLogs copies the container's logs, as selected by the log options, to our output
+func (c *Runner) replayLogs(ctx context.Context, pod *corev1.Pod, containerName string, streams IOStreams, logOpts *corev1.PodLogOptions) error {
 	pods := c.clientset.CoreV1().Pods(pod.Namespace)
 
-	logs, err := pods.GetLogs(pod.Name, &corev1.PodLogOptions{Container: containerName}).Stream(ctx)
+	logOpts.Container = containerName
+	logs, err := pods.GetLogs(pod.Name, logOpts).Stream(ctx)
 	if err != nil {
 		return err
 	}
@@ -416,16 +567,57 @@ func (c *Runner) extractLogs(ctx context.Context, csl *workloadsv1alpha1.Console
 	defer logs.Close()
 
 	_, err = io.Copy(streams.Out, logs)
+	return err
+}
+
+// FindLatestAttachableConsole returns the most recently created running console that we
+// have permission to attach to, optionally restricted to consoles created by the given
+// user. Users with broad permissions, such as cluster admins, can attach to every console
+// so should supply a username.
+func (c *Runner) FindLatestAttachableConsole(ctx context.Context, namespace, username string) (*workloadsv1alpha1.Console, error) {
+	consoles, err := c.ListConsolesByLabelsAndUser(ctx, namespace, username, "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Propagate the exit status of the pod as though we had actually attached.
-	return c.waitForSuccess(ctx, csl)
+	sort.SliceStable(consoles, func(i, j int) bool {
+		return consoles[j].CreationTimestamp.Before(&consoles[i].CreationTimestamp)
+	})
+
+	for idx, csl := range consoles {
+		if !csl.Running() || csl.Status.PodName == "" {
+			continue
+		}
+
+		review, err := c.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(
+			ctx,
+			&authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace:   csl.Namespace,
+						Verb:        "create",
+						Resource:    "pods",
+						Subresource: "attach",
+						Name:        csl.Status.PodName,
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check permission to attach to console: %w", err)
+		}
+
+		if review.Status.Allowed {
+			return &consoles[idx], nil
+		}
+	}
+
+	return nil, errors.New("no running consoles found that you can attach to")
 }
 
-func newInteractiveAttacher(clientset kubernetes.Interface, restconfig *rest.Config) Attacher {
-	return &interactiveAttacher{clientset, restconfig}
+func newInteractiveAttacher(clientset kubernetes.Interface, restconfig *rest.Config, activity *activityReporter) Attacher {
+	return &interactiveAttacher{clientset, restconfig, activity}
 }
 
 type Attacher interface {
@@ -437,6 +629,8 @@ type Attacher interface {
 type interactiveAttacher struct {
 	clientset  kubernetes.Interface
 	restconfig *rest.Config
+	// Reports activity whenever we send input to the console
+	activity *activityReporter
 }
 
 // Attach will interactively attach to a container's output, creating a new TTY
@@ -466,6 +660,12 @@ func (a *interactiveAttacher) Attach(ctx context.Context, pod *corev1.Pod, conta
 
 	streamOptions, safe := CreateInteractiveStreamOptions(streams)
 
+	// The terminal needs the original input to put it in raw mode, so we only wrap
+	// the input that we stream to the console
+	if a.activity != nil && streamOptions.Stdin != nil {
+		streamOptions.Stdin = &activityReader{Reader: streamOptions.Stdin, reporter: a.activity}
+	}
+
 	return safe(func() error { return remoteExecutor.Stream(streamOptions) })
 }
 
@@ -576,6 +776,9 @@ func (c *Runner) Authorise(ctx context.Context, opts AuthoriseOptions) error {
 		},
 		&authz,
 	)
+	if err != nil {
+		return err
+	}
 
 	err = c.kubeClient.Patch(ctx, &authz, client.ConstantPatch(types.JSONPatchType, patchBytes))
 	if err != nil {
@@ -585,22 +788,165 @@ func (c *Runner) Authorise(ctx context.Context, opts AuthoriseOptions) error {
 	return nil
 }
 
+// ShareOptions encapsulates the arguments to share a console with other users
+type ShareOptions struct {
+	Namespace   string
+	ConsoleName string
+	Users       []string
+
+	// Share the console in read-only mode, where the users can follow the output of
+	// the console but can't send it any input
+	ReadOnly bool
+}
+
+// Share invites users to join a running console, for example to pair during an
+// incident. Invitations are recorded in the console's share object, which only the
+// owner of the console is permitted to update, and the console controller grants the
+// invited users access. Sharing with an existing participant updates whether they are
+// read-only.
+func (c *Runner) Share(ctx context.Context, opts ShareOptions) (*workloadsv1alpha1.Console, error) {
+	csl, err := c.Get(ctx, GetOptions{Namespace: opts.Namespace, ConsoleName: opts.ConsoleName})
+	if err != nil {
+		return nil, err
+	}
+
+	if !csl.Running() {
+		return nil, fmt.Errorf("console %s is not running, so can't be shared", csl.Name)
+	}
+
+	// The share object has the same name as the console
+	share := &workloadsv1alpha1.ConsoleShare{}
+	err = c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Name}, share)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get console share: %w", err)
+	}
+
+	for _, user := range opts.Users {
+		if user == csl.Spec.User {
+			return nil, fmt.Errorf("%s owns console %s, so can't be invited to it", user, csl.Name)
+		}
+
+		participant := workloadsv1alpha1.ConsoleParticipant{Name: user, ReadOnly: opts.ReadOnly}
+
+		found := false
+		for idx, existing := range share.Spec.Participants {
+			if existing.Name == user {
+				share.Spec.Participants[idx] = participant
+				found = true
+			}
+		}
+
+		if !found {
+			share.Spec.Participants = append(share.Spec.Participants, participant)
+		}
+	}
+
+	if err := c.kubeClient.Update(ctx, share); err != nil {
+		return nil, fmt.Errorf("failed to share console: %w", err)
+	}
+
+	return csl, nil
+}
+
 type ListOptions struct {
 	Namespace string
 	Username  string
 	Selector  string
+	Filter    ConsoleFilter
 	Output    io.Writer
+
+	// Format of the output, as accepted by PrintOptions. Defaults to a table.
+	OutputFormat string
 }
 
 // List is a wrapper around ListConsolesByLabelsAndUser that will output to a specified output.
 // This functionality is intended to be used in a CLI setting, where you are usually outputting to os.Stdout.
 func (c *Runner) List(ctx context.Context, opts ListOptions) (ConsoleSlice, error) {
-	consoles, err := c.ListConsolesByLabelsAndUser(opts.Namespace, opts.Username, opts.Selector)
+	if err := ValidateOutputFormat(opts.OutputFormat); err != nil {
+		return nil, err
+	}
+
+	listed, err := c.ListConsolesByLabelsAndUser(ctx, opts.Namespace, opts.Username, opts.Selector)
 	if err != nil {
 		return nil, err
 	}
 
-	return consoles, consoles.Print(opts.Output)
+	var consoles ConsoleSlice
+	for idx := range listed {
+		match, err := c.Matches(ctx, opts.Filter, &listed[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			consoles = append(consoles, listed[idx])
+		}
+	}
+
+	printOpts := PrintOptions{Format: opts.OutputFormat}
+	if opts.OutputFormat == OutputFormatWide {
+		if printOpts.Authorisations, err = c.AuthorisationProgress(ctx, opts.Namespace, consoles); err != nil {
+			return nil, err
+		}
+	}
+
+	return consoles, consoles.PrintWithOptions(opts.Output, printOpts)
+}
+
+// AuthorisationProgress returns how many authorisations each console has been given,
+// against how many its template requires for the console's command, formatted as
+// given/required. Consoles that don't require authorisation are omitted.
+func (c *Runner) AuthorisationProgress(ctx context.Context, namespace string, consoles ConsoleSlice) (map[types.NamespacedName]string, error) {
+	progress := map[types.NamespacedName]string{}
+	if len(consoles) == 0 {
+		return progress, nil
+	}
+
+	// List rather than get each resource, as we're likely to be printing many consoles
+	var templates workloadsv1alpha1.ConsoleTemplateList
+	if err := c.kubeClient.List(ctx, &templates, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, err
+	}
+
+	var authorisations workloadsv1alpha1.ConsoleAuthorisationList
+	if err := c.kubeClient.List(ctx, &authorisations, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, err
+	}
+
+	templatesByName := map[types.NamespacedName]workloadsv1alpha1.ConsoleTemplate{}
+	for _, tpl := range templates.Items {
+		templatesByName[types.NamespacedName{Namespace: tpl.Namespace, Name: tpl.Name}] = tpl
+	}
+
+	authorisationsByName := map[types.NamespacedName]workloadsv1alpha1.ConsoleAuthorisation{}
+	for _, authz := range authorisations.Items {
+		authorisationsByName[types.NamespacedName{Namespace: authz.Namespace, Name: authz.Name}] = authz
+	}
+
+	for _, csl := range consoles {
+		name := types.NamespacedName{Namespace: csl.Namespace, Name: csl.Name}
+
+		tpl, ok := templatesByName[types.NamespacedName{Namespace: csl.Namespace, Name: csl.Spec.ConsoleTemplateRef.Name}]
+		if !ok || !tpl.HasAuthorisationRules() {
+			continue
+		}
+
+		command, err := csl.GetCommand(&tpl)
+		if err != nil {
+			continue
+		}
+
+		rule, err := tpl.GetAuthorisationRuleForCommand(command)
+		if err != nil || rule.AuthorisationsRequired == 0 {
+			continue
+		}
+
+		// The authorisation object has the same name as the console
+		given := len(authorisationsByName[name].Spec.Authorisations)
+		progress[name] = fmt.Sprintf("%d/%d", given, rule.AuthorisationsRequired)
+	}
+
+	return progress, nil
 }
 
 // CreateResource builds a console according to the supplied options and submits it to the API
@@ -620,6 +966,7 @@ func (c *Runner) CreateResource(namespace string, template workloadsv1alpha1.Con
 			Command:        opts.Cmd,
 			Reason:         opts.Reason,
 			Noninteractive: opts.Noninteractive,
+			Script:         opts.Script,
 		},
 	}
 
@@ -694,7 +1041,7 @@ func (c *Runner) FindConsoleByName(namespace, name string) (*workloadsv1alpha1.C
 	}
 
 	if len(matchingConsoles) == 0 {
-		return nil, fmt.Errorf("no consoles found with name: %s", name)
+		return nil, fmt.Errorf("%w with name: %s", ErrConsoleNotFound, name)
 	}
 	if len(matchingConsoles) > 1 {
 		return nil, fmt.Errorf("too many consoles found with name: %s, please specify namespace", name)
@@ -705,47 +1052,19 @@ func (c *Runner) FindConsoleByName(namespace, name string) (*workloadsv1alpha1.C
 
 type ConsoleSlice []workloadsv1alpha1.Console
 
-func (cs ConsoleSlice) Print(output io.Writer) error {
-	w := tabwriter.NewWriter(output, 0, 8, 2, ' ', 0)
-
-	if len(cs) == 0 {
-		return nil
-	}
-
-	decoder := scheme.Codecs.UniversalDecoder(scheme.Scheme.PrioritizedVersionsAllGroups()...)
-
-	printer, err := get.NewCustomColumnsPrinterFromSpec(
-		"NAME:.metadata.name,NAMESPACE:.metadata.namespace,PHASE:.status.phase,CREATED:.metadata.creationTimestamp,USER:.spec.user,REASON:.spec.reason",
-		decoder,
-		false, // false => print headers
-	)
-	if err != nil {
-		return err
-	}
-
-	for _, cnsl := range cs {
-		printer.PrintObj(&cnsl, w)
-	}
-
-	// Flush the printed buffer to output
-	w.Flush()
-
-	return nil
-}
-
-func (c *Runner) ListConsolesByLabelsAndUser(namespace, username, labelSelector string) (ConsoleSlice, error) {
+func (c *Runner) ListConsolesByLabelsAndUser(ctx context.Context, namespace, username, labelSelector string) (ConsoleSlice, error) {
 	// We cannot use a FieldSelector on spec.user in conjunction with the
 	// LabelSelector for CRD types like Console. The error message "field label
 	// not supported: spec.user" is returned by the real Kubernetes client.
 	// See https://github.com/kubernetes/kubernetes/issues/53459.
 	var csls workloadsv1alpha1.ConsoleList
-	selectorSet, err := labels.ConvertSelectorToLabelsMap(labelSelector)
+	selector, err := labels.Parse(labelSelector)
 	if err != nil {
 		return nil, fmt.Errorf("invalid selector: %w", err)
 	}
 
-	opts := &client.ListOptions{Namespace: namespace, LabelSelector: labels.SelectorFromSet(selectorSet)}
-	err = c.kubeClient.List(context.TODO(), &csls, opts)
+	opts := &client.ListOptions{Namespace: namespace, LabelSelector: selector}
+	err = c.kubeClient.List(ctx, &csls, opts)
 
 	var filtered []workloadsv1alpha1.Console
 	for _, csl := range csls.Items {
@@ -775,10 +1094,35 @@ func (c *Runner) WaitUntilReady(ctx context.Context, createdCsl workloadsv1alpha
 }
 
 var (
-	consolePendingAuthorisationError = errors.New("console pending authorisation")
-	consoleNotFoundError             = errors.New("console not found")
+	// ErrConsolePendingAuthorisation is returned by WaitUntilReady when the console
+	// is awaiting authorisation, and we weren't asked to wait for it
+	ErrConsolePendingAuthorisation = errors.New("console pending authorisation")
+	// ErrConsoleNotFound is returned when searching for a console by name finds
+	// nothing, or by WaitUntilReady if the console never appeared
+	ErrConsoleNotFound = errors.New("console not found")
+	// ErrConsoleFailed is returned by WaitUntilReady when the console's pod failed
+	// to start, wrapped in a ConsoleFailedError with the reason it failed
+	ErrConsoleFailed = errors.New("console failed to start")
 )
 
+// ConsoleFailedError is returned by WaitUntilReady when the console's pod failed to
+// start, and unwraps to ErrConsoleFailed.
+type ConsoleFailedError struct {
+	Reason string
+}
+
+func (e ConsoleFailedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrConsoleFailed, e.Reason)
+}
+
+func (e ConsoleFailedError) Unwrap() error {
+	return ErrConsoleFailed
+}
+
+// podFailureTimeout bounds how long we spend explaining why a pod failed to start,
+// which we often do after we've run out of time waiting for it
+const podFailureTimeout = 5 * time.Second
+
 func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha1.Console, waitForAuthorisation bool) (*workloadsv1alpha1.Console, error) {
 	isRunning := func(csl *workloadsv1alpha1.Console) bool {
 		return csl != nil && csl.Status.Phase == workloadsv1alpha1.ConsoleRunning
@@ -791,6 +1135,9 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 	isStopped := func(csl *workloadsv1alpha1.Console) bool {
 		return csl != nil && csl.Status.Phase == workloadsv1alpha1.ConsoleStopped
 	}
+	isFailed := func(csl *workloadsv1alpha1.Console) bool {
+		return csl != nil && csl.Status.Phase == workloadsv1alpha1.ConsoleFailed
+	}
 
 	listOptions := metav1.SingleObject(createdCsl.ObjectMeta)
 	w, err := c.consoleClient.Namespace(createdCsl.Namespace).Watch(ctx, listOptions)
@@ -813,13 +1160,16 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 		return csl, nil
 	}
 	if isPendingAuthorisation(csl) {
-		return csl, consolePendingAuthorisationError
+		return csl, ErrConsolePendingAuthorisation
 	}
 	// If the console has already stopped it may have already run to
 	// completion, so let's return it
 	if isStopped(csl) {
 		return csl, nil
 	}
+	if isFailed(csl) {
+		return nil, ConsoleFailedError{Reason: c.explainPodFailure(csl)}
+	}
 
 	status := w.ResultChan()
 	defer w.Stop()
@@ -840,22 +1190,79 @@ func (c *Runner) waitForConsole(ctx context.Context, createdCsl workloadsv1alpha
 				return csl, nil
 			}
 			if isPendingAuthorisation(csl) {
-				return csl, consolePendingAuthorisationError
+				return csl, ErrConsolePendingAuthorisation
 			}
 			// If the console has already stopped it may have already run to
 			// completion, so let's return it
 			if isStopped(csl) {
 				return csl, nil
 			}
+			if isFailed(csl) {
+				return nil, ConsoleFailedError{Reason: c.explainPodFailure(csl)}
+			}
 		case <-ctx.Done():
 			if csl == nil {
-				return nil, fmt.Errorf("%s: %w", consoleNotFoundError, ctx.Err())
+				return nil, fmt.Errorf("%s: %w", ErrConsoleNotFound, ctx.Err())
+			}
+			// A console stuck in Pending is usually waiting on a pod that can't
+			// start, which is more useful to know than that we gave up
+			if csl.Status.Phase == workloadsv1alpha1.ConsolePending && csl.Status.PodFailure != nil {
+				return nil, fmt.Errorf("console's last phase was: %v, as its pod is failing to start: %s: %w", csl.Status.Phase, c.explainPodFailure(csl), ctx.Err())
 			}
 			return nil, fmt.Errorf("console's last phase was: %v: %w", csl.Status.Phase, ctx.Err())
 		}
 	}
 }
 
+// explainPodFailure describes why the console's pod failed to start. We prefer the
+// most recent warning event for the pod, such as the scheduler explaining why no node
+// fits it, and fall back to the failure recorded in the console's status when the
+// events have expired or we can't see them.
+func (c *Runner) explainPodFailure(csl *workloadsv1alpha1.Console) string {
+	explanation := "unknown reason"
+	if failure := csl.Status.PodFailure; failure != nil {
+		explanation = failure.Reason
+		if failure.Message != "" {
+			explanation = fmt.Sprintf("%s: %s", failure.Reason, failure.Message)
+		}
+	}
+
+	if csl.Status.PodName == "" {
+		return explanation
+	}
+
+	// We're often called once the caller's context is done, so use our own
+	ctx, cancel := context.WithTimeout(context.Background(), podFailureTimeout)
+	defer cancel()
+
+	var events corev1.EventList
+	err := c.kubeClient.List(
+		ctx, &events,
+		client.InNamespace(csl.Namespace),
+		client.MatchingFields{"involvedObject.kind": "Pod", "involvedObject.name": csl.Status.PodName},
+	)
+	if err != nil {
+		return explanation
+	}
+
+	var latest *corev1.Event
+	for idx := range events.Items {
+		event := &events.Items[idx]
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		if latest == nil || latest.LastTimestamp.Before(&event.LastTimestamp) {
+			latest = event
+		}
+	}
+
+	if latest == nil {
+		return explanation
+	}
+
+	return fmt.Sprintf("%s: %s", latest.Reason, latest.Message)
+}
+
 func (c *Runner) waitForRoleBinding(ctx context.Context, csl *workloadsv1alpha1.Console) error {
 	if csl.Status.Phase == workloadsv1alpha1.ConsoleStopped {
 		return nil
@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConsoleFailedError", func() {
+	var err error = fmt.Errorf("waiting for console: %w", ConsoleFailedError{Reason: "image not found"})
+
+	It("Is an ErrConsoleFailed", func() {
+		Expect(errors.Is(err, ErrConsoleFailed)).To(BeTrue())
+		Expect(err.Error()).To(Equal("waiting for console: console failed to start: image not found"))
+	})
+
+	It("Carries the reason the console failed", func() {
+		var failedErr ConsoleFailedError
+		Expect(errors.As(err, &failedErr)).To(BeTrue())
+		Expect(failedErr.Reason).To(Equal("image not found"))
+	})
+})
@@ -0,0 +1,201 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// TemplateSlice is a list of console templates, which can be printed
+type TemplateSlice []workloadsv1alpha1.ConsoleTemplate
+
+// ListTemplatesOptions encapsulates the arguments to list console templates
+type ListTemplatesOptions struct {
+	Namespace string
+	Selector  string
+	Output    io.Writer
+
+	// Format of the output, as accepted by PrintOptions. Defaults to a table.
+	OutputFormat string
+}
+
+// ListTemplates prints the console templates visible to the user, so that they can
+// discover which selector to create a console with.
+func (c *Runner) ListTemplates(ctx context.Context, opts ListTemplatesOptions) (TemplateSlice, error) {
+	if err := ValidateOutputFormat(opts.OutputFormat); err != nil {
+		return nil, err
+	}
+
+	templates, err := c.ListTemplatesBySelector(ctx, opts.Namespace, opts.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, templates.PrintWithOptions(opts.Output, PrintOptions{Format: opts.OutputFormat})
+}
+
+// ListTemplatesBySelector returns the console templates matching the selector, in the
+// same form accepted by FindTemplateBySelector, sorted by namespace and name.
+func (c *Runner) ListTemplatesBySelector(ctx context.Context, namespace, labelSelector string) (TemplateSlice, error) {
+	selectorSet, err := labels.ConvertSelectorToLabelsMap(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	var templates workloadsv1alpha1.ConsoleTemplateList
+	opts := &client.ListOptions{Namespace: namespace, LabelSelector: labels.SelectorFromSet(selectorSet)}
+	if err := c.kubeClient.List(ctx, &templates, opts); err != nil {
+		return nil, fmt.Errorf("failed to list consoles templates: %w", err)
+	}
+
+	result := TemplateSlice(templates.Items)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// PrintWithOptions writes the templates to output in the requested format. Templates
+// have no extra columns, so the wide format prints the same table as the default.
+func (ts TemplateSlice) PrintWithOptions(output io.Writer, opts PrintOptions) error {
+	switch opts.Format {
+	case OutputFormatTable, OutputFormatWide:
+		return ts.printTable(output)
+	}
+
+	printer, err := newObjectPrinter(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	list := &workloadsv1alpha1.ConsoleTemplateList{}
+	list.SetGroupVersionKind(workloadsv1alpha1.GroupVersion.WithKind("ConsoleTemplateList"))
+	for _, tpl := range ts {
+		obj := tpl.DeepCopy()
+		obj.SetGroupVersionKind(workloadsv1alpha1.GroupVersion.WithKind("ConsoleTemplate"))
+		list.Items = append(list.Items, *obj)
+	}
+
+	return printer.PrintObj(list, output)
+}
+
+func (ts TemplateSlice) printTable(output io.Writer) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(output, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tNAMESPACE\tLABELS\tDEFAULT COMMAND\tTIMEOUT (DEFAULT/MAX)\tAUTHORISATION")
+	for _, tpl := range ts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			tpl.Name,
+			tpl.Namespace,
+			formatLabels(tpl.Labels),
+			formatDefaultCommand(tpl),
+			formatTimeouts(tpl),
+			summariseAuthorisation(tpl),
+		)
+	}
+
+	// Flush the printed buffer to output
+	return w.Flush()
+}
+
+// PickTemplate asks the user to choose one of the templates by number, for when they
+// don't know which selector to use. It keeps asking until it gets a valid answer, or
+// input ends.
+func PickTemplate(streams IOStreams, templates TemplateSlice) (*workloadsv1alpha1.ConsoleTemplate, error) {
+	if len(templates) == 0 {
+		return nil, errors.New("no console templates found")
+	}
+
+	w := tabwriter.NewWriter(streams.ErrOut, 0, 8, 2, ' ', 0)
+	for idx, tpl := range templates {
+		fmt.Fprintf(w, "[%d]\t%s/%s\t%s\t%s\n", idx+1, tpl.Namespace, tpl.Name, formatLabels(tpl.Labels), formatDefaultCommand(tpl))
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(streams.In)
+	for {
+		fmt.Fprintf(streams.ErrOut, "Choose a console template [1-%d]: ", len(templates))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+
+			return nil, errors.New("no console template chosen")
+		}
+
+		choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil || choice < 1 || choice > len(templates) {
+			fmt.Fprintf(streams.ErrOut, "Please enter a number between 1 and %d\n", len(templates))
+			continue
+		}
+
+		return &templates[choice-1], nil
+	}
+}
+
+// formatLabels prints labels in selector form, so that they can be passed straight to
+// create --selector
+func formatLabels(set map[string]string) string {
+	if len(set) == 0 {
+		return "<none>"
+	}
+
+	return labels.Set(set).String()
+}
+
+func formatDefaultCommand(tpl workloadsv1alpha1.ConsoleTemplate) string {
+	command, err := tpl.GetDefaultCommandWithArgs()
+	if err != nil || len(command) == 0 {
+		return "<image default>"
+	}
+
+	return strings.Join(command, " ")
+}
+
+func formatTimeouts(tpl workloadsv1alpha1.ConsoleTemplate) string {
+	seconds := func(s int) string { return (time.Duration(s) * time.Second).String() }
+	return seconds(tpl.Spec.DefaultTimeoutSeconds) + "/" + seconds(tpl.Spec.MaxTimeoutSeconds)
+}
+
+// summariseAuthorisation lists how many authorisations each of the template's rules
+// requires, ending with the default rule, such as "rails-console=2,default=1"
+func summariseAuthorisation(tpl workloadsv1alpha1.ConsoleTemplate) string {
+	if !tpl.HasAuthorisationRules() {
+		return "<none>"
+	}
+
+	rules := make([]string, 0, len(tpl.Spec.AuthorisationRules)+1)
+	for _, rule := range tpl.Spec.AuthorisationRules {
+		rules = append(rules, fmt.Sprintf("%s=%d", rule.Name, rule.AuthorisationsRequired))
+	}
+
+	if rule := tpl.Spec.DefaultAuthorisationRule; rule != nil {
+		rules = append(rules, fmt.Sprintf("default=%d", rule.AuthorisationsRequired))
+	}
+
+	return strings.Join(rules, ",")
+}
@@ -0,0 +1,309 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	workloadsv1alpha1 "github.com/gocardless/theatre/v2/apis/workloads/v1alpha1"
+)
+
+// ConsoleFilter narrows down the consoles that we list or watch
+type ConsoleFilter struct {
+	// Only show consoles in one of these phases, or all phases if empty
+	Phases []workloadsv1alpha1.ConsolePhase
+
+	// Only show consoles awaiting authorisation that this user, or a member of these
+	// groups, can authorise
+	Authoriser       string
+	AuthoriserGroups []string
+}
+
+// WatchOptions encapsulates the arguments to watch consoles
+type WatchOptions struct {
+	Namespace string
+	Username  string
+	Selector  string
+	Filter    ConsoleFilter
+
+	Output io.Writer
+
+	// Format of the output, as accepted by PrintOptions. Defaults to a table, and wide is
+	// treated the same as the default.
+	OutputFormat string
+}
+
+// Watch streams the phase transitions of consoles to the output until the context is
+// cancelled. It starts by printing the current state of every matching console, much
+// like kubectl get --watch.
+func (c *Runner) Watch(ctx context.Context, opts WatchOptions) error {
+	if err := ValidateOutputFormat(opts.OutputFormat); err != nil {
+		return err
+	}
+
+	printer := &watchPrinter{
+		output: opts.Output,
+		format: opts.OutputFormat,
+		table:  tabwriter.NewWriter(opts.Output, 12, 8, 2, ' ', 0),
+	}
+
+	return c.WatchEvents(ctx, opts, func(csl *workloadsv1alpha1.Console, deleted bool, err error) {
+		if err != nil {
+			fmt.Fprintf(opts.Output, "error filtering console %s/%s: %v\n", csl.Namespace, csl.Name, err)
+			return
+		}
+
+		printer.Print(csl, deleted)
+	})
+}
+
+// WatchHandler receives consoles as they change phase. The error is set if we
+// couldn't determine whether the console matches the filter.
+type WatchHandler func(csl *workloadsv1alpha1.Console, deleted bool, err error)
+
+// WatchEvents calls the handler with each matching console as its phase changes,
+// until the context is cancelled, starting with the current state of every matching
+// console. The output options are ignored. Handlers are called sequentially.
+func (c *Runner) WatchEvents(ctx context.Context, opts WatchOptions, handler WatchHandler) error {
+	parsed, err := labels.Parse(opts.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+	selector := parsed.String()
+
+	consoles := c.consoleClient.Namespace(opts.Namespace)
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = selector
+				return consoles.List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = selector
+				return consoles.Watch(ctx, options)
+			},
+		},
+		&unstructured.Unstructured{},
+		0, // never resync, as we only care about changes
+		cache.Indexers{},
+	)
+
+	// Informers call handlers sequentially, so handlers need no further locking
+	handle := func(obj interface{}, deleted bool) {
+		csl, ok := toConsole(obj)
+		if !ok {
+			return
+		}
+
+		if opts.Username != "" && csl.Spec.User != opts.Username {
+			return
+		}
+
+		match, err := c.Matches(ctx, opts.Filter, csl)
+		if err != nil {
+			handler(csl, deleted, err)
+			return
+		}
+
+		if match {
+			handler(csl, deleted, nil)
+		}
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { handle(obj, false) },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCsl, oldOk := toConsole(oldObj)
+			newCsl, newOk := toConsole(newObj)
+
+			// We stream phase transitions, rather than every change to the console
+			if oldOk && newOk && oldCsl.Status.Phase == newCsl.Status.Phase {
+				return
+			}
+
+			handle(newObj, false)
+		},
+		DeleteFunc: func(obj interface{}) { handle(obj, true) },
+	})
+
+	informer.Run(ctx.Done())
+
+	return ctx.Err()
+}
+
+// Matches decides whether a console passes the filter
+func (c *Runner) Matches(ctx context.Context, filter ConsoleFilter, csl *workloadsv1alpha1.Console) (bool, error) {
+	if len(filter.Phases) > 0 {
+		match := false
+		for _, phase := range filter.Phases {
+			if csl.Status.Phase == phase {
+				match = true
+				break
+			}
+		}
+
+		if !match {
+			return false, nil
+		}
+	}
+
+	if filter.Authoriser == "" && len(filter.AuthoriserGroups) == 0 {
+		return true, nil
+	}
+
+	return c.CanAuthorise(ctx, csl, filter.Authoriser, filter.AuthoriserGroups)
+}
+
+// CanAuthorise returns true if the console is awaiting authorisation, and the user or
+// one of their groups is a subject of the authorisation rule that applies to the
+// console's command. Users can never authorise their own consoles, nor authorise a
+// console twice.
+func (c *Runner) CanAuthorise(ctx context.Context, csl *workloadsv1alpha1.Console, username string, groups []string) (bool, error) {
+	if !csl.PendingAuthorisation() || (username != "" && csl.Spec.User == username) {
+		return false, nil
+	}
+
+	// Either of these may have been deleted by the time we look, in which case the
+	// console can no longer be authorised.
+	tpl := &workloadsv1alpha1.ConsoleTemplate{}
+	err := c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Spec.ConsoleTemplateRef.Name}, tpl)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	// The authorisation object has the same name as the console
+	authz := &workloadsv1alpha1.ConsoleAuthorisation{}
+	err = c.kubeClient.Get(ctx, client.ObjectKey{Namespace: csl.Namespace, Name: csl.Name}, authz)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	return CanAuthoriseConsole(csl, tpl, authz, username, groups)
+}
+
+// CanAuthoriseConsole applies the rules of CanAuthorise to a console whose template
+// and authorisation object have already been fetched, so that fakes can share them
+// without talking to the API server.
+func CanAuthoriseConsole(csl *workloadsv1alpha1.Console, tpl *workloadsv1alpha1.ConsoleTemplate, authz *workloadsv1alpha1.ConsoleAuthorisation, username string, groups []string) (bool, error) {
+	if !csl.PendingAuthorisation() || (username != "" && csl.Spec.User == username) {
+		return false, nil
+	}
+
+	if tpl == nil || authz == nil {
+		return false, nil
+	}
+
+	command, err := csl.GetCommand(tpl)
+	if err != nil {
+		return false, err
+	}
+
+	rule, err := tpl.GetAuthorisationRuleForCommand(command)
+	if err != nil {
+		return false, err
+	}
+
+	if !subjectsInclude(rule.Subjects, username, groups) {
+		return false, nil
+	}
+
+	for _, subject := range authz.Spec.Authorisations {
+		if username != "" && subject.Kind == rbacv1.UserKind && subject.Name == username {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func subjectsInclude(subjects []rbacv1.Subject, username string, groups []string) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if username != "" && subject.Name == username {
+				return true
+			}
+		case rbacv1.GroupKind:
+			for _, group := range groups {
+				if subject.Name == group {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// toConsole converts an object received by the informer into a console. Deleted objects
+// may arrive wrapped in a tombstone, if we missed the delete event.
+func toConsole(obj interface{}) (*workloadsv1alpha1.Console, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, false
+	}
+
+	csl := &workloadsv1alpha1.Console{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), csl); err != nil {
+		return nil, false
+	}
+
+	return csl, true
+}
+
+// watchPrinter prints each console as we observe it. Tables can't be aligned in advance
+// as we don't know what's coming, so we flush every row as it arrives.
+type watchPrinter struct {
+	output        io.Writer
+	format        string
+	table         *tabwriter.Writer
+	printedHeader bool
+}
+
+func (p *watchPrinter) Print(csl *workloadsv1alpha1.Console, deleted bool) {
+	switch p.format {
+	case OutputFormatTable, OutputFormatWide:
+	default:
+		if err := PrintConsole(p.output, csl, PrintOptions{Format: p.format}); err != nil {
+			fmt.Fprintf(p.output, "error printing console %s/%s: %v\n", csl.Namespace, csl.Name, err)
+		}
+		return
+	}
+
+	if !p.printedHeader {
+		fmt.Fprintln(p.table, "TIME\tNAME\tNAMESPACE\tPHASE\tUSER\tTEMPLATE\tREASON")
+		p.printedHeader = true
+	}
+
+	phase := string(csl.Status.Phase)
+	if deleted {
+		phase = "Deleted"
+	}
+
+	fmt.Fprintf(p.table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		time.Now().Format("15:04:05"),
+		csl.Name,
+		csl.Namespace,
+		phase,
+		csl.Spec.User,
+		csl.Spec.ConsoleTemplateRef.Name,
+		csl.Spec.Reason,
+	)
+
+	p.table.Flush()
+}